@@ -2,6 +2,8 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"os"
 
 	"go.uber.org/zap"
@@ -12,6 +14,18 @@ import (
 )
 
 func main() {
+	// Offline tools: no BOT_TOKEN or database needed.
+	if len(os.Args) > 1 && os.Args[1] == "simulate" {
+		err := runSimulate(os.Args[2:], os.Stdout, os.Stderr)
+		switch {
+		case errors.Is(err, flag.ErrHelp):
+		case err != nil:
+			_, _ = os.Stderr.WriteString("simulate: " + err.Error() + "\n")
+			os.Exit(2)
+		}
+		return
+	}
+
 	cfg, err := config.Load()
 	if err != nil {
 		// No logger yet; exit immediately.
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ykvlv/notification-bot/internal/domain"
+)
+
+const dateLayout = "2006-01-02"
+
+// simFire is one simulated fire time.
+type simFire struct {
+	Local time.Time `json:"local"`
+	UTC   time.Time `json:"utc"`
+}
+
+// runSimulate implements "notification-bot simulate": it prints every fire
+// time domain.NextFire produces for the given settings and date range. It
+// needs neither BOT_TOKEN nor a database.
+func runSimulate(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("simulate", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var (
+		tz       = fs.String("tz", "UTC", "IANA timezone, e.g. Europe/Moscow")
+		interval = fs.String("interval", "1h", "interval between reminders, e.g. 30m, 1h30m")
+		hours    = fs.String("hours", "09:00-21:00", "active hours HH:MM-HH:MM (may wrap midnight)")
+		fromStr  = fs.String("from", "", "first day, YYYY-MM-DD in -tz (default today)")
+		toStr    = fs.String("to", "", "last day, inclusive (default 7 days from -from)")
+		jitter   = fs.Duration("jitter", 0, "per-user offset added to every slot, e.g. 2m30s")
+		format   = fs.String("format", "table", "output format: table, csv or json")
+		limit    = fs.Int("limit", 10000, "maximum number of fire times")
+	)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: notification-bot simulate [flags]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+
+	loc, err := time.LoadLocation(*tz)
+	if err != nil {
+		return fmt.Errorf("-tz: %w", err)
+	}
+	every, err := domain.ParseDurationHuman(*interval)
+	if err != nil {
+		return fmt.Errorf("-interval: %w", err)
+	}
+	fromM, toM, err := domain.ParseActiveWindow(*hours)
+	if err != nil {
+		return fmt.Errorf("-hours: %w", err)
+	}
+	if *jitter < 0 || *jitter > domain.MaxJitter {
+		return fmt.Errorf("-jitter: must be between 0 and %s", domain.MaxJitter)
+	}
+
+	now := time.Now().In(loc)
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	if *fromStr != "" {
+		if from, err = time.ParseInLocation(dateLayout, *fromStr, loc); err != nil {
+			return fmt.Errorf("-from: %w", err)
+		}
+	}
+	last := from.AddDate(0, 0, 6)
+	if *toStr != "" {
+		if last, err = time.ParseInLocation(dateLayout, *toStr, loc); err != nil {
+			return fmt.Errorf("-to: %w", err)
+		}
+	}
+	to := last.AddDate(0, 0, 1)
+	if !to.After(from) {
+		return errors.New("-to is before -from")
+	}
+
+	u := &domain.User{
+		TZ:          loc.String(),
+		IntervalSec: int(every.Seconds()),
+		ActiveFromM: fromM,
+		ActiveToM:   toM,
+		JitterSec:   int(jitter.Seconds()),
+	}
+	var fires []simFire
+	for _, t := range domain.FireTimes(u, from, to, *limit) {
+		fires = append(fires, simFire{Local: t.In(loc), UTC: t.UTC()})
+	}
+	return writeFires(stdout, *format, fires)
+}
+
+// writeFires prints fire times in the requested format.
+func writeFires(w io.Writer, format string, fires []simFire) error {
+	switch format {
+	case "table":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "#\tLOCAL\tUTC")
+		for i, f := range fires {
+			fmt.Fprintf(tw, "%d\t%s\t%s\n", i+1,
+				f.Local.Format("Mon 2006-01-02 15:04:05 MST"), f.UTC.Format("2006-01-02 15:04:05"))
+		}
+		return tw.Flush()
+	case "csv":
+		cw := csv.NewWriter(w)
+		_ = cw.Write([]string{"n", "local", "utc"})
+		for i, f := range fires {
+			_ = cw.Write([]string{strconv.Itoa(i + 1), f.Local.Format(time.RFC3339), f.UTC.Format(time.RFC3339)})
+		}
+		cw.Flush()
+		return cw.Error()
+	case "json":
+		if fires == nil {
+			fires = []simFire{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(fires)
+	}
+	return fmt.Errorf("-format: unknown format %q", format)
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestSimulate_CSVAcrossFallBack(t *testing.T) {
+	// Europe/Berlin repeats 02:00–03:00 on 2025-10-26: 02:00 fires twice.
+	var out bytes.Buffer
+	err := runSimulate([]string{
+		"-tz", "Europe/Berlin", "-interval", "1h", "-hours", "01:00-04:00",
+		"-from", "2025-10-26", "-to", "2025-10-26", "-format", "csv",
+	}, &out, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `n,local,utc
+1,2025-10-26T01:00:00+02:00,2025-10-25T23:00:00Z
+2,2025-10-26T02:00:00+02:00,2025-10-26T00:00:00Z
+3,2025-10-26T02:00:00+01:00,2025-10-26T01:00:00Z
+4,2025-10-26T03:00:00+01:00,2025-10-26T02:00:00Z
+5,2025-10-26T04:00:00+01:00,2025-10-26T03:00:00Z
+`
+	if out.String() != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
+
+func TestSimulate_JSONAndErrors(t *testing.T) {
+	var out bytes.Buffer
+	err := runSimulate([]string{"-hours", "09:00-10:00", "-from", "2025-01-01", "-to", "2025-01-02", "-format", "json"}, &out, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fires []simFire
+	if err := json.Unmarshal(out.Bytes(), &fires); err != nil || len(fires) != 4 {
+		t.Fatalf("want 4 fires, got %d (%v): %s", len(fires), err, out.String())
+	}
+
+	for _, c := range []struct {
+		args []string
+		flag string
+	}{
+		{[]string{"-tz", "Mars/Olympus"}, "-tz"},
+		{[]string{"-interval", "1m"}, "-interval"},
+		{[]string{"-hours", "9-10"}, "-hours"},
+		{[]string{"-from", "2025-01-02", "-to", "2025-01-01"}, "-to"},
+		{[]string{"-format", "xml"}, "-format"},
+	} {
+		if err := runSimulate(c.args, io.Discard, io.Discard); err == nil || !strings.HasPrefix(err.Error(), c.flag) {
+			t.Fatalf("%v: want an error about %s, got %v", c.args, c.flag, err)
+		}
+	}
+}
@@ -3,9 +3,11 @@ package app
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
@@ -13,6 +15,9 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/ykvlv/notification-bot/internal/config"
+	"github.com/ykvlv/notification-bot/internal/domain"
+	"github.com/ykvlv/notification-bot/internal/httpapi"
+	"github.com/ykvlv/notification-bot/internal/notify"
 	"github.com/ykvlv/notification-bot/internal/scheduler"
 	"github.com/ykvlv/notification-bot/internal/store"
 	"github.com/ykvlv/notification-bot/internal/telegram"
@@ -23,12 +28,17 @@ type App struct {
 	log     *zap.Logger
 	bot     *tgbotapi.BotAPI
 	httpSrv *http.Server
+	mux     *http.ServeMux
 	repo    store.Repo
 	router  *telegram.Router
+	workers sync.WaitGroup // scheduler, webhook deliveries and sweeps; stopped before the DB closes
 }
 
 func New(cfg config.Config, log *zap.Logger) (*App, error) {
-	bot, err := tgbotapi.NewBotAPI(cfg.BotToken)
+	if cfg.RunMode != "polling" && cfg.RunMode != "webhook" {
+		return nil, fmt.Errorf("RUN_MODE must be polling or webhook, got %q", cfg.RunMode)
+	}
+	bot, err := tgbotapi.NewBotAPIWithClient(cfg.BotToken, cfg.TelegramAPIEndpoint, &http.Client{})
 	if err != nil {
 		return nil, err
 	}
@@ -40,10 +50,10 @@ func New(cfg config.Config, log *zap.Logger) (*App, error) {
 		Addr:         cfg.HTTPAddr,
 		Handler:      mux,
 		ReadTimeout:  3 * time.Second,
-		WriteTimeout: 3 * time.Second,
+		WriteTimeout: 30 * time.Second, // exports can be large
 	}
 
-	return &App{cfg: cfg, log: log, bot: bot, httpSrv: srv}, nil
+	return &App{cfg: cfg, log: log, bot: bot, httpSrv: srv, mux: mux}, nil
 }
 
 func (a *App) Run(ctx context.Context) error {
@@ -53,6 +63,11 @@ func (a *App) Run(ctx context.Context) error {
 		zap.String("bot_username", a.bot.Self.UserName),
 	)
 
+	// OS signals for graceful shutdown. Background workers run on this
+	// context too, so a signal stops them before the DB is closed.
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	// Open SQLite and run migrations.
 	repo, err := store.OpenSQLite(ctx, a.cfg.DBPath)
 	if err != nil {
@@ -62,69 +77,218 @@ func (a *App) Run(ctx context.Context) error {
 	a.repo = repo
 	a.log.Info("sqlite ready")
 
+	// Admin HTTP endpoints (disabled unless ADMIN_TOKEN is set).
+	if a.cfg.AdminToken != "" {
+		a.mux.Handle("/admin/deliveries", httpapi.RequireToken(a.cfg.AdminToken, httpapi.DeliveriesExport(a.repo, a.log)))
+	}
+
 	// Router (Telegram handlers)
-	a.router = telegram.NewRouter(a.bot, a.log, a.repo)
+	a.router = telegram.NewRouter(a.bot, a.log, a.repo, a.cfg.AdminIDs)
+	if err := telegram.RegisterCommands(a.bot); err != nil {
+		// The bot works without a command menu.
+		a.log.Warn("command menu registration failed", zap.Error(err))
+	}
+
+	// Lapsed prompts are deleted in the background, not per message.
+	a.workers.Add(1)
+	go func() {
+		defer a.workers.Done()
+		a.router.SweepConversations(ctx)
+	}()
+
+	// Notification channels: Telegram by default, others per user.
+	sender, err := a.newSender()
+	if err != nil {
+		a.log.Error("notification channels setup failed", zap.Error(err))
+		return err
+	}
 
 	// Start scheduler in background.
-	sch := scheduler.New(a.repo, a.log, a.router)
-	go sch.Run(ctx)
+	sch := scheduler.New(a.repo, a.log, sender, scheduler.Config{
+		Dispatch: scheduler.DispatchConfig{
+			Workers:         a.cfg.SendWorkers,
+			RatePerSec:      a.cfg.SendRatePerSec,
+			Burst:           a.cfg.SendBurst,
+			PerChatInterval: a.cfg.SendPerChatInterval,
+		},
+		Retry: scheduler.RetryPolicy{
+			MaxAttempts: a.cfg.SendMaxAttempts,
+			BaseDelay:   a.cfg.SendRetryBase,
+			MaxDelay:    a.cfg.SendRetryMax,
+		},
+		InstanceID: a.cfg.InstanceID,
+		LeaseTTL:   a.cfg.LeaseTTL,
+		TickBudget: a.cfg.TickBudget,
+	})
+	a.router.SetNotifier(sch)
+	// Metrics share the public listener with the webhook, so they are an
+	// admin endpoint too.
+	if a.cfg.AdminToken != "" {
+		a.mux.Handle("/metrics", httpapi.RequireToken(a.cfg.AdminToken, httpapi.Metrics(sch.Metrics())))
+	}
+	a.workers.Add(1)
+	go func() {
+		defer a.workers.Done()
+		sch.Run(ctx) // waits for its dispatcher
+	}()
+
+	// Outgoing webhooks for sent reminders.
+	hooks := notify.NewHooks(a.repo, a.log, notify.HooksConfig{
+		Client: a.webhookClient(),
+		Retry: scheduler.RetryPolicy{
+			MaxAttempts: a.cfg.WebhookMaxAttempts,
+			BaseDelay:   a.cfg.WebhookRetryBase,
+			MaxDelay:    a.cfg.WebhookRetryMax,
+		},
+		LeaseTTL: a.cfg.LeaseTTL,
+	})
+	a.workers.Add(1)
+	go func() {
+		defer a.workers.Done()
+		hooks.Run(ctx)
+	}()
 
 	// Start HTTP server.
 	go func() {
-		if err := a.httpSrv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		var err error
+		if a.serveTLS() {
+			err = a.httpSrv.ListenAndServeTLS(a.cfg.TelegramWebhookCert, a.cfg.TelegramWebhookKey)
+		} else {
+			err = a.httpSrv.ListenAndServe()
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
 			a.log.Error("http server error", zap.Error(err))
 		}
 	}()
 
 	// Prepare Telegram updates channel.
-	u := tgbotapi.NewUpdate(0)
-	u.Timeout = 30
-	updCh := a.bot.GetUpdatesChan(u)
-
-	// OS signals for graceful shutdown.
-	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
-	defer stop()
+	updCh, stopUpdates, err := a.startUpdates()
+	if err != nil {
+		a.log.Error("telegram updates setup failed", zap.Error(err))
+		stop()
+		a.shutdown(func() {})
+		return err
+	}
 
 	for {
 		select {
 		case <-ctx.Done():
 			a.log.Info("shutdown signal received")
-
-			// Stop receiving Telegram updates and close channel.
-			a.bot.StopReceivingUpdates()
-
-			// Shutdown HTTP server.
-			shCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-			err := a.httpSrv.Shutdown(shCtx)
-			cancel()
-			if err != nil {
-				a.log.Warn("http server shutdown error", zap.Error(err))
-			}
-
-			// Close DB.
-			if a.repo != nil {
-				_ = a.repo.Close()
-			}
+			a.shutdown(stopUpdates)
 			return nil
 
 		case upd, ok := <-updCh:
 			if !ok {
 				// Channel closed by StopReceivingUpdates or internal error.
 				a.log.Info("updates channel closed")
-				// Proceed to graceful shutdown path.
-				a.bot.StopReceivingUpdates()
-				shCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-				err := a.httpSrv.Shutdown(shCtx)
-				cancel()
-				if err != nil {
-					a.log.Warn("http server shutdown error", zap.Error(err))
-				}
-				if a.repo != nil {
-					_ = a.repo.Close()
-				}
+				stop()
+				a.shutdown(stopUpdates)
 				return nil
 			}
 			a.router.HandleUpdate(ctx, upd)
 		}
 	}
 }
+
+// startUpdates starts receiving Telegram updates in the configured run mode
+// and returns the updates channel and a function that stops receiving.
+func (a *App) startUpdates() (<-chan telegram.Update, func(), error) {
+	if a.cfg.RunMode == "polling" {
+		updCh, stop := telegram.PollUpdates(a.bot, a.log, 30)
+		return updCh, stop, nil
+	}
+
+	secret := a.cfg.TelegramWebhookSecret
+	if secret == "" {
+		var err error
+		if secret, err = telegram.NewSecretToken(); err != nil {
+			return nil, nil, err
+		}
+	}
+	updCh := make(chan telegram.Update, a.bot.Buffer)
+	a.mux.Handle(a.cfg.TelegramWebhookPath, telegram.WebhookHandler(secret, updCh))
+
+	wh := telegram.WebhookConfig{
+		URL:            a.cfg.TelegramWebhookURL,
+		Path:           a.cfg.TelegramWebhookPath,
+		SecretToken:    secret,
+		CertFile:       a.cfg.TelegramWebhookCert,
+		MaxConnections: a.cfg.TelegramWebhookMaxConnections,
+	}
+	if err := telegram.SetWebhook(a.bot, wh); err != nil {
+		return nil, nil, fmt.Errorf("setWebhook: %w", err)
+	}
+	endpoint, _ := wh.Endpoint()
+	a.log.Info("webhook registered", zap.String("url", endpoint), zap.Bool("certificate", wh.CertFile != ""))
+
+	stop := func() {}
+	if a.cfg.TelegramWebhookDeleteOnStop {
+		stop = func() {
+			if err := telegram.DeleteWebhook(a.bot); err != nil {
+				a.log.Warn("deleteWebhook failed", zap.Error(err))
+			}
+		}
+	}
+	return updCh, stop, nil
+}
+
+// serveTLS reports whether the HTTP server terminates TLS itself.
+func (a *App) serveTLS() bool {
+	return a.cfg.RunMode == "webhook" && a.cfg.TelegramWebhookCert != "" && a.cfg.TelegramWebhookKey != ""
+}
+
+// shutdown stops receiving updates, then stops the HTTP server, waits for the
+// background workers (their context must be canceled already) and closes the
+// DB.
+func (a *App) shutdown(stopUpdates func()) {
+	stopUpdates()
+
+	shCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	err := a.httpSrv.Shutdown(shCtx)
+	cancel()
+	if err != nil {
+		a.log.Warn("http server shutdown error", zap.Error(err))
+	}
+
+	a.workers.Wait()
+	if a.repo != nil {
+		_ = a.repo.Close()
+	}
+}
+
+// newSender builds the scheduler's sender: a notify.Mux that routes each
+// chat to its stored channel and falls back to the Telegram router.
+func (a *App) newSender() (*notify.Mux, error) {
+	mux := notify.NewMux(a.repo, a.log, a.router)
+	mux.Handle(domain.ChannelWebhook, notify.NewWebhook(a.webhookClient()))
+	mux.Handle(domain.ChannelBroadcast, telegram.NewBroadcast(a.bot, a.repo))
+	if a.cfg.SMTPAddr != "" {
+		smtp := notify.NewSMTP(notify.SMTPConfig{
+			Addr:     a.cfg.SMTPAddr,
+			From:     a.cfg.SMTPFrom,
+			Username: a.cfg.SMTPUsername,
+			Password: a.cfg.SMTPPassword,
+		})
+		mux.Handle(domain.ChannelEmail, smtp)
+		a.router.SetMailer(smtp) // confirmation codes for new addresses
+	}
+	if a.cfg.NotifyFile != "" {
+		sink, err := notify.OpenFileSink(a.cfg.NotifyFile)
+		if err != nil {
+			return nil, err
+		}
+		mux.Handle(domain.ChannelFile, sink)
+		if a.cfg.NotifyDryRun {
+			a.log.Info("dry run: all reminders go to the file sink", zap.String("file", a.cfg.NotifyFile))
+			mux.SetDryRun(sink)
+		}
+	} else if a.cfg.NotifyDryRun {
+		return nil, errors.New("NOTIFY_DRY_RUN requires NOTIFY_FILE")
+	}
+	return mux, nil
+}
+
+// webhookClient returns the HTTP client for user-supplied URLs.
+func (a *App) webhookClient() *http.Client {
+	return notify.NewHTTPClient(10*time.Second, a.cfg.WebhookAllowPrivate)
+}
@@ -1,15 +1,80 @@
 package config
 
-import "github.com/kelseyhightower/envconfig"
+import (
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+)
 
 // Config holds application configuration loaded from environment variables.
 type Config struct {
 	BotToken  string `envconfig:"BOT_TOKEN" required:"true"`
 	DBPath    string `envconfig:"DB_PATH" default:"./data/notification.db"`
 	DefaultTZ string `envconfig:"DEFAULT_TZ" default:"Europe/Moscow"`
-	RunMode   string `envconfig:"RUN_MODE" default:"polling"` // polling|webhook (MVP: polling)
+	RunMode   string `envconfig:"RUN_MODE" default:"polling"` // polling|webhook
 	LogLevel  string `envconfig:"LOG_LEVEL" default:"info"`   // debug|info|warn|error
-	HTTPAddr  string `envconfig:"HTTP_ADDR" default:":8080"`  // healthz (future-proof)
+	HTTPAddr  string `envconfig:"HTTP_ADDR" default:":8080"`  // healthz, metrics, admin API, Telegram webhook
+
+	// Bot API endpoint format (token, method); override to use a local Bot API
+	// server or a stub.
+	TelegramAPIEndpoint string `envconfig:"TELEGRAM_API_ENDPOINT" default:"https://api.telegram.org/bot%s/%s"`
+
+	// RUN_MODE=webhook: Telegram POSTs updates to TELEGRAM_WEBHOOK_URL +
+	// TELEGRAM_WEBHOOK_PATH on HTTP_ADDR. An empty secret is generated at
+	// startup. With both CERT and KEY set the HTTP server serves TLS itself;
+	// CERT alone is only uploaded (TLS terminated by a proxy). The webhook
+	// is left registered on shutdown, since other replicas may still serve
+	// it, unless DELETE_ON_STOP is set.
+	TelegramWebhookURL            string `envconfig:"TELEGRAM_WEBHOOK_URL"`
+	TelegramWebhookPath           string `envconfig:"TELEGRAM_WEBHOOK_PATH" default:"/telegram/webhook"`
+	TelegramWebhookSecret         string `envconfig:"TELEGRAM_WEBHOOK_SECRET"`
+	TelegramWebhookCert           string `envconfig:"TELEGRAM_WEBHOOK_CERT"`
+	TelegramWebhookKey            string `envconfig:"TELEGRAM_WEBHOOK_KEY"`
+	TelegramWebhookMaxConnections int    `envconfig:"TELEGRAM_WEBHOOK_MAX_CONNECTIONS" default:"40"`
+	TelegramWebhookDeleteOnStop   bool   `envconfig:"TELEGRAM_WEBHOOK_DELETE_ON_STOP" default:"false"`
+
+	// Outgoing message dispatch (Telegram limits: ~30 msg/s global, ~1 msg/s per chat).
+	SendWorkers         int           `envconfig:"SEND_WORKERS" default:"8"`
+	SendRatePerSec      float64       `envconfig:"SEND_RATE_PER_SEC" default:"30"`
+	SendBurst           int           `envconfig:"SEND_BURST" default:"1"`
+	SendPerChatInterval time.Duration `envconfig:"SEND_PER_CHAT_INTERVAL" default:"1s"`
+
+	// Failed sends are retried with exponential backoff, then dead-lettered.
+	SendMaxAttempts int           `envconfig:"SEND_MAX_ATTEMPTS" default:"5"`
+	SendRetryBase   time.Duration `envconfig:"SEND_RETRY_BASE" default:"30s"`
+	SendRetryMax    time.Duration `envconfig:"SEND_RETRY_MAX" default:"30m"`
+
+	// Multi-instance operation: each replica needs a unique ID (default: host-pid).
+	// Deliveries are leased to the sending instance for LeaseTTL.
+	InstanceID string        `envconfig:"INSTANCE_ID"`
+	LeaseTTL   time.Duration `envconfig:"LEASE_TTL" default:"5m"`
+
+	// Longest a single pass over due reminders may run before the scheduler
+	// yields to settings changes; the rest is sent right after.
+	TickBudget time.Duration `envconfig:"TICK_BUDGET" default:"1s"`
+
+	// Extra notification channels. Email is available when SMTP_ADDR is set,
+	// the file sink when NOTIFY_FILE is set ("-" for stdout). With
+	// NOTIFY_DRY_RUN every reminder goes to the file sink instead.
+	SMTPAddr     string `envconfig:"SMTP_ADDR"`
+	SMTPFrom     string `envconfig:"SMTP_FROM"`
+	SMTPUsername string `envconfig:"SMTP_USERNAME"`
+	SMTPPassword string `envconfig:"SMTP_PASSWORD"`
+	NotifyFile   string `envconfig:"NOTIFY_FILE"`
+	NotifyDryRun bool   `envconfig:"NOTIFY_DRY_RUN" default:"false"`
+
+	// Outgoing webhooks (/webhooks): retries for each POST. User-supplied
+	// URLs (webhooks and the webhook channel) may not point at private
+	// addresses unless WEBHOOK_ALLOW_PRIVATE is set.
+	WebhookMaxAttempts  int           `envconfig:"WEBHOOK_MAX_ATTEMPTS" default:"8"`
+	WebhookRetryBase    time.Duration `envconfig:"WEBHOOK_RETRY_BASE" default:"10s"`
+	WebhookRetryMax     time.Duration `envconfig:"WEBHOOK_RETRY_MAX" default:"1h"`
+	WebhookAllowPrivate bool          `envconfig:"WEBHOOK_ALLOW_PRIVATE" default:"false"`
+
+	// Telegram user IDs allowed to run admin commands (comma-separated).
+	AdminIDs []int64 `envconfig:"ADMIN_IDS"`
+	// Bearer token for admin HTTP endpoints (/admin/...); empty disables them.
+	AdminToken string `envconfig:"ADMIN_TOKEN"`
 }
 
 // Load reads environment variables into Config.
@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"errors"
+	"net/mail"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Channel kinds. Telegram is the default for users without a channel row.
+const (
+	ChannelTelegram = "telegram" // the chat itself
+	ChannelEmail    = "email"    // target is an email address
+	ChannelWebhook  = "webhook"  // target is an http(s) URL receiving a JSON POST
+	ChannelFile     = "file"     // the operator's file/stdout sink; target unused
+	// ChannelBroadcast posts to a Telegram channel the bot is an admin of;
+	// target is the channel's chat ID.
+	ChannelBroadcast = "broadcast"
+)
+
+// Channel is where a user's reminders are delivered.
+type Channel struct {
+	ChatID    int64
+	Kind      string // one of the Channel* constants
+	Target    string // address or URL, depending on Kind
+	UpdatedAt time.Time
+}
+
+var (
+	ErrUnknownChannel = errors.New("unknown channel kind")
+	ErrBadTarget      = errors.New("invalid channel target")
+)
+
+// ValidateChannelTarget checks and normalizes target for the given kind.
+func ValidateChannelTarget(kind, target string) (string, error) {
+	switch kind {
+	case ChannelTelegram, ChannelFile:
+		return "", nil
+	case ChannelEmail:
+		addr, err := mail.ParseAddress(target)
+		if err != nil {
+			return "", ErrBadTarget
+		}
+		return addr.Address, nil
+	case ChannelWebhook:
+		u, err := url.Parse(target)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return "", ErrBadTarget
+		}
+		return u.String(), nil
+	case ChannelBroadcast:
+		id, err := strconv.ParseInt(target, 10, 64)
+		if err != nil || id >= 0 {
+			return "", ErrBadTarget
+		}
+		return strconv.FormatInt(id, 10), nil
+	}
+	return "", ErrUnknownChannel
+}
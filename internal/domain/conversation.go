@@ -0,0 +1,17 @@
+package domain
+
+import "time"
+
+// Conversation is a chat member's pending free-form input, e.g. a custom
+// interval they were asked to type.
+type Conversation struct {
+	ChatID    int64
+	UserID    int64
+	State     string    // what the next message is read as
+	ExpiresAt time.Time // UTC; after this the input is no longer expected
+}
+
+// Expired reports whether the pending input has lapsed at now.
+func (c *Conversation) Expired(now time.Time) bool {
+	return !now.Before(c.ExpiresAt)
+}
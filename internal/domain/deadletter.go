@@ -0,0 +1,16 @@
+package domain
+
+import "time"
+
+// DeadLetter is a reminder that could not be delivered after all retry
+// attempts. It is kept for inspection and manual replay.
+type DeadLetter struct {
+	ID          int64
+	ChatID      int64
+	Message     string
+	ScheduledAt time.Time  // UTC, the slot that failed
+	Attempts    int        // number of failed attempts
+	LastError   string     // error from the final attempt
+	CreatedAt   time.Time  // UTC
+	ReplayedAt  *time.Time // UTC, nullable; set once queued for delivery again
+}
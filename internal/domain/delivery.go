@@ -0,0 +1,34 @@
+package domain
+
+import "time"
+
+// Delivery statuses. A delivery moves pending → sending → sent, or back to
+// pending for a retry, and ends as sent, failed or canceled. A delivery
+// whose sender crashed (its lease expired in sending) becomes unknown and is
+// not re-sent.
+const (
+	DeliveryPending  = "pending"  // claimed, waiting for its (next) attempt
+	DeliverySending  = "sending"  // attempt in flight
+	DeliverySent     = "sent"     // accepted by Telegram
+	DeliveryFailed   = "failed"   // gave up: retries exhausted or recipient unreachable
+	DeliveryCanceled = "canceled" // user paused before the attempt
+	DeliveryUnknown  = "unknown"  // process stopped mid-send; outcome not confirmed
+)
+
+// Delivery is one reminder handed to the outbox, kept afterwards as history.
+type Delivery struct {
+	ID            int64
+	ChatID        int64
+	Message       string     // reminder text as sent
+	ScheduledAt   time.Time  // UTC, the slot (next_fire_at) being delivered
+	SentAt        *time.Time // UTC, nullable; when Telegram accepted it
+	MessageID     int        // Telegram message_id; 0 if not sent
+	Status        string     // one of the Delivery* constants
+	Error         string     // last failure reason; empty on success
+	Attempts      int        // send attempts started so far
+	NextAttemptAt *time.Time // UTC, nullable; when a pending delivery is due
+	ClaimedBy     string     // instance ID holding the send lease; empty if none
+	ClaimExpires  *time.Time // UTC, nullable; when the send lease runs out
+	AckedAt       *time.Time // UTC, nullable; when the user pressed "Done"
+	CreatedAt     time.Time  // UTC, when the delivery was claimed
+}
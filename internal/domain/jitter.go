@@ -0,0 +1,25 @@
+package domain
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"time"
+)
+
+// MaxJitter bounds the per-user jitter an admin can configure.
+const MaxJitter = 15 * time.Minute
+
+// JitterFor returns a deterministic offset in seconds within [0, maxJitter] for a
+// chat, derived from a hash of its ID. It spreads users who share a window
+// start over maxJitter instead of firing them all on the same minute.
+func JitterFor(chatID int64, maxJitter time.Duration) int {
+	sec := int64(maxJitter / time.Second)
+	if sec <= 0 {
+		return 0
+	}
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], uint64(chatID))
+	h := fnv.New64a()
+	_, _ = h.Write(b[:])
+	return int(h.Sum64() % uint64(sec+1))
+}
@@ -140,3 +140,21 @@ func LocalizeTime(t time.Time, tz string) (string, error) {
 	lt := t.In(loc)
 	return lt.Format("15:04"), nil
 }
+
+// LocalizeTimeSec formats t in user's timezone as HH:MM:SS.
+func LocalizeTimeSec(t time.Time, tz string) (string, error) {
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return "", err
+	}
+	return t.In(loc).Format("15:04:05"), nil
+}
+
+// LocalizeDateTime formats t in user's timezone as YYYY-MM-DD HH:MM.
+func LocalizeDateTime(t time.Time, tz string) (string, error) {
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return "", err
+	}
+	return t.In(loc).Format("2006-01-02 15:04"), nil
+}
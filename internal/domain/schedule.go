@@ -23,7 +23,19 @@ func InWindow(localM, fromM, toM int) bool {
 //
 // If that slot falls outside the current window, schedule the start of the next window.
 // If now is outside the window, schedule at the next window start.
+//
+// A user's JitterSec shifts the whole schedule (window start and every slot)
+// later by that many seconds.
 func NextFire(nowUTC time.Time, u *User) time.Time {
+	if u.JitterSec > 0 {
+		j := time.Duration(u.JitterSec) * time.Second
+		return nextFire(nowUTC.Add(-j), u).Add(j)
+	}
+	return nextFire(nowUTC, u)
+}
+
+// nextFire is NextFire without jitter.
+func nextFire(nowUTC time.Time, u *User) time.Time {
 	loc, err := time.LoadLocation(u.TZ)
 	if err != nil {
 		loc = time.UTC
@@ -113,3 +125,21 @@ func NextFire(nowUTC time.Time, u *User) time.Time {
 
 	return nextLocal.UTC()
 }
+
+// FireTimes returns the user's fire times in [from, to), at most limit of
+// them, by chaining NextFire the way the scheduler does after each send.
+func FireTimes(u *User, from, to time.Time, limit int) []time.Time {
+	var res []time.Time
+	// NextFire returns slots strictly after now; step back so a slot at
+	// exactly `from` is included.
+	now := from.Add(-time.Nanosecond)
+	for len(res) < limit {
+		next := NextFire(now, u)
+		if !next.Before(to) {
+			break
+		}
+		res = append(res, next)
+		now = next
+	}
+	return res
+}
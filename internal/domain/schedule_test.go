@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"strings"
 	"testing"
 	"time"
 )
@@ -88,3 +89,78 @@ func TestNextFire_WrapWindow_MorningSegment(t *testing.T) {
 		t.Fatalf("want 02:00, got %s", got)
 	}
 }
+
+func TestJitterFor_DeterministicAndBounded(t *testing.T) {
+	const maxJitter = 5 * time.Minute
+	seen := map[int]bool{}
+	for id := int64(-500); id < 500; id++ {
+		j := JitterFor(id, maxJitter)
+		if j < 0 || j > int(maxJitter.Seconds()) {
+			t.Fatalf("chat %d: jitter %ds out of [0, %s]", id, j, maxJitter)
+		}
+		if j != JitterFor(id, maxJitter) {
+			t.Fatalf("chat %d: jitter not deterministic", id)
+		}
+		seen[j] = true
+	}
+	// 1000 chats over 301 possible offsets should spread widely.
+	if len(seen) < 200 {
+		t.Fatalf("only %d distinct offsets", len(seen))
+	}
+	if j := JitterFor(42, 0); j != 0 {
+		t.Fatalf("jitter off: got %d", j)
+	}
+}
+
+func TestNextFire_JitterShiftsSlots(t *testing.T) {
+	u := &User{
+		ChatID:      1,
+		Enabled:     true,
+		TZ:          "UTC",
+		IntervalSec: int(time.Hour.Seconds()),
+		ActiveFromM: 9 * 60,
+		ActiveToM:   11 * 60,
+		JitterSec:   150,
+	}
+	day := time.Date(2025, time.May, 5, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		now, want time.Duration // offsets from midnight
+	}{
+		{8 * time.Hour, 9*time.Hour + 150*time.Second},                                 // before window
+		{9*time.Hour + time.Minute, 9*time.Hour + 150*time.Second},                     // window start is shifted too
+		{9*time.Hour + 150*time.Second, 10*time.Hour + 150*time.Second},                // strictly after now
+		{10*time.Hour + 150*time.Second, 11*time.Hour + 150*time.Second},               // last slot of the window
+		{11*time.Hour + 150*time.Second, 24*time.Hour + 9*time.Hour + 150*time.Second}, // next day
+	}
+	for _, c := range cases {
+		got := NextFire(day.Add(c.now), u)
+		if want := day.Add(c.want); !got.Equal(want) {
+			t.Fatalf("now %s: want %s, got %s", day.Add(c.now).Format(time.TimeOnly), want, got)
+		}
+	}
+}
+
+func TestFireTimes(t *testing.T) {
+	u := &User{
+		TZ:          "Europe/Berlin",
+		IntervalSec: int(time.Hour.Seconds()),
+		ActiveFromM: 0,
+		ActiveToM:   5 * 60,
+	}
+	loc, _ := time.LoadLocation(u.TZ)
+	from := time.Date(2025, time.March, 3, 0, 0, 0, 0, loc)
+	got := FireTimes(u, from, from.AddDate(0, 0, 1), 100)
+
+	var local []string
+	for _, t := range got {
+		local = append(local, t.In(loc).Format("15:04"))
+	}
+	// A slot exactly at `from` is included; the next window starts tomorrow.
+	want := []string{"00:00", "01:00", "02:00", "03:00", "04:00", "05:00"}
+	if strings.Join(local, " ") != strings.Join(want, " ") {
+		t.Fatalf("want %v, got %v", want, local)
+	}
+	if n := len(FireTimes(u, from, from.AddDate(0, 0, 1), 2)); n != 2 {
+		t.Fatalf("limit ignored: %d", n)
+	}
+}
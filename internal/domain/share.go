@@ -0,0 +1,16 @@
+package domain
+
+import "time"
+
+// Share is a snapshot of reminder settings behind a /share link, so that
+// settings too long for a deep link (such as a custom text) can be passed
+// on by code.
+type Share struct {
+	Code        string // derived from the settings; equal settings share a code
+	IntervalSec int
+	ActiveFromM int
+	ActiveToM   int
+	TZ          string
+	Message     string
+	CreatedAt   time.Time
+}
@@ -14,4 +14,15 @@ type User struct {
 	NextFireAt  *time.Time // UTC, nullable
 	LastSentAt  *time.Time // UTC, nullable
 	CreatedAt   time.Time  // UTC
+
+	DisabledReason string // why the bot disabled this user (e.g. blocked); empty if paused by the user
+	JitterSec      int    // deterministic offset added to every slot; see JitterFor
+	DigestSec      int    // reminders due within this many seconds are sent as one message; 0 = off
+	ThreadID       int    // forum topic reminders are posted to (message_thread_id); 0 = General / none
+	Language       string // language of the bot's texts (e.g. "ru"); "" = not chosen, follow the sender's app
+}
+
+// DigestWindow returns the user's digest grouping window (0 = digest off).
+func (u *User) DigestWindow() time.Duration {
+	return time.Duration(u.DigestSec) * time.Second
 }
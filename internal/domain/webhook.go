@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+)
+
+// Webhook event statuses. An event moves pending → sending → sent, or back
+// to pending for a retry, and ends as sent or failed.
+const (
+	WebhookPending = "pending" // waiting for its (next) attempt
+	WebhookSending = "sending" // attempt in flight, leased until LeaseUntil
+	WebhookSent    = "sent"    // receiver answered 2xx
+	WebhookFailed  = "failed"  // gave up: rejected, retries exhausted or webhook removed
+)
+
+// Webhook is a user's outgoing webhook: every sent reminder is POSTed to
+// URL, signed with Secret.
+type Webhook struct {
+	ChatID    int64
+	URL       string
+	Secret    string // hex; HMAC-SHA256 key
+	CreatedAt time.Time
+}
+
+// WebhookEvent is one POST of a sent reminder to the chat's webhook, kept
+// afterwards as the delivery log.
+type WebhookEvent struct {
+	ID            int64
+	ChatID        int64
+	DeliveryID    int64
+	Status        string // one of the Webhook* constants
+	Attempts      int
+	NextAttemptAt *time.Time // UTC, nullable; when a pending event is due
+	LeaseUntil    *time.Time // UTC, nullable; when a sending event's lease runs out
+	ResponseCode  int        // HTTP status of the last attempt; 0 if none
+	Error         string     // last failure reason
+	CreatedAt     time.Time
+	SentAt        *time.Time
+
+	// From the delivery, for the payload.
+	Message     string
+	ScheduledAt time.Time
+	DeliveredAt *time.Time
+}
+
+// NewWebhookSecret returns a random 32-byte secret, hex encoded.
+func NewWebhookSecret() (string, error) {
+	var b [32]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b[:]), nil
+}
+
+// ValidateWebhookURL checks that s is an absolute http(s) URL.
+func ValidateWebhookURL(s string) (string, error) {
+	return ValidateChannelTarget(ChannelWebhook, s)
+}
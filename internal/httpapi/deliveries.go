@@ -0,0 +1,150 @@
+// Package httpapi holds HTTP endpoints served next to /healthz.
+package httpapi
+
+import (
+	"crypto/subtle"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/ykvlv/notification-bot/internal/domain"
+	"github.com/ykvlv/notification-bot/internal/store"
+)
+
+// RequireToken wraps h so that it only serves requests carrying
+// "Authorization: Bearer <token>".
+func RequireToken(token string, h http.Handler) http.Handler {
+	want := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got := []byte(r.Header.Get("Authorization"))
+		if subtle.ConstantTimeCompare(got, want) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// DeliveriesExport serves the delivery history as CSV (default) or JSON.
+//
+// Query parameters (all optional):
+//   - chat_id: only this chat
+//   - from, to: scheduled_at range, RFC 3339 or YYYY-MM-DD (UTC), to is exclusive
+//   - format: csv | json
+func DeliveriesExport(repo store.Repo, log *zap.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		f, err := parseDeliveryFilter(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		items, err := repo.ListDeliveries(r.Context(), f)
+		if err != nil {
+			log.Error("export deliveries failed", zap.Error(err))
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		if r.URL.Query().Get("format") == "json" {
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(toJSON(items))
+			return
+		}
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="deliveries.csv"`)
+		writeCSV(w, items)
+	})
+}
+
+func parseDeliveryFilter(r *http.Request) (store.DeliveryFilter, error) {
+	q := r.URL.Query()
+	var (
+		f   store.DeliveryFilter
+		err error
+	)
+	if v := q.Get("chat_id"); v != "" {
+		if f.ChatID, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return f, badParam("chat_id")
+		}
+	}
+	if v := q.Get("from"); v != "" {
+		if f.From, err = parseTime(v); err != nil {
+			return f, badParam("from")
+		}
+	}
+	if v := q.Get("to"); v != "" {
+		if f.To, err = parseTime(v); err != nil {
+			return f, badParam("to")
+		}
+	}
+	return f, nil
+}
+
+func parseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", s)
+}
+
+func badParam(name string) error { return fmt.Errorf("invalid %s", name) }
+
+// deliveryJSON is the export representation of domain.Delivery.
+type deliveryJSON struct {
+	ID          int64      `json:"id"`
+	ChatID      int64      `json:"chat_id"`
+	Message     string     `json:"message"`
+	ScheduledAt time.Time  `json:"scheduled_at"`
+	SentAt      *time.Time `json:"sent_at,omitempty"`
+	MessageID   int        `json:"message_id,omitempty"`
+	Status      string     `json:"status"`
+	Error       string     `json:"error,omitempty"`
+}
+
+func toJSON(items []domain.Delivery) []deliveryJSON {
+	out := make([]deliveryJSON, 0, len(items))
+	for _, d := range items {
+		out = append(out, deliveryJSON{
+			ID:          d.ID,
+			ChatID:      d.ChatID,
+			Message:     d.Message,
+			ScheduledAt: d.ScheduledAt,
+			SentAt:      d.SentAt,
+			MessageID:   d.MessageID,
+			Status:      d.Status,
+			Error:       d.Error,
+		})
+	}
+	return out
+}
+
+func writeCSV(w http.ResponseWriter, items []domain.Delivery) {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"id", "chat_id", "message", "scheduled_at", "sent_at", "message_id", "status", "error"})
+	for _, d := range items {
+		sentAt := ""
+		if d.SentAt != nil {
+			sentAt = d.SentAt.Format(time.RFC3339)
+		}
+		_ = cw.Write([]string{
+			strconv.FormatInt(d.ID, 10),
+			strconv.FormatInt(d.ChatID, 10),
+			d.Message,
+			d.ScheduledAt.Format(time.RFC3339),
+			sentAt,
+			strconv.Itoa(d.MessageID),
+			d.Status,
+			d.Error,
+		})
+	}
+	cw.Flush()
+}
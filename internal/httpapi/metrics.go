@@ -0,0 +1,28 @@
+package httpapi
+
+import (
+	"io"
+	"net/http"
+)
+
+// PrometheusWriter is implemented by metric sets that can render themselves
+// in the Prometheus text exposition format (e.g. *scheduler.Metrics).
+type PrometheusWriter interface {
+	WritePrometheus(w io.Writer) error
+}
+
+// Metrics serves the given metric sets at a Prometheus scrape endpoint.
+func Metrics(sets ...PrometheusWriter) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		for _, s := range sets {
+			if err := s.WritePrometheus(w); err != nil {
+				return // client went away
+			}
+		}
+	})
+}
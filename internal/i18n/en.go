@@ -0,0 +1,253 @@
+package i18n
+
+var en = map[string]string{
+	"language.name":    "English",
+	"language.prompt":  "Choose the language of my messages:",
+	"language.set":     "I will speak English here.",
+	"language.unknown": "Unknown language. Available: en, ru.",
+	"language.failed":  "Could not save language.",
+
+	// Commands menu
+	"cmd.start":    "Start reminders",
+	"cmd.status":   "Show current settings",
+	"cmd.settings": "Change interval, hours, timezone, message",
+	"cmd.pause":    "Pause reminders",
+	"cmd.resume":   "Resume reminders",
+	"cmd.history":  "Recent reminders",
+	"cmd.examples": "Notification sounds (MP3)",
+	"cmd.share":    "Link to share your reminder settings",
+	"cmd.cancel":   "Cancel the current question",
+	"cmd.language": "Language of the bot",
+	"cmd.channel":  "Where reminders are delivered",
+	"cmd.webhooks": "Webhooks for sent reminders",
+
+	// /start and /status
+	"start.text": "👋 I am a reminder bot.\n\n" +
+		"Set interval, active hours, timezone and your message — I will ping you.\n\n" +
+		"🎵 Need ready-made sounds? Use /examples to get MP3s and set them as custom notification sounds in Telegram.",
+	"start.failed":   "Profile initialization error. Please try again later.",
+	"status.title":   "🧾 Your current settings:",
+	"status.body":    "• Interval: %s\n• Active hours: %s–%s\n• TZ: %s\n• Enabled: %s\n• Next: %s\n• Message: %s\n",
+	"status.jitter":  "• Offset: +%s (spreads load at popular times)\n",
+	"status.digest":  "• Digest: reminders within %s are sent together\n",
+	"status.enabled": "✅ Enabled",
+	"status.paused":  "⏸ Paused",
+	"status.next":    "%s, %s",
+	"status.failed":  "Error reading your settings.",
+	"status.none":    "Reminders are not set up here yet: send /start.",
+
+	// Pause, resume, examples
+	"pause.done":       "Paused ⏸",
+	"pause.failed":     "Failed to pause.",
+	"resume.done":      "Resumed ✅",
+	"resume.failed":    "Failed to resume.",
+	"examples.none":    "No audio examples bundled.",
+	"examples.sending": "Sending audio examples…",
+	"examples.done":    "Done. Open the chat’s notification settings in Telegram to set a custom sound.",
+
+	// Settings menu
+	"menu.title":    "⚙️ Settings",
+	"menu.body":     "• Interval: %s\n• Active hours: %s–%s\n• TZ: %s\n• Message: %s\n• Digest: %s",
+	"menu.question": "What do you want to configure?",
+	"menu.failed":   "Error opening settings.",
+	"menu.stale":    "This menu is outdated. Open /settings for a fresh one.",
+	"btn.interval":  "⏲️ Interval",
+	"btn.hours":     "🕘 Active hours",
+	"btn.tz":        "🌍 Timezone",
+	"btn.message":   "📝 Message",
+	"btn.digest":    "📦 Digest",
+	"btn.examples":  "🎵 Audio examples",
+	"btn.cancel":    "✖️ Cancel",
+	"btn.save":      "✅ Save",
+	"btn.back":      "⬅️ Back",
+	"btn.custom":    "✍️ Custom…",
+	"btn.done":      "✅ Done",
+	"btn.prev":      "◀️ Prev",
+	"btn.next":      "Next ▶️",
+
+	// Forms
+	"form.current":   "\n\nCurrent: %s",
+	"form.try_again": "\nTry again, or /cancel.",
+
+	"interval.prompt":  "Choose an interval (or Custom to enter your own):",
+	"interval.custom":  "Enter interval, e.g.: 30m, 1h, 1h30m, 90m",
+	"interval.updated": "Interval updated: %s",
+	"interval.failed":  "Could not save interval.",
+	"duration.short":   "Interval is too short. Minimum is 10m.",
+	"duration.long":    "Interval is too long. Maximum is 72h.",
+	"duration.invalid": "Invalid interval. Examples: 30m, 1h, 1h30m.",
+	"duration.failed":  "Failed to parse interval.",
+
+	"hours.prompt":  "Choose active hours (or Custom):",
+	"hours.custom":  "Enter active hours as HH:MM–HH:MM (e.g., 09:00–21:00)",
+	"hours.invalid": "Invalid format. Example: 09:00–21:00",
+	"hours.updated": "Active hours updated: %s–%s",
+	"hours.failed":  "Could not save active hours.",
+
+	"tz.prompt":  "Choose a timezone or enter your own (Region/City):",
+	"tz.custom":  "Enter timezone (e.g., Europe/Moscow):",
+	"tz.invalid": "Invalid timezone. Example: Europe/Moscow",
+	"tz.updated": "Timezone updated: %s",
+	"tz.failed":  "Could not save timezone.",
+
+	"message.prompt":  "Send your reminder text in a single message (max 512 chars):",
+	"message.invalid": "Please send text of up to 512 characters.",
+	"message.summary": "Your reminders will read:\n\n%s",
+	"message.updated": "Message updated.",
+	"message.failed":  "Could not save message.",
+
+	"digest.prompt":  "Reminders due within the window are sent as one message.\nChoose a digest window:",
+	"digest.invalid": "Choose one of the digest windows.",
+	"digest.updated": "Digest window updated: %s",
+	"digest.off":     "Digest turned off: every reminder is sent separately.",
+	"digest.failed":  "Could not save digest window.",
+	"preset.off":     "Off",
+	"value.off":      "off",
+
+	// Reminders
+	"digest.title#one":   "🔔 %d reminder:",
+	"digest.title#other": "🔔 %d reminders:",
+	"ack.done":           "Done ✅",
+	"ack.already":        "Already done",
+	"ack.failed":         "Could not save, try again.",
+
+	// /history
+	"history.title":    "🗂 Delivery history (page %d/%d):",
+	"history.empty":    "No reminders sent yet.",
+	"history.failed":   "Failed to load history.",
+	"history.acked":    "done",
+	"history.retrying": "retrying (%d attempts): %s",
+	"history.canceled": "canceled",
+	"history.unknown":  "not confirmed",
+
+	// Prompts and groups
+	"pending.expired":  "⌛ That answer came too late: the question expired. Open /settings to try again.",
+	"cancel.hint":      "\n\nSend /cancel to cancel.",
+	"cancel.nothing":   "Nothing to cancel.",
+	"cancel.done":      "Cancelled.",
+	"group.not_admin":  "Only chat admins can change settings.",
+	"group.reply_hint": "\n\n↩️ Reply to this message with your answer.",
+
+	// Inline mode templates
+	"tpl.card":            "🔔 %s\n\n%s\n\n⏰ %s",
+	"tpl.schedule":        "every %s, %s–%s",
+	"btn.setup":           "⚙️ Set this up",
+	"tpl.water.title":     "Drink water",
+	"tpl.water.message":   "💧 Time for a glass of water.",
+	"tpl.stretch.title":   "Stretch",
+	"tpl.stretch.message": "🤸 Stand up and stretch for a minute.",
+	"tpl.eyes.title":      "Rest your eyes",
+	"tpl.eyes.message":    "👀 Look at something 6 metres away for 20 seconds.",
+	"tpl.posture.title":   "Check your posture",
+	"tpl.posture.message": "🪑 Sit up straight and relax your shoulders.",
+	"tpl.walk.title":      "Take a walk",
+	"tpl.walk.message":    "🚶 Go for a short walk.",
+
+	// Deep links and /share
+	"link.confirm":     "🔗 Set up this reminder?\n\n%s",
+	"link.summary":     "• Text: %s\n• Interval: %s\n• Active hours: %s–%s\n• Time zone: %s",
+	"link.bad":         "This link is broken or no longer supported. Send /start to set up reminders yourself.",
+	"link.stale":       "This confirmation is out of date. Open the link again.",
+	"link.done":        "✅ Reminder set up. /status shows it, /settings changes it.",
+	"link.failed":      "Could not set up the reminder. Try the link again later.",
+	"btn.link_confirm": "✅ Set up",
+	"share.text":       "🔗 Send this link to share your reminder:\n%s\n\nIt sets up:\n%s",
+	"share.none":       "Nothing to share yet: send /start first.",
+	"share.failed":     "Could not create a share link.",
+
+	// /channel and broadcasting
+	"channel.usage": "Usage:\n" +
+		"/channel telegram — reminders in this chat (default)\n" +
+		"/channel email <address>\n" +
+		"/channel webhook <https://…>\n" +
+		"/channel broadcast [@channel] — post to a Telegram channel you admin (private chat only)",
+	"channel.here":           "Reminders are sent to this chat.",
+	"channel.current":        "Reminders are sent via %s.",
+	"channel.updated":        "Channel updated: %s",
+	"channel.load_failed":    "Failed to load channel.",
+	"channel.failed":         "Could not save channel.",
+	"broadcast.prompt":       "Forward any post from your channel here, or send its @username.\nI must be an admin of the channel with the right to post messages.",
+	"broadcast.private_only": "Set up channel broadcasting in a private chat with me.",
+	"broadcast.not_found":    "Channel not found. Add me to the channel as an admin first, then try again.",
+	"broadcast.cannot_post":  "I can't post to %s. Make me an admin with the “Post messages” right and try again.",
+	"broadcast.not_admin":    "Only admins of %s can send reminders there.",
+	"broadcast.check_failed": "Could not check my rights in the channel. Try again later.",
+	"broadcast.done":         "Reminders will be posted to %s with your settings. /channel telegram switches back.",
+	"email.unavailable":      "Email delivery is not set up on this bot.",
+	"email.send_failed":      "Could not send the confirmation code to %s. Check the address and try again.",
+	"email.code_prompt":      "📧 I sent a confirmation code to %s. Send it here to receive reminders there.",
+	"email.code_wrong":       "Wrong code. Check the email and try again.",
+	"email.code_exhausted":   "Wrong code again. Send /channel email <address> for a new one.",
+	"email.code_subject":     "Confirmation code",
+	"email.code_body":        "Your confirmation code: %s\n\nSend it to the reminder bot in Telegram to receive reminders at this address. If you did not ask for it, ignore this email.",
+	"email.subject":          "Reminder",
+
+	"email.subject_digest#one":   "%d reminder",
+	"email.subject_digest#other": "%d reminders",
+
+	// /webhooks
+	"webhooks.usage": "Usage:\n" +
+		"/webhooks set <https://…> — POST every sent reminder to this URL\n" +
+		"/webhooks rotate — generate a new signing secret\n" +
+		"/webhooks off — remove the webhook\n\n" +
+		"Requests are signed: X-Webhook-Signature = sha256=HMAC-SHA256(secret, X-Webhook-Timestamp + \".\" + body).",
+	"webhooks.private_only":  "Set up webhooks in a private chat with me.",
+	"webhooks.none":          "No webhook set.",
+	"webhooks.info":          "🔗 Webhook: %s\nSecret: %s…",
+	"webhooks.no_events":     "No deliveries yet.",
+	"webhooks.recent":        "Recent deliveries:",
+	"webhooks.load_failed":   "Failed to load webhook.",
+	"webhooks.log_failed":    "Failed to load webhook log.",
+	"webhooks.bad_url":       "Invalid URL. Example: /webhooks set https://example.com/hook",
+	"webhooks.set":           "Webhook set: %s\nThe signing secret is unchanged.",
+	"webhooks.set_secret":    "Webhook set: %s\nSigning secret (shown once, keep it safe):\n%s",
+	"webhooks.failed":        "Could not save webhook.",
+	"webhooks.rotated":       "New signing secret (shown once, keep it safe):\n%s",
+	"webhooks.rotate_failed": "Could not rotate secret.",
+	"webhooks.removed":       "Webhook removed.",
+	"webhooks.remove_failed": "Could not remove webhook.",
+
+	// Admin commands
+	"dead.title":          "☠️ Dead letters (latest %d):",
+	"dead.item":           "#%d • chat %d • %s UTC • attempts: %d\n  %s",
+	"dead.hint":           "Replay with /replay <id>",
+	"dead.none":           "No dead letters 🎉",
+	"dead.failed":         "Failed to load dead letters.",
+	"replay.usage":        "Usage: /replay <id>",
+	"replay.not_found":    "Dead letter #%d not found.",
+	"replay.already":      "Dead letter #%d was already replayed.",
+	"replay.failed":       "Replay of #%d failed: %s",
+	"replay.done":         "Dead letter #%d is queued for delivery again ✅",
+	"jitter.current":      "Jitter: up to %s per user.\nChange with /jitter <duration> or /jitter off",
+	"jitter.none":         "Jitter is off.\nEnable with /jitter <duration>, e.g. /jitter 5m",
+	"jitter.usage":        "Usage: /jitter <duration up to %s> or /jitter off",
+	"jitter.failed":       "Failed to save jitter.",
+	"jitter.apply_failed": "Saved, but failed to update users.",
+	"jitter.off#one":      "Jitter off for %d user. Applies from their next reminder.",
+	"jitter.off#other":    "Jitter off for %d users. Applies from each user's next reminder.",
+	"jitter.set#one":      "Jitter set for %d user: up to %s. Applies from their next reminder.",
+	"jitter.set#other":    "Jitter set for %d users: up to %s. Applies from each user's next reminder.",
+
+	// Units and dates
+	"unit.hour#one":     "%d hour",
+	"unit.hour#other":   "%d hours",
+	"unit.minute#one":   "%d minute",
+	"unit.minute#other": "%d minutes",
+	"unit.second#one":   "%d second",
+	"unit.second#other": "%d seconds",
+	"date.today":        "today",
+	"date.tomorrow":     "tomorrow",
+	"date.day":          "%d %s",
+	"date.month.1":      "January",
+	"date.month.2":      "February",
+	"date.month.3":      "March",
+	"date.month.4":      "April",
+	"date.month.5":      "May",
+	"date.month.6":      "June",
+	"date.month.7":      "July",
+	"date.month.8":      "August",
+	"date.month.9":      "September",
+	"date.month.10":     "October",
+	"date.month.11":     "November",
+	"date.month.12":     "December",
+}
@@ -0,0 +1,151 @@
+// Package i18n holds the bot's message catalog in Russian and English,
+// with plural forms and localized durations and dates.
+package i18n
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Lang is a catalog language, named by its IETF code as Telegram reports it.
+type Lang string
+
+const (
+	En Lang = "en"
+	Ru Lang = "ru"
+
+	// Default is used when nothing tells the language, e.g. for reminders
+	// of a chat that never chose one.
+	Default = Ru
+)
+
+// Langs lists the catalog languages, the first being the fallback for
+// unsupported app languages.
+var Langs = []Lang{En, Ru}
+
+// catalogs maps each language to its messages. Counted messages have one
+// key per plural form: "<key>#one", "<key>#few" and so on; see forms.
+var catalogs = map[Lang]map[string]string{
+	En: en,
+	Ru: ru,
+}
+
+// forms are each language's plural categories (CLDR names).
+var forms = map[Lang][]string{
+	En: {"one", "other"},
+	Ru: {"one", "few", "many"},
+}
+
+// Match picks the catalog language for a Telegram language code such as
+// "ru" or "en-US": Default when the code is empty, Langs[0] when it is not
+// supported.
+func Match(code string) Lang {
+	if code == "" {
+		return Default
+	}
+	base, _, _ := strings.Cut(strings.ToLower(code), "-")
+	if l, ok := Parse(base); ok {
+		return l
+	}
+	return Langs[0]
+}
+
+// Parse returns the catalog language named s exactly.
+func Parse(s string) (Lang, bool) {
+	for _, l := range Langs {
+		if string(l) == s {
+			return l, true
+		}
+	}
+	return "", false
+}
+
+// Name is the language's own name, for language pickers.
+func (l Lang) Name() string {
+	return l.T("language.name")
+}
+
+// Lookup returns the message for key, if the catalog has it.
+func (l Lang) Lookup(key string) (string, bool) {
+	s, ok := catalogs[l][key]
+	return s, ok
+}
+
+// T formats the message for key with args, like fmt.Sprintf. A missing key
+// is returned as is, so it shows up instead of breaking the reply.
+func (l Lang) T(key string, args ...any) string {
+	s, ok := l.Lookup(key)
+	if !ok {
+		return key
+	}
+	if len(args) == 0 {
+		return s
+	}
+	return fmt.Sprintf(s, args...)
+}
+
+// N formats the plural form of key that fits n, with n as the first
+// argument: N("unit.minute", 5) is "5 минут" in Russian.
+func (l Lang) N(key string, n int, args ...any) string {
+	return l.T(key+"#"+l.plural(n), append([]any{n}, args...)...)
+}
+
+// plural returns the plural category of n.
+func (l Lang) plural(n int) string {
+	if n < 0 {
+		n = -n
+	}
+	switch l {
+	case Ru:
+		switch {
+		case n%10 == 1 && n%100 != 11:
+			return "one"
+		case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+			return "few"
+		default:
+			return "many"
+		}
+	default:
+		if n == 1 {
+			return "one"
+		}
+		return "other"
+	}
+}
+
+// Duration spells d out in hours, minutes and seconds, dropping zero
+// parts: "1 hour 30 minutes". Zero is "0 minutes".
+func (l Lang) Duration(d time.Duration) string {
+	d = d.Round(time.Second)
+	h, m, s := int(d/time.Hour), int(d%time.Hour/time.Minute), int(d%time.Minute/time.Second)
+	var parts []string
+	if h > 0 {
+		parts = append(parts, l.N("unit.hour", h))
+	}
+	if m > 0 {
+		parts = append(parts, l.N("unit.minute", m))
+	}
+	if s > 0 {
+		parts = append(parts, l.N("unit.second", s))
+	}
+	if len(parts) == 0 {
+		return l.N("unit.minute", 0)
+	}
+	return strings.Join(parts, " ")
+}
+
+// Day names t's day relative to now, both in the same location: "today",
+// "tomorrow" or a date like "18 October".
+func (l Lang) Day(t, now time.Time) string {
+	y, m, d := t.Date()
+	ny, nm, nd := now.Date()
+	today := time.Date(ny, nm, nd, 0, 0, 0, 0, t.Location())
+	switch time.Date(y, m, d, 0, 0, 0, 0, t.Location()) {
+	case today:
+		return l.T("date.today")
+	case today.AddDate(0, 0, 1):
+		return l.T("date.tomorrow")
+	}
+	return l.T("date.day", d, l.T(fmt.Sprintf("date.month.%d", int(m))))
+}
@@ -0,0 +1,135 @@
+package i18n
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestCatalogsComplete fails when a language lacks a message another one
+// has, lacks one of its plural forms, or expects other arguments.
+func TestCatalogsComplete(t *testing.T) {
+	keys := map[string]bool{} // base key -> counted
+	for _, cat := range catalogs {
+		for k := range cat {
+			base, _, counted := strings.Cut(k, "#")
+			keys[base] = keys[base] || counted
+		}
+	}
+	for _, l := range Langs {
+		cat := catalogs[l]
+		if cat == nil || forms[l] == nil {
+			t.Fatalf("%s: no catalog or plural forms", l)
+		}
+		for base, counted := range keys {
+			if !counted {
+				if _, ok := cat[base]; !ok {
+					t.Errorf("%s: missing %q", l, base)
+				}
+				continue
+			}
+			for _, f := range forms[l] {
+				if _, ok := cat[base+"#"+f]; !ok {
+					t.Errorf("%s: missing %q", l, base+"#"+f)
+				}
+			}
+		}
+		for k := range cat {
+			if base, f, counted := strings.Cut(k, "#"); counted && !hasForm(l, f) {
+				t.Errorf("%s: %q is not a plural form of %s (key %s)", l, f, l, base)
+			}
+		}
+	}
+
+	// Every form of every language takes the arguments of the first one.
+	verbs := regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z]`)
+	for base, counted := range keys {
+		var want []string
+		for i, l := range Langs {
+			variants := []string{base}
+			if counted {
+				variants = variants[:0]
+				for _, f := range forms[l] {
+					variants = append(variants, base+"#"+f)
+				}
+			}
+			for j, k := range variants {
+				got := verbs.FindAllString(catalogs[l][k], -1)
+				if i == 0 && j == 0 {
+					want = got
+				} else if strings.Join(got, "") != strings.Join(want, "") {
+					t.Errorf("%s: %q has verbs %v, want %v", l, k, got, want)
+				}
+			}
+		}
+	}
+}
+
+func hasForm(l Lang, f string) bool {
+	for _, g := range forms[l] {
+		if g == f {
+			return true
+		}
+	}
+	return false
+}
+
+func TestPlural(t *testing.T) {
+	cases := []struct {
+		l    Lang
+		n    int
+		want string
+	}{
+		{Ru, 1, "1 минута"}, {Ru, 2, "2 минуты"}, {Ru, 5, "5 минут"},
+		{Ru, 11, "11 минут"}, {Ru, 14, "14 минут"}, {Ru, 21, "21 минута"},
+		{Ru, 22, "22 минуты"}, {Ru, 111, "111 минут"}, {Ru, 0, "0 минут"},
+		{En, 1, "1 minute"}, {En, 2, "2 minutes"}, {En, 0, "0 minutes"},
+	}
+	for _, c := range cases {
+		if got := c.l.N("unit.minute", c.n); got != c.want {
+			t.Errorf("%s.N(unit.minute, %d) = %q, want %q", c.l, c.n, got, c.want)
+		}
+	}
+}
+
+func TestDuration(t *testing.T) {
+	cases := []struct {
+		l    Lang
+		d    time.Duration
+		want string
+	}{
+		{Ru, 90 * time.Minute, "1 час 30 минут"},
+		{Ru, 2 * time.Hour, "2 часа"},
+		{Ru, 30 * time.Second, "30 секунд"},
+		{En, 24 * time.Hour, "24 hours"},
+		{En, 0, "0 minutes"},
+	}
+	for _, c := range cases {
+		if got := c.l.Duration(c.d); got != c.want {
+			t.Errorf("%s.Duration(%s) = %q, want %q", c.l, c.d, got, c.want)
+		}
+	}
+}
+
+func TestDay(t *testing.T) {
+	loc := time.FixedZone("UTC+3", 3*3600)
+	now := time.Date(2025, 12, 31, 23, 30, 0, 0, loc)
+	if got := Ru.Day(now.Add(20*time.Minute), now); got != "сегодня" {
+		t.Errorf("today: %q", got)
+	}
+	if got := En.Day(now.Add(time.Hour), now); got != "tomorrow" {
+		t.Errorf("tomorrow: %q", got)
+	}
+	if got := Ru.Day(now.Add(48*time.Hour), now); got != "2 января" {
+		t.Errorf("date: %q", got)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	for code, want := range map[string]Lang{"ru": Ru, "en-US": En, "de": En, "": Default, "RU": Ru} {
+		if got := Match(code); got != want {
+			t.Errorf("Match(%q) = %s, want %s", code, got, want)
+		}
+	}
+}
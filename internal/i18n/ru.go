@@ -0,0 +1,260 @@
+package i18n
+
+var ru = map[string]string{
+	"language.name":    "Русский",
+	"language.prompt":  "Выберите язык моих сообщений:",
+	"language.set":     "Здесь я буду говорить по-русски.",
+	"language.unknown": "Неизвестный язык. Доступны: en, ru.",
+	"language.failed":  "Не удалось сохранить язык.",
+
+	// Commands menu
+	"cmd.start":    "Запустить напоминания",
+	"cmd.status":   "Текущие настройки",
+	"cmd.settings": "Интервал, часы, часовой пояс, текст",
+	"cmd.pause":    "Приостановить напоминания",
+	"cmd.resume":   "Возобновить напоминания",
+	"cmd.history":  "Недавние напоминания",
+	"cmd.examples": "Звуки для уведомлений (MP3)",
+	"cmd.share":    "Ссылка, чтобы поделиться настройками",
+	"cmd.cancel":   "Отменить текущий вопрос",
+	"cmd.language": "Язык бота",
+	"cmd.channel":  "Куда доставлять напоминания",
+	"cmd.webhooks": "Вебхуки об отправленных напоминаниях",
+
+	// /start and /status
+	"start.text": "👋 Я бот-напоминалка.\n\n" +
+		"Задайте интервал, активные часы, часовой пояс и текст — и я буду вам напоминать.\n\n" +
+		"🎵 Нужны готовые звуки? Отправьте /examples, чтобы получить MP3 и поставить их как звук уведомлений в Telegram.",
+	"start.failed":   "Не удалось создать профиль. Попробуйте позже.",
+	"status.title":   "🧾 Ваши настройки:",
+	"status.body":    "• Интервал: %s\n• Активные часы: %s–%s\n• Часовой пояс: %s\n• Состояние: %s\n• Следующее: %s\n• Текст: %s\n",
+	"status.jitter":  "• Сдвиг: +%s (разгружает популярное время)\n",
+	"status.digest":  "• Сводка: напоминания в пределах %s приходят одним сообщением\n",
+	"status.enabled": "✅ Включены",
+	"status.paused":  "⏸ На паузе",
+	"status.next":    "%s, %s",
+	"status.failed":  "Не удалось прочитать настройки.",
+	"status.none":    "Напоминания здесь ещё не настроены: отправьте /start.",
+
+	// Pause, resume, examples
+	"pause.done":       "Пауза ⏸",
+	"pause.failed":     "Не удалось поставить на паузу.",
+	"resume.done":      "Возобновлено ✅",
+	"resume.failed":    "Не удалось возобновить.",
+	"examples.none":    "Примеров звуков нет.",
+	"examples.sending": "Отправляю примеры звуков…",
+	"examples.done":    "Готово. Откройте настройки уведомлений чата в Telegram, чтобы выбрать свой звук.",
+
+	// Settings menu
+	"menu.title":    "⚙️ Настройки",
+	"menu.body":     "• Интервал: %s\n• Активные часы: %s–%s\n• Часовой пояс: %s\n• Текст: %s\n• Сводка: %s",
+	"menu.question": "Что настроить?",
+	"menu.failed":   "Не удалось открыть настройки.",
+	"menu.stale":    "Это меню устарело. Откройте /settings заново.",
+	"btn.interval":  "⏲️ Интервал",
+	"btn.hours":     "🕘 Активные часы",
+	"btn.tz":        "🌍 Часовой пояс",
+	"btn.message":   "📝 Текст",
+	"btn.digest":    "📦 Сводка",
+	"btn.examples":  "🎵 Примеры звуков",
+	"btn.cancel":    "✖️ Отмена",
+	"btn.save":      "✅ Сохранить",
+	"btn.back":      "⬅️ Назад",
+	"btn.custom":    "✍️ Своё…",
+	"btn.done":      "✅ Готово",
+	"btn.prev":      "◀️ Назад",
+	"btn.next":      "Вперёд ▶️",
+
+	// Forms
+	"form.current":   "\n\nСейчас: %s",
+	"form.try_again": "\nПопробуйте ещё раз или /cancel.",
+
+	"interval.prompt":  "Выберите интервал (или «Своё», чтобы ввести свой):",
+	"interval.custom":  "Введите интервал, например: 30m, 1h, 1h30m, 90m",
+	"interval.updated": "Интервал: %s",
+	"interval.failed":  "Не удалось сохранить интервал.",
+	"duration.short":   "Слишком короткий интервал. Минимум — 10m.",
+	"duration.long":    "Слишком длинный интервал. Максимум — 72h.",
+	"duration.invalid": "Неверный интервал. Примеры: 30m, 1h, 1h30m.",
+	"duration.failed":  "Не удалось разобрать интервал.",
+
+	"hours.prompt":  "Выберите активные часы (или «Своё»):",
+	"hours.custom":  "Введите активные часы как ЧЧ:ММ–ЧЧ:ММ (например, 09:00–21:00)",
+	"hours.invalid": "Неверный формат. Пример: 09:00–21:00",
+	"hours.updated": "Активные часы: %s–%s",
+	"hours.failed":  "Не удалось сохранить активные часы.",
+
+	"tz.prompt":  "Выберите часовой пояс или введите свой (Регион/Город):",
+	"tz.custom":  "Введите часовой пояс (например, Europe/Moscow):",
+	"tz.invalid": "Неверный часовой пояс. Пример: Europe/Moscow",
+	"tz.updated": "Часовой пояс: %s",
+	"tz.failed":  "Не удалось сохранить часовой пояс.",
+
+	"message.prompt":  "Отправьте текст напоминания одним сообщением (до 512 символов):",
+	"message.invalid": "Отправьте текст длиной до 512 символов.",
+	"message.summary": "Напоминания будут такими:\n\n%s",
+	"message.updated": "Текст обновлён.",
+	"message.failed":  "Не удалось сохранить текст.",
+
+	"digest.prompt":  "Напоминания, которые приходятся на одно окно, отправляются одним сообщением.\nВыберите окно сводки:",
+	"digest.invalid": "Выберите одно из окон сводки.",
+	"digest.updated": "Окно сводки: %s",
+	"digest.off":     "Сводка выключена: каждое напоминание приходит отдельно.",
+	"digest.failed":  "Не удалось сохранить окно сводки.",
+	"preset.off":     "Выкл.",
+	"value.off":      "выключена",
+
+	// Reminders
+	"digest.title#one":  "🔔 %d напоминание:",
+	"digest.title#few":  "🔔 %d напоминания:",
+	"digest.title#many": "🔔 %d напоминаний:",
+	"ack.done":          "Готово ✅",
+	"ack.already":       "Уже отмечено",
+	"ack.failed":        "Не удалось сохранить, попробуйте ещё раз.",
+
+	// /history
+	"history.title":    "🗂 История отправок (стр. %d/%d):",
+	"history.empty":    "Напоминаний пока не было.",
+	"history.failed":   "Не удалось загрузить историю.",
+	"history.acked":    "выполнено",
+	"history.retrying": "повтор (попыток: %d): %s",
+	"history.canceled": "отменено",
+	"history.unknown":  "не подтверждено",
+
+	// Prompts and groups
+	"pending.expired":  "⌛ Ответ пришёл слишком поздно: вопрос устарел. Откройте /settings, чтобы попробовать снова.",
+	"cancel.hint":      "\n\nОтправьте /cancel для отмены.",
+	"cancel.nothing":   "Нечего отменять.",
+	"cancel.done":      "Отменено.",
+	"group.not_admin":  "Менять настройки могут только админы чата.",
+	"group.reply_hint": "\n\n↩️ Ответьте на это сообщение.",
+
+	// Inline mode templates
+	"tpl.card":            "🔔 %s\n\n%s\n\n⏰ %s",
+	"tpl.schedule":        "раз в %s, %s–%s",
+	"btn.setup":           "⚙️ Настроить",
+	"tpl.water.title":     "Пить воду",
+	"tpl.water.message":   "💧 Пора выпить стакан воды.",
+	"tpl.stretch.title":   "Разминка",
+	"tpl.stretch.message": "🤸 Встаньте и потянитесь минутку.",
+	"tpl.eyes.title":      "Отдых для глаз",
+	"tpl.eyes.message":    "👀 Посмотрите на что-нибудь в 6 метрах от вас 20 секунд.",
+	"tpl.posture.title":   "Осанка",
+	"tpl.posture.message": "🪑 Выпрямите спину и расслабьте плечи.",
+	"tpl.walk.title":      "Прогулка",
+	"tpl.walk.message":    "🚶 Выйдите ненадолго прогуляться.",
+
+	// Deep links and /share
+	"link.confirm":     "🔗 Настроить такое напоминание?\n\n%s",
+	"link.summary":     "• Текст: %s\n• Интервал: %s\n• Активные часы: %s–%s\n• Часовой пояс: %s",
+	"link.bad":         "Ссылка повреждена или больше не поддерживается. Отправьте /start, чтобы настроить напоминания самостоятельно.",
+	"link.stale":       "Это подтверждение устарело. Откройте ссылку ещё раз.",
+	"link.done":        "✅ Напоминание настроено. /status покажет его, /settings — изменит.",
+	"link.failed":      "Не удалось настроить напоминание. Попробуйте открыть ссылку позже.",
+	"btn.link_confirm": "✅ Настроить",
+	"share.text":       "🔗 Отправьте эту ссылку, чтобы поделиться напоминанием:\n%s\n\nОна настроит:\n%s",
+	"share.none":       "Пока нечем делиться: сначала отправьте /start.",
+	"share.failed":     "Не удалось создать ссылку.",
+
+	// /channel and broadcasting
+	"channel.usage": "Как пользоваться:\n" +
+		"/channel telegram — напоминания в этот чат (по умолчанию)\n" +
+		"/channel email <адрес>\n" +
+		"/channel webhook <https://…>\n" +
+		"/channel broadcast [@канал] — публиковать в Telegram-канал, где вы админ (только в личном чате)",
+	"channel.here":           "Напоминания приходят в этот чат.",
+	"channel.current":        "Напоминания доставляются через %s.",
+	"channel.updated":        "Канал доставки изменён: %s",
+	"channel.load_failed":    "Не удалось загрузить канал доставки.",
+	"channel.failed":         "Не удалось сохранить канал доставки.",
+	"broadcast.prompt":       "Перешлите сюда любой пост из вашего канала или отправьте его @username.\nЯ должен быть админом канала с правом публиковать сообщения.",
+	"broadcast.private_only": "Публикацию в канал настраивайте в личном чате со мной.",
+	"broadcast.not_found":    "Канал не найден. Сначала добавьте меня в канал админом, затем попробуйте снова.",
+	"broadcast.cannot_post":  "Я не могу публиковать в «%s». Сделайте меня админом с правом «Публикация сообщений» и попробуйте снова.",
+	"broadcast.not_admin":    "Отправлять туда напоминания могут только админы «%s».",
+	"broadcast.check_failed": "Не удалось проверить мои права в канале. Попробуйте позже.",
+	"broadcast.done":         "Напоминания будут публиковаться в «%s» с вашими настройками. /channel telegram вернёт их сюда.",
+	"email.unavailable":      "Доставка на почту в этом боте не настроена.",
+	"email.send_failed":      "Не удалось отправить код подтверждения на %s. Проверьте адрес и попробуйте снова.",
+	"email.code_prompt":      "📧 Я отправил код подтверждения на %s. Пришлите его сюда, чтобы получать напоминания туда.",
+	"email.code_wrong":       "Неверный код. Проверьте письмо и попробуйте снова.",
+	"email.code_exhausted":   "Код снова неверный. Отправьте /channel email <адрес>, чтобы получить новый.",
+	"email.code_subject":     "Код подтверждения",
+	"email.code_body":        "Ваш код подтверждения: %s\n\nПришлите его боту напоминаний в Telegram, чтобы получать напоминания на этот адрес. Если вы его не запрашивали, просто проигнорируйте письмо.",
+	"email.subject":          "Напоминание",
+
+	"email.subject_digest#one":  "%d напоминание",
+	"email.subject_digest#few":  "%d напоминания",
+	"email.subject_digest#many": "%d напоминаний",
+
+	// /webhooks
+	"webhooks.usage": "Как пользоваться:\n" +
+		"/webhooks set <https://…> — отправлять POST на этот адрес о каждом напоминании\n" +
+		"/webhooks rotate — создать новый ключ подписи\n" +
+		"/webhooks off — удалить вебхук\n\n" +
+		"Запросы подписаны: X-Webhook-Signature = sha256=HMAC-SHA256(ключ, X-Webhook-Timestamp + \".\" + тело).",
+	"webhooks.private_only":  "Вебхуки настраивайте в личном чате со мной.",
+	"webhooks.none":          "Вебхук не задан.",
+	"webhooks.info":          "🔗 Вебхук: %s\nКлюч: %s…",
+	"webhooks.no_events":     "Отправок пока не было.",
+	"webhooks.recent":        "Последние отправки:",
+	"webhooks.load_failed":   "Не удалось загрузить вебхук.",
+	"webhooks.log_failed":    "Не удалось загрузить журнал вебхука.",
+	"webhooks.bad_url":       "Неверный адрес. Пример: /webhooks set https://example.com/hook",
+	"webhooks.set":           "Вебхук задан: %s\nКлюч подписи не изменился.",
+	"webhooks.set_secret":    "Вебхук задан: %s\nКлюч подписи (показываю один раз, сохраните его):\n%s",
+	"webhooks.failed":        "Не удалось сохранить вебхук.",
+	"webhooks.rotated":       "Новый ключ подписи (показываю один раз, сохраните его):\n%s",
+	"webhooks.rotate_failed": "Не удалось сменить ключ.",
+	"webhooks.removed":       "Вебхук удалён.",
+	"webhooks.remove_failed": "Не удалось удалить вебхук.",
+
+	// Admin commands
+	"dead.title":          "☠️ Недоставленные (последние %d):",
+	"dead.item":           "#%d • чат %d • %s UTC • попыток: %d\n  %s",
+	"dead.hint":           "Повторить: /replay <id>",
+	"dead.none":           "Недоставленных нет 🎉",
+	"dead.failed":         "Не удалось загрузить недоставленные.",
+	"replay.usage":        "Как пользоваться: /replay <id>",
+	"replay.not_found":    "Недоставленное #%d не найдено.",
+	"replay.already":      "Недоставленное #%d уже отправлено повторно.",
+	"replay.failed":       "Не удалось повторить #%d: %s",
+	"replay.done":         "Недоставленное #%d снова поставлено в очередь ✅",
+	"jitter.current":      "Разброс: до %s на пользователя.\nИзменить: /jitter <длительность> или /jitter off",
+	"jitter.none":         "Разброс выключен.\nВключить: /jitter <длительность>, например /jitter 5m",
+	"jitter.usage":        "Как пользоваться: /jitter <длительность до %s> или /jitter off",
+	"jitter.failed":       "Не удалось сохранить разброс.",
+	"jitter.apply_failed": "Сохранено, но не удалось обновить пользователей.",
+	"jitter.off#one":      "Разброс выключен для %d пользователя. Действует с его следующего напоминания.",
+	"jitter.off#few":      "Разброс выключен для %d пользователей. Действует с их следующих напоминаний.",
+	"jitter.off#many":     "Разброс выключен для %d пользователей. Действует с их следующих напоминаний.",
+	"jitter.set#one":      "Для %d пользователя задан разброс до %s. Действует с его следующего напоминания.",
+	"jitter.set#few":      "Для %d пользователей задан разброс до %s. Действует с их следующих напоминаний.",
+	"jitter.set#many":     "Для %d пользователей задан разброс до %s. Действует с их следующих напоминаний.",
+
+	// Units and dates
+	"unit.hour#one":    "%d час",
+	"unit.hour#few":    "%d часа",
+	"unit.hour#many":   "%d часов",
+	"unit.minute#one":  "%d минута",
+	"unit.minute#few":  "%d минуты",
+	"unit.minute#many": "%d минут",
+	"unit.second#one":  "%d секунда",
+	"unit.second#few":  "%d секунды",
+	"unit.second#many": "%d секунд",
+	"date.today":       "сегодня",
+	"date.tomorrow":    "завтра",
+	"date.day":         "%d %s",
+	"date.month.1":     "января",
+	"date.month.2":     "февраля",
+	"date.month.3":     "марта",
+	"date.month.4":     "апреля",
+	"date.month.5":     "мая",
+	"date.month.6":     "июня",
+	"date.month.7":     "июля",
+	"date.month.8":     "августа",
+	"date.month.9":     "сентября",
+	"date.month.10":    "октября",
+	"date.month.11":    "ноября",
+	"date.month.12":    "декабря",
+}
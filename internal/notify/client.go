@@ -0,0 +1,38 @@
+package notify
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// errPrivateAddr is returned when a user-supplied URL resolves to an
+// address that is not publicly routable.
+var errPrivateAddr = errors.New("destination address is not public")
+
+// NewHTTPClient returns a client for user-supplied URLs (webhook channels
+// and outgoing webhooks). Unless allowPrivate is set, it refuses to connect
+// to loopback, private, link-local and other non-public addresses, so users
+// cannot make the bot probe its own network.
+func NewHTTPClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = func(_, address string, _ syscall.RawConn) error {
+			ap, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := ap.Addr().Unmap(); !ip.IsGlobalUnicast() || ip.IsPrivate() {
+				return errPrivateAddr
+			}
+			return nil
+		}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	transport.Proxy = nil // a proxy would bypass the address check
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
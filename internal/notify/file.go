@@ -0,0 +1,54 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+)
+
+// FileSink writes each message as a JSON line to a file or stdout. It is
+// meant for dry runs and local testing; the target is ignored.
+type FileSink struct {
+	mu sync.Mutex
+	w  io.Writer
+	c  io.Closer // nil for stdout
+}
+
+// NewFileSink writes to w.
+func NewFileSink(w io.Writer) *FileSink {
+	return &FileSink{w: w}
+}
+
+// OpenFileSink appends to the file at path, or writes to stdout if path is "-".
+func OpenFileSink(path string) (*FileSink, error) {
+	if path == "-" {
+		return NewFileSink(os.Stdout), nil
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &FileSink{w: f, c: f}, nil
+}
+
+// Send implements Channel.
+func (s *FileSink) Send(_ context.Context, _ string, m Message) error {
+	line, err := json.Marshal(newPayload(m))
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.w.Write(append(line, '\n'))
+	return err
+}
+
+// Close closes the underlying file, if any.
+func (s *FileSink) Close() error {
+	if s.c == nil {
+		return nil
+	}
+	return s.c.Close()
+}
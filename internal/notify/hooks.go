@@ -0,0 +1,239 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/ykvlv/notification-bot/internal/domain"
+	"github.com/ykvlv/notification-bot/internal/scheduler"
+)
+
+// Headers set on every outgoing webhook request. The signature is
+// "sha256=" + hex(HMAC-SHA256(secret, timestamp + "." + body)); receivers
+// should recompute it and reject stale timestamps.
+const (
+	HeaderEventID   = "X-Webhook-Id"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+const (
+	hookPollEvery = 5 * time.Second
+	hookBatch     = 10 // most events claimed at once; see claimLimit
+)
+
+// Sign returns the signature header value for a body sent at timestamp ts.
+func Sign(secret string, ts int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(ts, 10)))
+	mac.Write([]byte{'.'})
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// hookPayload is the JSON body POSTed for each sent reminder.
+type hookPayload struct {
+	EventID     int64      `json:"event_id"`
+	Type        string     `json:"type"`
+	ChatID      int64      `json:"chat_id"`
+	DeliveryID  int64      `json:"delivery_id"`
+	ScheduledAt time.Time  `json:"scheduled_at"`
+	SentAt      *time.Time `json:"sent_at,omitempty"`
+	Text        string     `json:"text"`
+}
+
+// HookStore is the part of store.Repo the webhook worker needs.
+type HookStore interface {
+	GetWebhook(ctx context.Context, chatID int64) (*domain.Webhook, error)
+	ClaimWebhookEvents(ctx context.Context, now, leaseUntil time.Time, limit int) ([]domain.WebhookEvent, error)
+	FinishWebhookEvent(ctx context.Context, id int64, status string, code int, errText string, at time.Time) error
+	RetryWebhookEvent(ctx context.Context, id int64, code int, errText string, next time.Time) error
+}
+
+// HooksConfig controls webhook retries.
+type HooksConfig struct {
+	Client   *http.Client          // nil: 10s timeout
+	Retry    scheduler.RetryPolicy // zero: scheduler.DefaultRetryPolicy
+	LeaseTTL time.Duration         // how long a claimed event is owned; default 1m
+	Clock    scheduler.Clock       // nil = scheduler.RealClock
+}
+
+// Hooks POSTs queued webhook events to their chats' webhooks, retrying
+// failures with backoff. Every instance may run it; events are leased.
+type Hooks struct {
+	repo     HookStore
+	log      *zap.Logger
+	client   *http.Client
+	retry    scheduler.RetryPolicy
+	leaseTTL time.Duration
+	clock    scheduler.Clock
+	batch    int // events claimed at once, all POSTed within one lease
+}
+
+// NewHooks creates the webhook worker.
+func NewHooks(repo HookStore, log *zap.Logger, cfg HooksConfig) *Hooks {
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if cfg.Retry.MaxAttempts <= 0 {
+		cfg.Retry = scheduler.DefaultRetryPolicy()
+	}
+	if cfg.LeaseTTL <= 0 {
+		cfg.LeaseTTL = time.Minute
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = scheduler.RealClock()
+	}
+	return &Hooks{
+		repo: repo, log: log, client: cfg.Client, retry: cfg.Retry, leaseTTL: cfg.LeaseTTL, clock: cfg.Clock,
+		batch: claimLimit(cfg.LeaseTTL, cfg.Client.Timeout),
+	}
+}
+
+// claimLimit returns how many events can be claimed under one lease: every
+// POST may take the full client timeout, and all of them must finish well
+// before the lease runs out, or another instance re-claims and re-sends the
+// rest.
+func claimLimit(lease, timeout time.Duration) int {
+	if timeout <= 0 {
+		return 1 // no bound on a POST
+	}
+	return min(max(int(lease/(2*timeout)), 1), hookBatch)
+}
+
+// Run polls for due events until ctx is canceled.
+func (h *Hooks) Run(ctx context.Context) {
+	t := h.clock.NewTicker(hookPollEvery)
+	defer t.Stop()
+	for {
+		if h.deliverDue(ctx) == h.batch && ctx.Err() == nil {
+			continue // a full batch: more events may be due
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C():
+		}
+	}
+}
+
+// now returns the clock's current time in UTC.
+func (h *Hooks) now() time.Time { return h.clock.Now().UTC() }
+
+// deliverDue sends one batch of due events and returns how many were claimed.
+// Events left when ctx is canceled stay leased and are re-claimed once the
+// lease expires.
+func (h *Hooks) deliverDue(ctx context.Context) int {
+	now := h.now()
+	events, err := h.repo.ClaimWebhookEvents(ctx, now, now.Add(h.leaseTTL), h.batch)
+	if err != nil {
+		h.log.Error("ClaimWebhookEvents failed", zap.Error(err))
+	}
+	for _, e := range events {
+		if ctx.Err() != nil {
+			break
+		}
+		h.deliver(ctx, e)
+	}
+	return len(events)
+}
+
+// deliver POSTs one event, signed with the current time, and records the
+// outcome.
+func (h *Hooks) deliver(ctx context.Context, e domain.WebhookEvent) {
+	w, err := h.repo.GetWebhook(ctx, e.ChatID)
+	if errors.Is(err, sql.ErrNoRows) {
+		h.finish(ctx, e.ID, domain.WebhookFailed, 0, "webhook removed", h.now())
+		return
+	}
+	if err != nil {
+		h.log.Error("GetWebhook failed", zap.Error(err), zap.Int64("chatID", e.ChatID))
+		h.retryLater(ctx, e, 0, err.Error(), h.now())
+		return
+	}
+
+	code, err := h.post(ctx, w, e)
+	if ctx.Err() != nil {
+		return // shutting down; the lease runs out and the event is re-claimed
+	}
+	now := h.now()
+	switch {
+	case err == nil:
+		h.finish(ctx, e.ID, domain.WebhookSent, code, "", now)
+	case code >= 400 && code < 500 && code != http.StatusRequestTimeout && code != http.StatusTooManyRequests:
+		// The receiver rejected the request; sending it again will not help.
+		h.finish(ctx, e.ID, domain.WebhookFailed, code, err.Error(), now)
+	default:
+		h.retryLater(ctx, e, code, err.Error(), now)
+	}
+}
+
+// post sends the event, signed at the current time, and returns the
+// response status, if any.
+func (h *Hooks) post(ctx context.Context, w *domain.Webhook, e domain.WebhookEvent) (int, error) {
+	body, err := json.Marshal(hookPayload{
+		EventID:     e.ID,
+		Type:        "reminder.sent",
+		ChatID:      e.ChatID,
+		DeliveryID:  e.DeliveryID,
+		ScheduledAt: e.ScheduledAt,
+		SentAt:      e.DeliveredAt,
+		Text:        e.Message,
+	})
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	ts := h.now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "notification-bot")
+	req.Header.Set(HeaderEventID, strconv.FormatInt(e.ID, 10))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(ts, 10))
+	req.Header.Set(HeaderSignature, Sign(w.Secret, ts, body))
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// retryLater schedules the next attempt, or fails the event when its
+// attempts are used up.
+func (h *Hooks) retryLater(ctx context.Context, e domain.WebhookEvent, code int, errText string, now time.Time) {
+	if e.Attempts >= h.retry.MaxAttempts {
+		h.finish(ctx, e.ID, domain.WebhookFailed, code, errText, now)
+		return
+	}
+	next := now.Add(h.retry.Backoff(e.Attempts))
+	if err := h.repo.RetryWebhookEvent(ctx, e.ID, code, errText, next); err != nil {
+		h.log.Error("RetryWebhookEvent failed", zap.Error(err), zap.Int64("eventID", e.ID))
+	}
+}
+
+func (h *Hooks) finish(ctx context.Context, id int64, status string, code int, errText string, now time.Time) {
+	if err := h.repo.FinishWebhookEvent(ctx, id, status, code, errText, now); err != nil {
+		h.log.Error("FinishWebhookEvent failed", zap.Error(err), zap.Int64("eventID", id))
+	}
+}
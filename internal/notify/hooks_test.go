@@ -0,0 +1,239 @@
+package notify
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/ykvlv/notification-bot/internal/domain"
+	"github.com/ykvlv/notification-bot/internal/scheduler"
+)
+
+// memHooks is an in-memory HookStore.
+type memHooks struct {
+	mu       sync.Mutex
+	webhooks map[int64]domain.Webhook
+	events   []*domain.WebhookEvent
+}
+
+func (m *memHooks) GetWebhook(_ context.Context, chatID int64) (*domain.Webhook, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	w, ok := m.webhooks[chatID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &w, nil
+}
+
+func (m *memHooks) ClaimWebhookEvents(_ context.Context, now, leaseUntil time.Time, limit int) ([]domain.WebhookEvent, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var res []domain.WebhookEvent
+	for _, e := range m.events {
+		due := (e.Status == domain.WebhookPending && !e.NextAttemptAt.After(now)) ||
+			(e.Status == domain.WebhookSending && !e.LeaseUntil.After(now))
+		if !due || len(res) == limit {
+			continue
+		}
+		e.Status, e.Attempts, e.NextAttemptAt, e.LeaseUntil = domain.WebhookSending, e.Attempts+1, nil, &leaseUntil
+		res = append(res, *e)
+	}
+	return res, nil
+}
+
+func (m *memHooks) FinishWebhookEvent(_ context.Context, id int64, status string, code int, errText string, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e := m.events[id-1]
+	e.Status, e.ResponseCode, e.Error, e.NextAttemptAt, e.LeaseUntil = status, code, errText, nil, nil
+	if status == domain.WebhookSent {
+		e.SentAt = &at
+	}
+	return nil
+}
+
+func (m *memHooks) RetryWebhookEvent(_ context.Context, id int64, code int, errText string, next time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e := m.events[id-1]
+	e.Status, e.ResponseCode, e.Error, e.NextAttemptAt, e.LeaseUntil = domain.WebhookPending, code, errText, &next, nil
+	return nil
+}
+
+func (m *memHooks) event(id int64) domain.WebhookEvent {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return *m.events[id-1]
+}
+
+// hookReceiver verifies signatures and answers with the queued statuses
+// (then 200).
+type hookReceiver struct {
+	t      *testing.T
+	secret string
+
+	onPost func() // optional: called for every request
+
+	mu       sync.Mutex
+	statuses []int
+	got      []hookPayload
+	stamps   []int64 // timestamp headers
+}
+
+func (h *hookReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	ts, _ := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+	if got, want := r.Header.Get(HeaderSignature), Sign(h.secret, ts, body); got != want {
+		h.t.Errorf("signature %q, want %q", got, want)
+	}
+	var p hookPayload
+	if err := json.Unmarshal(body, &p); err != nil {
+		h.t.Errorf("payload: %v", err)
+	}
+	if r.Header.Get(HeaderEventID) != strconv.FormatInt(p.EventID, 10) {
+		h.t.Errorf("event ID header %q for event %d", r.Header.Get(HeaderEventID), p.EventID)
+	}
+
+	if h.onPost != nil {
+		h.onPost()
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.got = append(h.got, p)
+	h.stamps = append(h.stamps, ts)
+	status := http.StatusOK
+	if len(h.statuses) > 0 {
+		status, h.statuses = h.statuses[0], h.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+func newHookTest(t *testing.T, statuses ...int) (*Hooks, *memHooks, *hookReceiver, *scheduler.FakeClock) {
+	t.Helper()
+	recv := &hookReceiver{t: t, secret: "s3cret", statuses: statuses}
+	srv := httptest.NewServer(recv)
+	t.Cleanup(srv.Close)
+
+	sched := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	repo := &memHooks{
+		webhooks: map[int64]domain.Webhook{1: {ChatID: 1, URL: srv.URL, Secret: recv.secret}},
+		events: []*domain.WebhookEvent{{
+			ID: 1, ChatID: 1, DeliveryID: 10, Status: domain.WebhookPending, NextAttemptAt: &sched,
+			Message: "Drink water", ScheduledAt: sched, DeliveredAt: &sched,
+		}},
+	}
+	client := srv.Client()
+	client.Timeout = 10 * time.Second // with the default 1m lease: 3 events per claim
+	clock := scheduler.NewFakeClock(sched)
+	h := NewHooks(repo, zap.NewNop(), HooksConfig{
+		Client: client,
+		Retry:  scheduler.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Minute, MaxDelay: time.Hour},
+		Clock:  clock,
+	})
+	return h, repo, recv, clock
+}
+
+func TestHooks_SignsAndRetries(t *testing.T) {
+	h, repo, recv, clock := newHookTest(t, http.StatusInternalServerError)
+	now := time.Date(2025, 3, 1, 9, 0, 5, 0, time.UTC)
+
+	if n := deliverAt(h, clock, now); n != 1 {
+		t.Fatalf("claimed %d events, want 1", n)
+	}
+	e := repo.event(1)
+	if e.Status != domain.WebhookPending || e.ResponseCode != 500 || !e.NextAttemptAt.Equal(now.Add(time.Minute)) {
+		t.Fatalf("after 500: %+v", e)
+	}
+	if n := deliverAt(h, clock, now.Add(30*time.Second)); n != 0 {
+		t.Fatalf("retried before backoff: claimed %d", n)
+	}
+
+	deliverAt(h, clock, now.Add(time.Minute))
+	if e := repo.event(1); e.Status != domain.WebhookSent || e.Attempts != 2 || e.ResponseCode != 200 {
+		t.Fatalf("after retry: %+v", e)
+	}
+	p := recv.got[1]
+	if p.EventID != 1 || p.ChatID != 1 || p.DeliveryID != 10 || p.Text != "Drink water" || p.Type != "reminder.sent" {
+		t.Fatalf("unexpected payload %+v", p)
+	}
+}
+
+func TestHooks_GivesUp(t *testing.T) {
+	now := time.Date(2025, 3, 1, 9, 0, 5, 0, time.UTC)
+
+	// A 4xx rejection is final.
+	h, repo, _, clock := newHookTest(t, http.StatusBadRequest)
+	deliverAt(h, clock, now)
+	if e := repo.event(1); e.Status != domain.WebhookFailed || e.Attempts != 1 {
+		t.Fatalf("after 400: %+v", e)
+	}
+
+	// 5xx is retried until MaxAttempts.
+	h, repo, _, clock = newHookTest(t, 502, 502, 502)
+	for i := 0; i < 3; i++ {
+		deliverAt(h, clock, now.Add(time.Duration(i)*time.Hour))
+	}
+	if e := repo.event(1); e.Status != domain.WebhookFailed || e.Attempts != 3 || e.ResponseCode != 502 {
+		t.Fatalf("after 3x502: %+v", e)
+	}
+
+	// A removed webhook fails its queued events without a request.
+	h, repo, recv, clock := newHookTest(t)
+	delete(repo.webhooks, 1)
+	deliverAt(h, clock, now)
+	if e := repo.event(1); e.Status != domain.WebhookFailed || e.Error != "webhook removed" || len(recv.got) != 0 {
+		t.Fatalf("removed webhook: %+v", e)
+	}
+}
+
+func TestHooks_ClaimsWhatFitsTheLease(t *testing.T) {
+	for _, c := range []struct {
+		lease, timeout time.Duration
+		want           int
+	}{
+		{time.Minute, 10 * time.Second, 3},
+		{5 * time.Minute, 10 * time.Second, hookBatch},
+		{10 * time.Second, 10 * time.Second, 1},
+		{time.Minute, 0, 1},
+	} {
+		if got := claimLimit(c.lease, c.timeout); got != c.want {
+			t.Errorf("claimLimit(%s, %s) = %d, want %d", c.lease, c.timeout, got, c.want)
+		}
+	}
+
+	// Every request of a batch is signed when it is sent.
+	h, repo, recv, clock := newHookTest(t)
+	recv.onPost = func() { clock.Advance(10 * time.Second) }
+	first := *repo.events[0]
+	for id := int64(2); id <= 3; id++ {
+		e := first
+		e.ID = id
+		repo.events = append(repo.events, &e)
+	}
+	start := clock.Now()
+	if n := h.deliverDue(context.Background()); n != 3 {
+		t.Fatalf("claimed %d events, want 3", n)
+	}
+	for i, ts := range recv.stamps {
+		if want := start.Add(time.Duration(i) * 10 * time.Second).Unix(); ts != want {
+			t.Errorf("request %d signed at %d, want %d", i, ts, want)
+		}
+	}
+}
+
+// deliverAt runs one delivery round with the clock set to now.
+func deliverAt(h *Hooks, clock *scheduler.FakeClock, now time.Time) int {
+	clock.Set(now)
+	return h.deliverDue(context.Background())
+}
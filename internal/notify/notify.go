@@ -0,0 +1,183 @@
+// Package notify delivers reminders over channels other than Telegram
+// (email, webhooks, a local file sink) and routes each chat's reminders to
+// the channel stored for it.
+package notify
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/ykvlv/notification-bot/internal/domain"
+	"github.com/ykvlv/notification-bot/internal/i18n"
+	"github.com/ykvlv/notification-bot/internal/scheduler"
+)
+
+// sendTimeout bounds a single send over any non-Telegram channel.
+const sendTimeout = 30 * time.Second
+
+// Message is one send: a single reminder or a digest of several.
+type Message struct {
+	ChatID int64
+	Items  []scheduler.Reminder
+	At     time.Time // UTC, when the message was handed to the channel
+	Lang   i18n.Lang // the chat's language; i18n.Default when empty
+}
+
+// Subject is a one-line summary of the message in its language.
+func (m Message) Subject() string {
+	l := m.Lang
+	if l == "" {
+		l = i18n.Default
+	}
+	if len(m.Items) == 1 {
+		return l.T("email.subject")
+	}
+	return l.N("email.subject_digest", len(m.Items))
+}
+
+// Text renders the message body: the reminder itself or a numbered list.
+func (m Message) Text() string {
+	if len(m.Items) == 1 {
+		return m.Items[0].Text
+	}
+	var b strings.Builder
+	for i, it := range m.Items {
+		fmt.Fprintf(&b, "%d. %s\n", i+1, it.Text)
+	}
+	return b.String()
+}
+
+// Channel sends a message to a target (an address or URL, depending on the
+// channel). Errors may be scheduler.BlockedError when the recipient can never
+// be reached, or scheduler.RejectedError when only this delivery cannot
+// succeed; anything else is retried.
+type Channel interface {
+	Send(ctx context.Context, target string, m Message) error
+}
+
+// ChannelStore is the part of store.Repo the Mux needs.
+type ChannelStore interface {
+	GetChannel(ctx context.Context, chatID int64) (*domain.Channel, error)
+	GetUser(ctx context.Context, chatID int64) (*domain.User, error)
+}
+
+// Mux implements scheduler.Sender by sending each chat's reminders over the
+// channel stored for it. Chats without a channel, or with a kind that has
+// no registered Channel, are sent to Telegram.
+type Mux struct {
+	repo     ChannelStore
+	log      *zap.Logger
+	telegram scheduler.Sender
+
+	mu       sync.RWMutex
+	channels map[string]Channel
+	dryRun   Channel
+}
+
+// NewMux creates a Mux falling back to the given Telegram sender.
+func NewMux(repo ChannelStore, log *zap.Logger, telegram scheduler.Sender) *Mux {
+	return &Mux{
+		repo:     repo,
+		log:      log,
+		telegram: telegram,
+		channels: make(map[string]Channel),
+	}
+}
+
+// Handle registers the Channel for a kind (one of the domain.Channel* constants).
+func (m *Mux) Handle(kind string, ch Channel) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.channels[kind] = ch
+}
+
+// SetDryRun sends every reminder, Telegram included, to ch instead of its
+// real channel. Pass nil to turn dry run off.
+func (m *Mux) SetDryRun(ch Channel) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.dryRun = ch
+}
+
+// SendReminders implements scheduler.Sender. The message ID is Telegram's,
+// or 0 for other channels.
+func (m *Mux) SendReminders(chatID int64, items []scheduler.Reminder) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+	// Telegram looks the language up itself, so it is only resolved here
+	// for other channels.
+	msg := Message{ChatID: chatID, Items: items, At: time.Now().UTC()}
+
+	m.mu.RLock()
+	dryRun := m.dryRun
+	m.mu.RUnlock()
+	if dryRun != nil {
+		msg.Lang = m.lang(ctx, chatID)
+		return 0, dryRun.Send(ctx, "", msg)
+	}
+
+	c, err := m.repo.GetChannel(ctx, chatID)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return m.telegram.SendReminders(chatID, items)
+	case err != nil:
+		return 0, fmt.Errorf("load channel: %w", err)
+	case c.Kind == domain.ChannelTelegram:
+		return m.telegram.SendReminders(chatID, items)
+	}
+
+	m.mu.RLock()
+	ch, ok := m.channels[c.Kind]
+	m.mu.RUnlock()
+	if !ok {
+		m.log.Warn("channel not configured, sending to Telegram",
+			zap.Int64("chatID", chatID), zap.String("kind", c.Kind))
+		return m.telegram.SendReminders(chatID, items)
+	}
+	msg.Lang = m.lang(ctx, chatID)
+	if err := ch.Send(ctx, c.Target, msg); err != nil {
+		return 0, fmt.Errorf("%s: %w", c.Kind, err)
+	}
+	return 0, nil
+}
+
+// TargetChat implements scheduler.TargetResolver: a broadcasting chat's
+// reminders land in its channel, so the dispatcher spaces them by the
+// channel's ID. Any other chat, or one whose channel cannot be loaded, is
+// its own target.
+func (m *Mux) TargetChat(chatID int64) int64 {
+	m.mu.RLock()
+	dryRun := m.dryRun
+	m.mu.RUnlock()
+	if dryRun != nil {
+		return chatID
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+	c, err := m.repo.GetChannel(ctx, chatID)
+	if err != nil || c.Kind != domain.ChannelBroadcast {
+		return chatID
+	}
+	target, err := strconv.ParseInt(c.Target, 10, 64)
+	if err != nil {
+		return chatID
+	}
+	return target
+}
+
+// lang is the chat's stored language, else i18n.Default.
+func (m *Mux) lang(ctx context.Context, chatID int64) i18n.Lang {
+	u, err := m.repo.GetUser(ctx, chatID)
+	if err != nil || u.Language == "" {
+		return i18n.Default
+	}
+	return i18n.Match(u.Language)
+}
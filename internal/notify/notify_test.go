@@ -0,0 +1,317 @@
+package notify
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/ykvlv/notification-bot/internal/domain"
+	"github.com/ykvlv/notification-bot/internal/i18n"
+	"github.com/ykvlv/notification-bot/internal/scheduler"
+)
+
+var testItems = []scheduler.Reminder{{DeliveryID: 7, Text: "Drink water"}, {DeliveryID: 8, Text: "Stretch"}}
+
+// smtpServer is a minimal in-process SMTP server. Recipients starting with
+// "bounce" are rejected with 550.
+type smtpServer struct {
+	ln net.Listener
+
+	mu   sync.Mutex
+	mail []receivedMail
+}
+
+type receivedMail struct {
+	from, to, data string
+}
+
+func newSMTPServer(t *testing.T) *smtpServer {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &smtpServer{ln: ln}
+	t.Cleanup(func() { _ = ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *smtpServer) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { _, _ = io.WriteString(conn, line+"\r\n") }
+
+	var cur receivedMail
+	reply("220 localhost test")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		cmd := strings.ToUpper(line)
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250-localhost")
+			reply("250 8BITMIME")
+		case strings.HasPrefix(cmd, "MAIL FROM:"):
+			cur = receivedMail{from: angleAddr(line)}
+			reply("250 ok")
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			to := angleAddr(line)
+			if strings.HasPrefix(to, "bounce") {
+				reply("550 no such user")
+				continue
+			}
+			cur.to = to
+			reply("250 ok")
+		case cmd == "DATA":
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(l)
+			}
+			cur.data = data.String()
+			s.mu.Lock()
+			s.mail = append(s.mail, cur)
+			s.mu.Unlock()
+			reply("250 queued")
+		case cmd == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+// angleAddr extracts the <address> of a MAIL or RCPT command.
+func angleAddr(line string) string {
+	_, rest, _ := strings.Cut(line, "<")
+	addr, _, _ := strings.Cut(rest, ">")
+	return addr
+}
+
+func (s *smtpServer) received() []receivedMail {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]receivedMail(nil), s.mail...)
+}
+
+func TestSMTP_SendsDigest(t *testing.T) {
+	srv := newSMTPServer(t)
+	ch := NewSMTP(SMTPConfig{Addr: srv.ln.Addr().String(), From: "bot@example.com"})
+
+	at := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	if err := ch.Send(context.Background(), "user@example.com", Message{ChatID: 1, Items: testItems, At: at, Lang: i18n.En}); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	got := srv.received()
+	if len(got) != 1 {
+		t.Fatalf("want 1 mail, got %d", len(got))
+	}
+	m := got[0]
+	if m.from != "bot@example.com" || m.to != "user@example.com" {
+		t.Fatalf("envelope %s -> %s", m.from, m.to)
+	}
+	for _, want := range []string{"Subject: 2 reminders\r\n", "\r\n\r\n1. Drink water\r\n2. Stretch\r\n"} {
+		if !strings.Contains(m.data, want) {
+			t.Fatalf("mail lacks %q:\n%s", want, m.data)
+		}
+	}
+	if got := (Message{Items: testItems[:1]}).Subject(); got != i18n.Default.T("email.subject") {
+		t.Fatalf("subject without a language: %q", got)
+	}
+}
+
+func TestSMTP_RejectedRecipientIsBlocked(t *testing.T) {
+	srv := newSMTPServer(t)
+	ch := NewSMTP(SMTPConfig{Addr: srv.ln.Addr().String(), From: "bot@example.com"})
+
+	err := ch.Send(context.Background(), "bounce@example.com", Message{Items: testItems[:1]})
+	var blocked *scheduler.BlockedError
+	if !errors.As(err, &blocked) {
+		t.Fatalf("want BlockedError, got %v", err)
+	}
+}
+
+func TestWebhook_PostsJSON(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		bodies []payload
+		status = http.StatusOK
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("unexpected request %s %s", r.Method, r.Header.Get("Content-Type"))
+		}
+		var p payload
+		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+			t.Errorf("decode: %v", err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		bodies = append(bodies, p)
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+	ch := NewWebhook(srv.Client())
+
+	at := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	if err := ch.Send(context.Background(), srv.URL, Message{ChatID: 42, Items: testItems, At: at}); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if len(bodies) != 1 || bodies[0].ChatID != 42 || !bodies[0].SentAt.Equal(at) ||
+		len(bodies[0].Reminders) != 2 || bodies[0].Reminders[1] != (payloadItem{DeliveryID: 8, Text: "Stretch"}) {
+		t.Fatalf("unexpected payload %+v", bodies)
+	}
+
+	mu.Lock()
+	status = http.StatusServiceUnavailable
+	mu.Unlock()
+	err := ch.Send(context.Background(), srv.URL, Message{Items: testItems})
+	var blocked *scheduler.BlockedError
+	if err == nil || errors.As(err, &blocked) {
+		t.Fatalf("503 must be a retryable error, got %v", err)
+	}
+
+	mu.Lock()
+	status = http.StatusGone
+	mu.Unlock()
+	var rejected *scheduler.RejectedError
+	err = ch.Send(context.Background(), srv.URL, Message{Items: testItems})
+	if !errors.As(err, &rejected) || errors.As(err, &blocked) {
+		t.Fatalf("410 must be RejectedError, got %v", err)
+	}
+}
+
+// fakeChannels is a ChannelStore backed by a map.
+type fakeChannels map[int64]domain.Channel
+
+func (f fakeChannels) GetChannel(_ context.Context, chatID int64) (*domain.Channel, error) {
+	c, ok := f[chatID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &c, nil
+}
+
+func (f fakeChannels) GetUser(context.Context, int64) (*domain.User, error) {
+	return nil, sql.ErrNoRows
+}
+
+// recorder is both a Channel and a Telegram stand-in.
+type recorder struct {
+	mu   sync.Mutex
+	sent []string
+}
+
+func (r *recorder) Send(_ context.Context, target string, m Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sent = append(r.sent, target+": "+m.Text())
+	return nil
+}
+
+func (r *recorder) SendReminders(chatID int64, items []scheduler.Reminder) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sent = append(r.sent, "telegram: "+items[0].Text)
+	return 100, nil
+}
+
+func TestMux_RoutesByStoredChannel(t *testing.T) {
+	tg, email := &recorder{}, &recorder{}
+	mux := NewMux(fakeChannels{
+		2: {ChatID: 2, Kind: domain.ChannelEmail, Target: "a@example.com"},
+		3: {ChatID: 3, Kind: domain.ChannelWebhook, Target: "https://example.com/hook"}, // not registered
+	}, zap.NewNop(), tg)
+	mux.Handle(domain.ChannelEmail, email)
+
+	one := testItems[:1]
+	for chatID, wantID := range map[int64]int{1: 100, 2: 0, 3: 100} {
+		id, err := mux.SendReminders(chatID, one)
+		if err != nil || id != wantID {
+			t.Fatalf("chat %d: got (%d, %v), want message ID %d", chatID, id, err, wantID)
+		}
+	}
+	if len(tg.sent) != 2 || len(email.sent) != 1 || email.sent[0] != "a@example.com: Drink water" {
+		t.Fatalf("telegram %q, email %q", tg.sent, email.sent)
+	}
+}
+
+func TestMux_TargetChat(t *testing.T) {
+	mux := NewMux(fakeChannels{
+		2: {ChatID: 2, Kind: domain.ChannelBroadcast, Target: "-100"},
+		3: {ChatID: 3, Kind: domain.ChannelEmail, Target: "a@example.com"},
+	}, zap.NewNop(), &recorder{})
+	for chatID, want := range map[int64]int64{1: 1, 2: -100, 3: 3} {
+		if got := mux.TargetChat(chatID); got != want {
+			t.Errorf("TargetChat(%d) = %d, want %d", chatID, got, want)
+		}
+	}
+}
+
+func TestMux_DryRunWritesFileSink(t *testing.T) {
+	tg := &recorder{}
+	var buf bytes.Buffer
+	mux := NewMux(fakeChannels{}, zap.NewNop(), tg)
+	mux.SetDryRun(NewFileSink(&buf))
+
+	if _, err := mux.SendReminders(5, testItems); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if len(tg.sent) != 0 {
+		t.Fatalf("dry run reached Telegram: %q", tg.sent)
+	}
+	var p payload
+	if err := json.Unmarshal(buf.Bytes(), &p); err != nil {
+		t.Fatalf("sink line %q: %v", buf.String(), err)
+	}
+	if p.ChatID != 5 || len(p.Reminders) != 2 || !strings.HasSuffix(buf.String(), "}\n") {
+		t.Fatalf("unexpected sink line %q", buf.String())
+	}
+}
+
+func TestHTTPClient_RefusesPrivateAddresses(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}))
+	defer srv.Close()
+
+	if _, err := NewHTTPClient(time.Second, false).Get(srv.URL); !errors.Is(err, errPrivateAddr) {
+		t.Fatalf("loopback must be refused, got %v", err)
+	}
+	resp, err := NewHTTPClient(time.Second, true).Get(srv.URL)
+	if err != nil {
+		t.Fatalf("allowPrivate: %v", err)
+	}
+	resp.Body.Close()
+}
@@ -0,0 +1,124 @@
+package notify
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/ykvlv/notification-bot/internal/scheduler"
+)
+
+// SMTPConfig describes the outgoing mail server.
+type SMTPConfig struct {
+	Addr     string // host:port
+	From     string // envelope and header sender
+	Username string // optional; PLAIN auth is used when set
+	Password string
+}
+
+// SMTP sends each message as a plain-text email to the target address.
+// STARTTLS is used whenever the server offers it.
+type SMTP struct {
+	cfg SMTPConfig
+}
+
+// NewSMTP creates an email channel.
+func NewSMTP(cfg SMTPConfig) *SMTP {
+	return &SMTP{cfg: cfg}
+}
+
+// Send implements Channel. A permanent rejection of the recipient (550, 551,
+// 553) is reported as scheduler.BlockedError.
+func (s *SMTP) Send(ctx context.Context, target string, m Message) error {
+	return s.send(ctx, target, s.compose(target, m.Subject(), m.Text(), m.At))
+}
+
+// Mail sends a plain-text email that is not a reminder, such as an address
+// confirmation code. Errors are classified as for Send.
+func (s *SMTP) Mail(ctx context.Context, to, subject, body string) error {
+	return s.send(ctx, to, s.compose(to, subject, body, time.Now()))
+}
+
+// send delivers a composed message to one recipient.
+func (s *SMTP) send(ctx context.Context, target string, msg []byte) error {
+	host, _, err := net.SplitHostPort(s.cfg.Addr)
+	if err != nil {
+		return err
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", s.cfg.Addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if s.cfg.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(s.cfg.From); err != nil {
+		return err
+	}
+	if err := c.Rcpt(target); err != nil {
+		return classifySMTPError(err)
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// compose builds the RFC 5322 message with CRLF line endings.
+func (s *SMTP) compose(to, subject, text string, at time.Time) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", s.cfg.From)
+	fmt.Fprintf(&b, "To: %s\r\n", to)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", at.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	body := strings.ReplaceAll(strings.TrimRight(text, "\n"), "\n", "\r\n")
+	b.WriteString(body)
+	b.WriteString("\r\n")
+	return []byte(b.String())
+}
+
+func classifySMTPError(err error) error {
+	var tpErr *textproto.Error
+	if errors.As(err, &tpErr) {
+		switch tpErr.Code {
+		case 550, 551, 553:
+			return &scheduler.BlockedError{Reason: "mailbox rejected: " + tpErr.Msg}
+		}
+	}
+	return err
+}
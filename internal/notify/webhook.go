@@ -0,0 +1,77 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/ykvlv/notification-bot/internal/scheduler"
+)
+
+// payload is the JSON body of a webhook POST and of a file sink line.
+type payload struct {
+	ChatID    int64         `json:"chat_id"`
+	SentAt    time.Time     `json:"sent_at"`
+	Reminders []payloadItem `json:"reminders"`
+}
+
+type payloadItem struct {
+	DeliveryID int64  `json:"delivery_id"`
+	Text       string `json:"text"`
+}
+
+func newPayload(m Message) payload {
+	p := payload{ChatID: m.ChatID, SentAt: m.At, Reminders: make([]payloadItem, len(m.Items))}
+	for i, it := range m.Items {
+		p.Reminders[i] = payloadItem{DeliveryID: it.DeliveryID, Text: it.Text}
+	}
+	return p
+}
+
+// Webhook POSTs each message as JSON to the target URL. Any 2xx response
+// is success; 404 and 410 mean the endpoint is gone and fail the delivery
+// without retries, leaving the user's reminders on.
+type Webhook struct {
+	client *http.Client
+}
+
+// NewWebhook creates a webhook channel. A nil client uses one with a 10s timeout.
+func NewWebhook(client *http.Client) *Webhook {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &Webhook{client: client}
+}
+
+// Send implements Channel.
+func (w *Webhook) Send(ctx context.Context, target string, m Message) error {
+	body, err := json.Marshal(newPayload(m))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return &scheduler.RejectedError{Reason: "bad webhook URL: " + err.Error()}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "notification-bot")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return &scheduler.RejectedError{Reason: "webhook returned " + resp.Status}
+	}
+	return fmt.Errorf("webhook returned %s", resp.Status)
+}
@@ -0,0 +1,202 @@
+package scheduler
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is the scheduler's source of time. The real clock is used in
+// production; FakeClock runs the scheduler on virtual time in tests and
+// simulations, including the dispatcher's rate limits and digest windows.
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+	NewTicker(d time.Duration) Ticker
+}
+
+// Timer is the subset of *time.Timer the scheduler uses.
+type Timer interface {
+	C() <-chan time.Time
+	Reset(d time.Duration)
+	Stop()
+}
+
+// Ticker is the subset of *time.Ticker the scheduler uses.
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// RealClock returns a Clock backed by the time package.
+func RealClock() Clock { return realClock{} }
+
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+func (realClock) NewTimer(d time.Duration) Timer { return realTimer{time.NewTimer(d)} }
+
+func (realClock) NewTicker(d time.Duration) Ticker { return realTicker{time.NewTicker(d)} }
+
+type realTimer struct{ t *time.Timer }
+
+func (r realTimer) C() <-chan time.Time   { return r.t.C }
+func (r realTimer) Reset(d time.Duration) { r.t.Reset(d) }
+func (r realTimer) Stop()                 { r.t.Stop() }
+
+type realTicker struct{ t *time.Ticker }
+
+func (r realTicker) C() <-chan time.Time { return r.t.C }
+func (r realTicker) Stop()               { r.t.Stop() }
+
+// FakeClock is a manually advanced Clock. Timers and tickers fire when Set or
+// Advance moves the time past their deadline; like real ones, they drop a
+// tick if the previous one has not been received yet.
+type FakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []*fakeTimer
+}
+
+// NewFakeClock returns a FakeClock set to now.
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// Now returns the current virtual time.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance moves the clock forward by d.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.Set(c.Now().Add(d))
+}
+
+// Set moves the clock to t and fires every timer and ticker due by then.
+// Moving backwards is ignored.
+func (c *FakeClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if t.Before(c.now) {
+		return
+	}
+	c.now = t
+	for _, w := range c.waiters {
+		w.fireIfDue(t)
+	}
+}
+
+// NewTimer creates a one-shot timer that fires d from the current virtual time.
+func (c *FakeClock) NewTimer(d time.Duration) Timer {
+	return c.add(d, 0)
+}
+
+// NewTicker creates a ticker that fires every d of virtual time.
+func (c *FakeClock) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("scheduler: non-positive ticker interval")
+	}
+	return c.add(d, d)
+}
+
+// handled reports whether every timer that fired has since been stopped or
+// reset, i.e. its owner has finished acting on the tick. Tickers are not
+// tracked.
+func (c *FakeClock) handled() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, w := range c.waiters {
+		if w.fired {
+			return false
+		}
+	}
+	return true
+}
+
+// nextDeadline returns when the earliest active timer or ticker fires.
+func (c *FakeClock) nextDeadline() (time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var next time.Time
+	for _, w := range c.waiters {
+		if w.active && (next.IsZero() || w.at.Before(next)) {
+			next = w.at
+		}
+	}
+	return next, !next.IsZero()
+}
+
+func (c *FakeClock) add(d, period time.Duration) *fakeTimer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &fakeTimer{
+		clock:  c,
+		ch:     make(chan time.Time, 1),
+		at:     c.now.Add(d),
+		period: period,
+		active: true,
+	}
+	c.waiters = append(c.waiters, t)
+	t.fireIfDue(c.now)
+	return t
+}
+
+// fakeTimer implements both Timer and Ticker; period is 0 for timers.
+// Its fields are guarded by clock.mu.
+type fakeTimer struct {
+	clock  *FakeClock
+	ch     chan time.Time
+	at     time.Time
+	period time.Duration
+	active bool
+	fired  bool // a timer fired and was not stopped or reset since
+}
+
+func (t *fakeTimer) fireIfDue(now time.Time) {
+	if !t.active || t.at.After(now) {
+		return
+	}
+	select {
+	case t.ch <- t.at:
+	default:
+	}
+	if t.period == 0 {
+		t.active = false
+		t.fired = true
+		return
+	}
+	for !t.at.After(now) {
+		t.at = t.at.Add(t.period)
+	}
+}
+
+func (t *fakeTimer) C() <-chan time.Time { return t.ch }
+
+func (t *fakeTimer) Reset(d time.Duration) {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	// Like Go 1.23+ timers, a reset discards a tick that was not received.
+	select {
+	case <-t.ch:
+	default:
+	}
+	t.at = t.clock.now.Add(d)
+	t.active = true
+	t.fired = false
+	t.fireIfDue(t.clock.now)
+}
+
+func (t *fakeTimer) Stop() {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	// Like Go 1.23+ timers, no tick is received after Stop returns.
+	select {
+	case <-t.ch:
+	default:
+	}
+	t.active = false
+	t.fired = false
+}
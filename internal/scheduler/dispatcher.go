@@ -0,0 +1,318 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DispatchConfig controls concurrency and rate limits for outgoing messages.
+// Defaults follow Telegram's documented limits: ~30 msg/s globally and
+// ~1 msg/s per chat.
+type DispatchConfig struct {
+	Workers         int           // number of concurrent senders
+	RatePerSec      float64       // global send rate; <= 0 disables the limit
+	Burst           int           // global bucket size
+	PerChatInterval time.Duration // minimum gap between two sends to one chat
+	QueueSize       int           // per-worker queue capacity
+	Clock           Clock         // nil = RealClock
+}
+
+// DefaultDispatchConfig returns limits suitable for the public Bot API.
+func DefaultDispatchConfig() DispatchConfig {
+	return DispatchConfig{
+		Workers:         8,
+		RatePerSec:      30,
+		Burst:           1,
+		PerChatInterval: time.Second,
+		QueueSize:       1024,
+	}
+}
+
+// job is a single reminder to deliver. Both callbacks run on the worker
+// goroutine: start right before the send (returning false drops the job
+// without calling done), done with the send result.
+//
+// A job with a digest window is held for that long; further jobs for the
+// same chat arriving meanwhile join it and all are sent as one message.
+type job struct {
+	chatID int64
+	target int64 // chat the message lands in; set by Submit
+	item   Reminder
+	digest time.Duration
+	start  func() bool
+	done   func(messageID int, err error)
+}
+
+// heldBatch is a chat's jobs waiting for their digest window to close.
+type heldBatch struct {
+	jobs    []job
+	flushAt time.Time
+}
+
+// Dispatcher sends messages through a pool of workers behind a global token
+// bucket. Jobs are sharded by the chat they land in, which differs from the
+// user's chat for broadcasts (see TargetResolver), so that all messages to
+// one chat are handled by the same worker: this keeps them in order and lets
+// each worker enforce the per-chat interval without shared state.
+//
+// Digest windows, per-chat spacing and the global bucket all wait on the
+// configured clock, so a FakeClock drives them in virtual time.
+type Dispatcher struct {
+	sender Sender
+	cfg    DispatchConfig
+	clock  Clock
+	global *tokenBucket
+	shards []*shard
+	wg     sync.WaitGroup
+}
+
+// shard is one worker's queue and what the worker is up to. pending counts
+// jobs submitted to it and wake-ups the worker has not finished handling;
+// sleeping is set while the worker waits on the clock with work in hand.
+type shard struct {
+	jobs     chan job
+	pending  atomic.Int64
+	sleeping atomic.Bool
+}
+
+// NewDispatcher creates a dispatcher. Call Start before Submit.
+func NewDispatcher(sender Sender, cfg DispatchConfig) *Dispatcher {
+	def := DefaultDispatchConfig()
+	if cfg.Workers <= 0 {
+		cfg.Workers = def.Workers
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = def.QueueSize
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = RealClock()
+	}
+	d := &Dispatcher{
+		sender: sender,
+		cfg:    cfg,
+		clock:  cfg.Clock,
+		global: newTokenBucket(cfg.Clock, cfg.RatePerSec, cfg.Burst),
+		shards: make([]*shard, cfg.Workers),
+	}
+	for i := range d.shards {
+		d.shards[i] = &shard{jobs: make(chan job, cfg.QueueSize)}
+	}
+	return d
+}
+
+// Start launches the workers. They exit when ctx is canceled; pending and
+// held jobs are dropped without calling done.
+func (d *Dispatcher) Start(ctx context.Context) {
+	for i := range d.shards {
+		d.wg.Add(1)
+		go d.worker(ctx, d.shards[i])
+	}
+}
+
+// Wait blocks until all workers have exited.
+func (d *Dispatcher) Wait() {
+	d.wg.Wait()
+}
+
+// Submit enqueues a job on its target chat's shard. It blocks if that
+// shard's queue is full, which applies backpressure to the scheduler loop.
+func (d *Dispatcher) Submit(ctx context.Context, j job) error {
+	j.target = j.chatID
+	if r, ok := d.sender.(TargetResolver); ok {
+		j.target = r.TargetChat(j.chatID)
+	}
+	sh := d.shards[d.shardOf(j.target)]
+	sh.pending.Add(1)
+	select {
+	case sh.jobs <- j:
+		return nil
+	case <-ctx.Done():
+		sh.pending.Add(-1)
+		return ctx.Err()
+	}
+}
+
+// idle reports whether no worker can make progress until the clock moves:
+// each has nothing to do or is sleeping. Callers driving a FakeClock must
+// check that its fired timers are handled first.
+func (d *Dispatcher) idle() bool {
+	for _, sh := range d.shards {
+		if sh.pending.Load() > 0 && !sh.sleeping.Load() {
+			return false
+		}
+	}
+	return true
+}
+
+func (d *Dispatcher) shardOf(chatID int64) int {
+	n := int64(len(d.shards))
+	return int(((chatID % n) + n) % n)
+}
+
+func (d *Dispatcher) worker(ctx context.Context, sh *shard) {
+	defer d.wg.Done()
+	lastSent := make(map[int64]time.Time) // target chats pinned to this worker only
+	held := make(map[int64]*heldBatch)    // digests being collected, by user chat
+
+	// The flush timer is armed only while the worker waits for work, so it
+	// never waits on two timers at once. An overdue digest fires right away
+	// when the timer is re-armed.
+	flush := d.clock.NewTimer(time.Hour)
+	flush.Stop()
+	defer flush.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case j := <-sh.jobs:
+			flush.Stop()
+			if !hold(j, held, d.clock.Now()) {
+				d.run(ctx, sh, []job{j}, lastSent)
+			}
+			d.rearmFlush(flush, held)
+			sh.pending.Add(-1)
+		case <-flush.C():
+			sh.pending.Add(1)
+			flush.Stop()
+			now := d.clock.Now()
+			for chatID, b := range held {
+				if !b.flushAt.After(now) {
+					delete(held, chatID)
+					d.run(ctx, sh, b.jobs, lastSent)
+				}
+			}
+			d.rearmFlush(flush, held)
+			sh.pending.Add(-1)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		if len(lastSent) > 1024 {
+			pruneBefore(lastSent, d.clock.Now().Add(-d.cfg.PerChatInterval))
+		}
+	}
+}
+
+// hold adds j to its chat's open digest, which also keeps per-chat order,
+// or opens a digest if j has a window. It reports whether j was held.
+func hold(j job, held map[int64]*heldBatch, now time.Time) bool {
+	if b, ok := held[j.chatID]; ok {
+		b.jobs = append(b.jobs, j)
+		return true
+	}
+	if j.digest <= 0 {
+		return false
+	}
+	held[j.chatID] = &heldBatch{jobs: []job{j}, flushAt: now.Add(j.digest)}
+	return true
+}
+
+// rearmFlush points the flush timer at the earliest held digest.
+func (d *Dispatcher) rearmFlush(t Timer, held map[int64]*heldBatch) {
+	var next time.Time
+	for _, b := range held {
+		if next.IsZero() || b.flushAt.Before(next) {
+			next = b.flushAt
+		}
+	}
+	if next.IsZero() {
+		t.Stop()
+		return
+	}
+	t.Reset(next.Sub(d.clock.Now()))
+}
+
+// run sends a batch of jobs for one chat and reports the result to each job
+// that was started, even when shutting down: a started job is sending in the
+// store and must be settled. Jobs not started yet are dropped.
+func (d *Dispatcher) run(ctx context.Context, sh *shard, batch []job, lastSent map[int64]time.Time) {
+	started, messageID, err := d.send(ctx, sh, batch, lastSent)
+	for _, j := range started {
+		if j.done != nil {
+			j.done(messageID, err)
+		}
+	}
+}
+
+// maxRateLimitedResends bounds how many times a single message is re-sent in
+// place after 429 responses before the error is reported to the scheduler.
+const maxRateLimitedResends = 3
+
+// send delivers a batch of jobs for one chat as a single message, honoring
+// the target chat's spacing and the global bucket. It returns the jobs whose start
+// hook accepted them; nothing is sent if there are none. On a 429 it pauses
+// the whole dispatcher for retry_after and re-sends the same message, so
+// per-chat order is preserved.
+func (d *Dispatcher) send(ctx context.Context, sh *shard, batch []job, lastSent map[int64]time.Time) ([]job, int, error) {
+	chatID, target := batch[0].chatID, batch[0].target
+	var (
+		started []job
+		items   []Reminder
+	)
+	for attempt := 0; ; attempt++ {
+		// Per-chat spacing first, then the global token: taking the token
+		// before a long per-chat wait would waste global capacity.
+		if last, seen := lastSent[target]; seen {
+			if err := d.sleep(ctx, sh, last.Add(d.cfg.PerChatInterval).Sub(d.clock.Now())); err != nil {
+				return started, 0, err
+			}
+		}
+		if err := d.sleep(ctx, sh, d.global.reserve()); err != nil {
+			return started, 0, err
+		}
+		if attempt == 0 {
+			for _, j := range batch {
+				if j.start == nil || j.start() {
+					started = append(started, j)
+					items = append(items, j.item)
+				}
+			}
+			if len(started) == 0 {
+				return nil, 0, nil
+			}
+		}
+
+		messageID, err := d.sender.SendReminders(chatID, items)
+		lastSent[target] = d.clock.Now()
+
+		ra, limited := asRetryAfter(err)
+		if !limited {
+			return started, messageID, err
+		}
+		d.global.PauseUntil(d.clock.Now().Add(ra.After))
+		if attempt >= maxRateLimitedResends {
+			return started, 0, err
+		}
+	}
+}
+
+// sleep waits for dur on the dispatcher's clock or until ctx is canceled.
+func (d *Dispatcher) sleep(ctx context.Context, sh *shard, dur time.Duration) error {
+	if dur <= 0 {
+		return ctx.Err()
+	}
+	t := d.clock.NewTimer(dur)
+	defer t.Stop()
+	// Deferred calls run in reverse: the flag is cleared before the timer
+	// is stopped, which a FakeClock driver relies on.
+	sh.sleeping.Store(true)
+	defer sh.sleeping.Store(false)
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C():
+		return nil
+	}
+}
+
+// pruneBefore drops entries older than cutoff; they no longer constrain sends.
+func pruneBefore(m map[int64]time.Time, cutoff time.Time) {
+	for k, t := range m {
+		if t.Before(cutoff) {
+			delete(m, k)
+		}
+	}
+}
@@ -0,0 +1,302 @@
+package scheduler
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSender records every send with its wall-clock time.
+type fakeSender struct {
+	mu    sync.Mutex
+	delay time.Duration
+	fail  func(call int) error // optional: error to return for the n-th call (0-based)
+	calls int
+	sent  []sentMsg
+}
+
+type sentMsg struct {
+	chatID int64
+	text   string
+	at     time.Time
+}
+
+func (f *fakeSender) SendReminders(chatID int64, items []Reminder) (int, error) {
+	if f.delay > 0 {
+		time.Sleep(f.delay)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	call := f.calls
+	f.calls++
+	if f.fail != nil {
+		if err := f.fail(call); err != nil {
+			return 0, err
+		}
+	}
+	f.sent = append(f.sent, sentMsg{chatID: chatID, text: joinTexts(items), at: time.Now()})
+	return call + 1, nil
+}
+
+// joinTexts renders a batch as "a + b + c".
+func joinTexts(items []Reminder) string {
+	texts := make([]string, len(items))
+	for i, it := range items {
+		texts[i] = it.Text
+	}
+	return strings.Join(texts, " + ")
+}
+
+func (f *fakeSender) snapshot() []sentMsg {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]sentMsg(nil), f.sent...)
+}
+
+// runJobs submits the jobs and waits until all of them are done.
+func runJobs(t *testing.T, d *Dispatcher, jobs []job) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		cancel()
+		d.Wait()
+	}()
+	d.Start(ctx)
+
+	var wg sync.WaitGroup
+	wg.Add(len(jobs))
+	for _, j := range jobs {
+		j.done = func(int, error) { wg.Done() }
+		if err := d.Submit(ctx, j); err != nil {
+			t.Fatalf("submit: %v", err)
+		}
+	}
+
+	finished := make(chan struct{})
+	go func() { wg.Wait(); close(finished) }()
+	select {
+	case <-finished:
+	case <-time.After(10 * time.Second):
+		t.Fatalf("jobs did not finish in time")
+	}
+}
+
+func TestDispatcher_GlobalRateLimit(t *testing.T) {
+	const rate = 100
+	fs := &fakeSender{}
+	d := NewDispatcher(fs, DispatchConfig{Workers: 8, RatePerSec: rate, Burst: 1})
+
+	var jobs []job
+	for i := 0; i < 2*rate+1; i++ {
+		jobs = append(jobs, job{chatID: int64(i), item: Reminder{Text: "x"}}) // distinct chats: no per-chat waits
+	}
+	runJobs(t, d, jobs)
+
+	sent := fs.snapshot()
+	if len(sent) != len(jobs) {
+		t.Fatalf("want %d sends, got %d", len(jobs), len(sent))
+	}
+	// Any rate+1 consecutive sends must span at least one second (minus scheduling slack).
+	for i := 0; i+rate < len(sent); i++ {
+		if span := sent[i+rate].at.Sub(sent[i].at); span < 950*time.Millisecond {
+			t.Fatalf("%d sends within %s, limit is %d/s", rate+1, span, rate)
+		}
+	}
+}
+
+func TestDispatcher_PerChatIntervalAndOrder(t *testing.T) {
+	const gap = 100 * time.Millisecond
+	fs := &fakeSender{}
+	d := NewDispatcher(fs, DispatchConfig{Workers: 4, PerChatInterval: gap})
+
+	var jobs []job
+	for i := 0; i < 5; i++ {
+		for chat := int64(1); chat <= 3; chat++ {
+			jobs = append(jobs, job{chatID: chat, item: Reminder{Text: string(rune('a' + i))}})
+		}
+	}
+	runJobs(t, d, jobs)
+
+	last := map[int64]sentMsg{}
+	for _, m := range fs.snapshot() {
+		if prev, ok := last[m.chatID]; ok {
+			if m.text <= prev.text {
+				t.Fatalf("chat %d: %q sent after %q", m.chatID, m.text, prev.text)
+			}
+			if el := m.at.Sub(prev.at); el < gap-5*time.Millisecond {
+				t.Fatalf("chat %d: sends %s apart, want >= %s", m.chatID, el, gap)
+			}
+		}
+		last[m.chatID] = m
+	}
+}
+
+// channelSender sends every chat's reminders to one channel.
+type channelSender struct {
+	fakeSender
+	channel int64
+}
+
+func (c *channelSender) TargetChat(int64) int64 { return c.channel }
+
+func TestDispatcher_SpacesChatsSharingATarget(t *testing.T) {
+	const gap = 100 * time.Millisecond
+	fs := &channelSender{channel: -100}
+	d := NewDispatcher(fs, DispatchConfig{Workers: 4, PerChatInterval: gap})
+
+	var jobs []job
+	for chat := int64(1); chat <= 4; chat++ {
+		jobs = append(jobs, job{chatID: chat, item: Reminder{Text: "x"}})
+	}
+	runJobs(t, d, jobs)
+
+	sent := fs.snapshot()
+	for i := 1; i < len(sent); i++ {
+		if el := sent[i].at.Sub(sent[i-1].at); el < gap-5*time.Millisecond {
+			t.Fatalf("sends to one channel %s apart, want >= %s", el, gap)
+		}
+	}
+}
+
+func TestDispatcher_SlowSendDoesNotBlockOtherChats(t *testing.T) {
+	const delay = 50 * time.Millisecond
+	fs := &fakeSender{delay: delay}
+	d := NewDispatcher(fs, DispatchConfig{Workers: 8})
+
+	var jobs []job
+	for chat := int64(0); chat < 16; chat++ {
+		jobs = append(jobs, job{chatID: chat, item: Reminder{Text: "x"}})
+	}
+	start := time.Now()
+	runJobs(t, d, jobs)
+
+	// Sequential sending would take 16*delay; 8 workers need about 2*delay.
+	if el := time.Since(start); el > 8*delay {
+		t.Fatalf("took %s, sends are not concurrent", el)
+	}
+}
+
+func TestDispatcher_RetryAfterPausesAllChats(t *testing.T) {
+	const pause = 300 * time.Millisecond
+	fs := &fakeSender{fail: func(call int) error {
+		if call == 0 {
+			return &RetryAfterError{After: pause}
+		}
+		return nil
+	}}
+	d := NewDispatcher(fs, DispatchConfig{Workers: 4})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		cancel()
+		d.Wait()
+	}()
+	d.Start(ctx)
+
+	var wg sync.WaitGroup
+	submit := func(chatID int64) {
+		wg.Add(1)
+		if err := d.Submit(ctx, job{chatID: chatID, item: Reminder{Text: "x"}, done: func(int, error) { wg.Done() }}); err != nil {
+			t.Fatalf("submit: %v", err)
+		}
+	}
+
+	// Chat 0 hits the 429; other chats (on other workers) are submitted after it.
+	start := time.Now()
+	submit(0)
+	time.Sleep(20 * time.Millisecond)
+	for chat := int64(1); chat < 4; chat++ {
+		submit(chat)
+	}
+	wg.Wait()
+
+	sent := fs.snapshot()
+	if len(sent) != 4 {
+		t.Fatalf("want 4 sends (rate-limited job re-sent), got %d", len(sent))
+	}
+	for _, m := range sent {
+		if el := m.at.Sub(start); el < pause-10*time.Millisecond {
+			t.Fatalf("chat %d sent after %s, during the retry_after pause", m.chatID, el)
+		}
+	}
+}
+
+func TestDispatcher_DigestBundlesChatJobs(t *testing.T) {
+	const window = 50 * time.Millisecond
+	fs := &fakeSender{}
+	d := NewDispatcher(fs, DispatchConfig{Workers: 2})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		cancel()
+		d.Wait()
+	}()
+	d.Start(ctx)
+
+	var (
+		mu  sync.Mutex
+		ids = map[int64]int{} // delivery ID -> message ID
+		wg  sync.WaitGroup
+	)
+	submit := func(chatID, id int64, text string, digest time.Duration) {
+		wg.Add(1)
+		j := job{chatID: chatID, item: Reminder{DeliveryID: id, Text: text}, digest: digest,
+			done: func(messageID int, err error) {
+				if err != nil {
+					t.Errorf("delivery %d: %v", id, err)
+				}
+				mu.Lock()
+				ids[id] = messageID
+				mu.Unlock()
+				wg.Done()
+			}}
+		if err := d.Submit(ctx, j); err != nil {
+			t.Fatalf("submit: %v", err)
+		}
+	}
+
+	submit(1, 1, "a", window)
+	submit(2, 2, "solo", 0) // another chat without digest is not held
+	submit(1, 3, "b", window)
+	submit(1, 4, "c", 0) // joins the open digest even without its own window
+	wg.Wait()
+
+	got := map[int64]string{}
+	for _, m := range fs.snapshot() {
+		got[m.chatID] = m.text
+	}
+	if len(fs.snapshot()) != 2 || got[1] != "a + b + c" || got[2] != "solo" {
+		t.Fatalf("want one digest for chat 1 and one message for chat 2, got %+v", fs.snapshot())
+	}
+	if ids[1] == 0 || ids[1] != ids[3] || ids[1] != ids[4] {
+		t.Fatalf("digest items must share one message ID, got %v", ids)
+	}
+}
+
+func TestDispatcher_ShutdownSettlesStartedJobs(t *testing.T) {
+	fs := &fakeSender{delay: 50 * time.Millisecond}
+	d := NewDispatcher(fs, DispatchConfig{Workers: 1})
+	ctx, cancel := context.WithCancel(context.Background())
+	d.Start(ctx)
+
+	started := make(chan struct{})
+	var (
+		messageID int
+		doneErr   error
+		done      bool
+	)
+	j := job{chatID: 1, item: Reminder{Text: "x"},
+		start: func() bool { close(started); return true },
+		done:  func(id int, err error) { messageID, doneErr, done = id, err, true },
+	}
+	if err := d.Submit(ctx, j); err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	<-started
+	cancel() // shut down while the message is on its way
+	d.Wait()
+
+	if !done || doneErr != nil || messageID != 1 {
+		t.Fatalf("started job not settled: done=%v id=%d err=%v", done, messageID, doneErr)
+	}
+}
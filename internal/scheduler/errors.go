@@ -0,0 +1,53 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Send errors a Sender may return (possibly wrapped) so the scheduler can
+// react instead of retrying blindly. telegram.Router maps Bot API responses
+// onto these.
+
+// BlockedError means the recipient can no longer be reached (bot blocked,
+// user deactivated, bot kicked from the chat). Retrying will not help.
+type BlockedError struct {
+	Reason string
+}
+
+func (e *BlockedError) Error() string { return "recipient unreachable: " + e.Reason }
+
+// RejectedError means the channel refused this delivery for good (a webhook
+// endpoint that is gone, an invalid target). Retrying will not help, but the
+// user is still reachable otherwise, so only the delivery fails.
+type RejectedError struct {
+	Reason string
+}
+
+func (e *RejectedError) Error() string { return "delivery rejected: " + e.Reason }
+
+// RetryAfterError means the API asked us to back off (HTTP 429). The pause
+// applies to all sends, not just this chat.
+type RetryAfterError struct {
+	After time.Duration
+}
+
+func (e *RetryAfterError) Error() string { return fmt.Sprintf("rate limited, retry after %s", e.After) }
+
+// ChatMigratedError means a group was upgraded to a supergroup and now lives
+// under a new chat ID.
+type ChatMigratedError struct {
+	NewChatID int64
+}
+
+func (e *ChatMigratedError) Error() string {
+	return fmt.Sprintf("chat migrated to %d", e.NewChatID)
+}
+
+// asRetryAfter extracts a RetryAfterError from err.
+func asRetryAfter(err error) (*RetryAfterError, bool) {
+	var ra *RetryAfterError
+	ok := errors.As(err, &ra)
+	return ra, ok
+}
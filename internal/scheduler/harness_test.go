@@ -0,0 +1,226 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/ykvlv/notification-bot/internal/domain"
+)
+
+// simSender records sends at virtual time and can be told to fail.
+type simSender struct {
+	clock *FakeClock
+
+	mu   sync.Mutex
+	fail func(chatID int64, call int) error // optional; call is 0-based per chat
+	call map[int64]int
+	sent []string
+}
+
+func (f *simSender) SendReminders(chatID int64, items []Reminder) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := f.call[chatID]
+	f.call[chatID]++
+	if f.fail != nil {
+		if err := f.fail(chatID, n); err != nil {
+			return 0, err
+		}
+	}
+	f.sent = append(f.sent, fmt.Sprintf("%s #%d %s",
+		f.clock.Now().UTC().Format("2006-01-02 15:04:05"), chatID, joinTexts(items)))
+	return len(f.sent), nil
+}
+
+// harness drives a Scheduler on virtual time. Instead of running the Run
+// loop, it performs the same steps synchronously, jumping the clock from one
+// due entry or dispatcher timer to the next and waiting for the dispatcher
+// after each step, so the order of deliveries is deterministic.
+type harness struct {
+	t      *testing.T
+	ctx    context.Context
+	clock  *FakeClock
+	repo   *memRepo
+	sender *simSender
+	s      *Scheduler
+
+	nextReconcile time.Time
+}
+
+// newHarness starts a harness with one worker and no rate limits, so sends
+// happen in submission order at the time they are due.
+func newHarness(t *testing.T, start time.Time) *harness {
+	t.Helper()
+	return newHarnessWith(t, start, DispatchConfig{Workers: 1})
+}
+
+// newHarnessWith starts a harness whose dispatcher uses cfg; its clock is
+// always the harness clock.
+func newHarnessWith(t *testing.T, start time.Time, cfg DispatchConfig) *harness {
+	t.Helper()
+	clock := NewFakeClock(start)
+	repo := newMemRepo(clock)
+	sender := &simSender{clock: clock, call: make(map[int64]int)}
+	cfg.Clock = clock
+	s := New(repo, zap.NewNop(), sender, Config{
+		Dispatch:   cfg,
+		Retry:      DefaultRetryPolicy(),
+		InstanceID: "test",
+		Clock:      clock,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(func() {
+		cancel()
+		s.dispatch.Wait()
+	})
+	s.dispatch.Start(ctx)
+
+	h := &harness{t: t, ctx: ctx, clock: clock, repo: repo, sender: sender, s: s}
+	h.reconcile()
+	return h
+}
+
+// addUser stores u with its first fire time computed like the bot does.
+func (h *harness) addUser(u domain.User) {
+	h.t.Helper()
+	next := domain.NextFire(h.clock.Now().UTC(), &u)
+	u.NextFireAt = &next
+	if err := h.repo.UpsertUser(h.ctx, &u); err != nil {
+		h.t.Fatal(err)
+	}
+	h.s.Notify(u.ChatID)
+}
+
+// update changes a user's settings and reschedules it, like the settings handlers.
+func (h *harness) update(chatID int64, fn func(u *domain.User)) {
+	h.t.Helper()
+	u, err := h.repo.GetUser(h.ctx, chatID)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	fn(u)
+	next := domain.NextFire(h.clock.Now().UTC(), u)
+	u.NextFireAt = &next
+	if err := h.repo.UpsertUser(h.ctx, u); err != nil {
+		h.t.Fatal(err)
+	}
+	h.s.Notify(chatID)
+}
+
+func (h *harness) pause(chatID int64) {
+	h.t.Helper()
+	if err := h.repo.SetEnabled(h.ctx, chatID, false); err != nil {
+		h.t.Fatal(err)
+	}
+	h.s.Notify(chatID)
+}
+
+func (h *harness) resume(chatID int64) {
+	h.t.Helper()
+	if err := h.repo.SetEnabled(h.ctx, chatID, true); err != nil {
+		h.t.Fatal(err)
+	}
+	h.update(chatID, func(*domain.User) {})
+}
+
+// runUntil advances virtual time to end, delivering everything due on the way.
+func (h *harness) runUntil(end time.Time) {
+	h.t.Helper()
+	for {
+		h.settle()
+		next := h.nextReconcile
+		if at, ok := h.s.nextWake(); ok && at.Before(next) {
+			next = at
+		}
+		if at, ok := h.clock.nextDeadline(); ok && at.Before(next) {
+			next = at // a digest window or rate limit the dispatcher waits out
+		}
+		if next.After(end) {
+			h.clock.Set(end)
+			return
+		}
+		h.clock.Set(next) // no-op if next is already past
+		if !h.clock.Now().Before(h.nextReconcile) {
+			h.reconcile()
+		}
+		h.s.fireDue(h.ctx)
+	}
+}
+
+// runFor advances virtual time by d.
+func (h *harness) runFor(d time.Duration) {
+	h.t.Helper()
+	h.runUntil(h.clock.Now().Add(d))
+}
+
+func (h *harness) reconcile() {
+	h.s.recover(h.ctx, "")
+	h.s.reconcile(h.ctx)
+	h.nextReconcile = h.clock.Now().Add(reconcileEvery)
+}
+
+// settle waits for in-flight deliveries and applies queued change
+// notifications until there is nothing left to do at the current time.
+// Deliveries the dispatcher holds until a later time stay in flight.
+func (h *harness) settle() {
+	h.t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		h.s.mu.Lock()
+		busy := len(h.s.inflight) > 0
+		h.s.mu.Unlock()
+		if busy && !h.dispatchWaiting() {
+			if time.Now().After(deadline) {
+				h.t.Fatal("deliveries stuck in flight")
+			}
+			time.Sleep(50 * time.Microsecond)
+			continue
+		}
+
+		select {
+		case c := <-h.s.changes:
+			h.s.refresh(h.ctx, c)
+			continue
+		default:
+		}
+		if h.s.overrun.Swap(false) {
+			h.s.reconcile(h.ctx)
+			continue
+		}
+		return
+	}
+}
+
+// dispatchWaiting reports whether the dispatcher's workers are all waiting
+// for the clock. The order of the checks matters: a worker woken by a timer
+// counts as busy before it stops or resets that timer.
+func (h *harness) dispatchWaiting() bool {
+	return h.clock.handled() && h.s.dispatch.idle()
+}
+
+// sent returns every delivery so far as "YYYY-MM-DD hh:mm:ss #chat text", in order.
+func (h *harness) sent() []string {
+	h.sender.mu.Lock()
+	defer h.sender.mu.Unlock()
+	return append([]string(nil), h.sender.sent...)
+}
+
+// expectSent fails unless exactly want was delivered so far.
+func (h *harness) expectSent(want ...string) {
+	h.t.Helper()
+	got := h.sent()
+	if len(got) != len(want) {
+		h.t.Fatalf("got %d deliveries, want %d:\ngot  %q\nwant %q", len(got), len(want), got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			h.t.Fatalf("delivery %d: got %q, want %q\nall: %q", i, got[i], want[i], got)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package scheduler
+
+import (
+	"container/heap"
+	"time"
+)
+
+// entry is a single pending fire: a key (chat or delivery ID) and the UTC
+// time it is due.
+type entry struct {
+	key   int64
+	at    time.Time
+	index int // position in the heap, maintained by heap.Interface
+}
+
+// fireQueue is a min-heap of entries ordered by fire time, with an index
+// by key so that an entry can be moved or removed in O(log n).
+type fireQueue struct {
+	items []*entry
+	byKey map[int64]*entry
+}
+
+func newFireQueue() *fireQueue {
+	return &fireQueue{byKey: make(map[int64]*entry)}
+}
+
+// heap.Interface implementation (do not call directly; use the methods below).
+
+func (q *fireQueue) Len() int { return len(q.items) }
+
+func (q *fireQueue) Less(i, j int) bool {
+	if q.items[i].at.Equal(q.items[j].at) {
+		return q.items[i].key < q.items[j].key
+	}
+	return q.items[i].at.Before(q.items[j].at)
+}
+
+func (q *fireQueue) Swap(i, j int) {
+	q.items[i], q.items[j] = q.items[j], q.items[i]
+	q.items[i].index = i
+	q.items[j].index = j
+}
+
+func (q *fireQueue) Push(x any) {
+	e := x.(*entry)
+	e.index = len(q.items)
+	q.items = append(q.items, e)
+}
+
+func (q *fireQueue) Pop() any {
+	old := q.items
+	n := len(old)
+	e := old[n-1]
+	old[n-1] = nil
+	q.items = old[:n-1]
+	e.index = -1
+	return e
+}
+
+// Set inserts or moves the key's entry to fire at t.
+func (q *fireQueue) Set(key int64, t time.Time) {
+	if e, ok := q.byKey[key]; ok {
+		e.at = t
+		heap.Fix(q, e.index)
+		return
+	}
+	e := &entry{key: key, at: t}
+	heap.Push(q, e)
+	q.byKey[key] = e
+}
+
+// Remove drops the key's entry if present.
+func (q *fireQueue) Remove(key int64) {
+	e, ok := q.byKey[key]
+	if !ok {
+		return
+	}
+	heap.Remove(q, e.index)
+	delete(q.byKey, key)
+}
+
+// Peek returns the earliest entry without removing it.
+func (q *fireQueue) Peek() (*entry, bool) {
+	if len(q.items) == 0 {
+		return nil, false
+	}
+	return q.items[0], true
+}
+
+// PopDue removes and returns the earliest entry if it is due at or before now.
+func (q *fireQueue) PopDue(now time.Time) (*entry, bool) {
+	e, ok := q.Peek()
+	if !ok || e.at.After(now) {
+		return nil, false
+	}
+	heap.Pop(q)
+	delete(q.byKey, e.key)
+	return e, true
+}
+
+// Reset clears the queue.
+func (q *fireQueue) Reset() {
+	q.items = nil
+	q.byKey = make(map[int64]*entry)
+}
@@ -0,0 +1,334 @@
+package scheduler
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ykvlv/notification-bot/internal/domain"
+	"github.com/ykvlv/notification-bot/internal/store"
+)
+
+// memRepo is an in-memory store.Repo with the same compare-and-set semantics
+// as the SQLite one, for the methods the scheduler uses. Other methods come
+// from the nil embedded interface and panic if called.
+type memRepo struct {
+	store.Repo
+
+	clock *FakeClock
+
+	mu          sync.Mutex
+	users       map[int64]domain.User
+	deliveries  []*domain.Delivery // index = ID-1
+	deadLetters []domain.DeadLetter
+}
+
+func newMemRepo(clock *FakeClock) *memRepo {
+	return &memRepo{clock: clock, users: make(map[int64]domain.User)}
+}
+
+// cloneTime copies a nullable time so callers never share pointers with the repo.
+func cloneTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	v := *t
+	return &v
+}
+
+func cloneUser(u domain.User) *domain.User {
+	u.NextFireAt = cloneTime(u.NextFireAt)
+	u.LastSentAt = cloneTime(u.LastSentAt)
+	return &u
+}
+
+func cloneDelivery(d *domain.Delivery) *domain.Delivery {
+	c := *d
+	c.SentAt = cloneTime(d.SentAt)
+	c.NextAttemptAt = cloneTime(d.NextAttemptAt)
+	c.ClaimExpires = cloneTime(d.ClaimExpires)
+	return &c
+}
+
+func (r *memRepo) UpsertUser(_ context.Context, u *domain.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if u.CreatedAt.IsZero() {
+		u.CreatedAt = r.clock.Now().UTC()
+	}
+	r.users[u.ChatID] = *cloneUser(*u)
+	return nil
+}
+
+func (r *memRepo) GetUser(_ context.Context, chatID int64) (*domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[chatID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return cloneUser(u), nil
+}
+
+// afterCursor reports whether (at, id) sorts after c.
+func afterCursor(at time.Time, id int64, c store.Cursor) bool {
+	if c.At.IsZero() {
+		return true
+	}
+	if d := at.Compare(c.At); d != 0 {
+		return d > 0
+	}
+	return id > c.ID
+}
+
+func (r *memRepo) ListDue(_ context.Context, now time.Time, after store.Cursor, limit int) ([]domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var res []domain.User
+	for _, u := range r.users {
+		if u.Enabled && u.NextFireAt != nil && !u.NextFireAt.After(now) &&
+			afterCursor(*u.NextFireAt, u.ChatID, after) {
+			res = append(res, *cloneUser(u))
+		}
+	}
+	slices.SortFunc(res, func(a, b domain.User) int {
+		if c := a.NextFireAt.Compare(*b.NextFireAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ChatID, b.ChatID)
+	})
+	if len(res) > limit {
+		res = res[:limit]
+	}
+	return res, nil
+}
+
+func (r *memRepo) SetSchedule(_ context.Context, chatID int64, next time.Time, last *time.Time) error {
+	return r.updateUser(chatID, func(u *domain.User) {
+		u.NextFireAt = &next
+		u.LastSentAt = cloneTime(last)
+	})
+}
+
+func (r *memRepo) SetEnabled(_ context.Context, chatID int64, enabled bool) error {
+	return r.updateUser(chatID, func(u *domain.User) {
+		u.Enabled = enabled
+		u.DisabledReason = ""
+	})
+}
+
+func (r *memRepo) DisableUser(_ context.Context, chatID int64, reason string) error {
+	return r.updateUser(chatID, func(u *domain.User) {
+		u.Enabled = false
+		u.DisabledReason = reason
+	})
+}
+
+func (r *memRepo) MigrateChat(_ context.Context, oldChatID, newChatID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[oldChatID]
+	delete(r.users, oldChatID)
+	if _, exists := r.users[newChatID]; ok && !exists {
+		u.ChatID = newChatID
+		r.users[newChatID] = u
+	}
+	return nil
+}
+
+func (r *memRepo) updateUser(chatID int64, fn func(u *domain.User)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[chatID]
+	if ok {
+		fn(&u)
+		r.users[chatID] = u
+	}
+	return nil
+}
+
+func (r *memRepo) AddDeadLetter(_ context.Context, d *domain.DeadLetter) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	d.ID = int64(len(r.deadLetters) + 1)
+	r.deadLetters = append(r.deadLetters, *d)
+	return nil
+}
+
+func (r *memRepo) ClaimSlot(_ context.Context, chatID int64, slot, next time.Time, message string) (*domain.Delivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[chatID]
+	if !ok || !u.Enabled || u.NextFireAt == nil || !u.NextFireAt.Equal(slot) {
+		return nil, store.ErrConflict
+	}
+	u.NextFireAt = &next
+	r.users[chatID] = u
+
+	d := &domain.Delivery{
+		ID:            int64(len(r.deliveries) + 1),
+		ChatID:        chatID,
+		Message:       message,
+		ScheduledAt:   slot,
+		Status:        domain.DeliveryPending,
+		NextAttemptAt: &slot,
+		CreatedAt:     r.clock.Now().UTC(),
+	}
+	r.deliveries = append(r.deliveries, d)
+	return cloneDelivery(d), nil
+}
+
+func (r *memRepo) GetDelivery(_ context.Context, id int64) (*domain.Delivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	d, ok := r.delivery(id)
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return cloneDelivery(d), nil
+}
+
+func (r *memRepo) ListPendingDeliveries(_ context.Context, before time.Time, after store.Cursor, limit int) ([]domain.Delivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var res []domain.Delivery
+	for _, d := range r.deliveries {
+		if d.Status == domain.DeliveryPending && d.NextAttemptAt != nil && !d.NextAttemptAt.After(before) &&
+			afterCursor(*d.NextAttemptAt, d.ID, after) {
+			res = append(res, *cloneDelivery(d))
+		}
+	}
+	slices.SortStableFunc(res, func(a, b domain.Delivery) int { return a.NextAttemptAt.Compare(*b.NextAttemptAt) })
+	if len(res) > limit {
+		res = res[:limit]
+	}
+	return res, nil
+}
+
+func (r *memRepo) MarkDeliverySending(_ context.Context, id int64, owner string, leaseUntil time.Time) error {
+	return r.updateDelivery(id, func(d *domain.Delivery) bool {
+		if d.Status != domain.DeliveryPending {
+			return false
+		}
+		d.Status = domain.DeliverySending
+		d.Attempts++
+		d.NextAttemptAt = nil
+		d.ClaimedBy = owner
+		d.ClaimExpires = &leaseUntil
+		return true
+	})
+}
+
+func (r *memRepo) MarkDeliverySent(_ context.Context, id int64, owner string, messageID int, at time.Time) error {
+	var chatID int64
+	err := r.updateDelivery(id, func(d *domain.Delivery) bool {
+		if d.ClaimedBy != owner || (d.Status != domain.DeliverySending && d.Status != domain.DeliveryUnknown) {
+			return false
+		}
+		d.Status = domain.DeliverySent
+		d.SentAt = &at
+		d.MessageID = messageID
+		d.Error = ""
+		d.ClaimedBy, d.ClaimExpires = "", nil
+		chatID = d.ChatID
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	return r.updateUser(chatID, func(u *domain.User) { u.LastSentAt = &at })
+}
+
+func (r *memRepo) MarkDeliveryRetry(_ context.Context, id int64, owner, errText string, at time.Time) error {
+	return r.updateDelivery(id, func(d *domain.Delivery) bool {
+		if d.ClaimedBy != owner || d.Status != domain.DeliverySending {
+			return false
+		}
+		d.Status = domain.DeliveryPending
+		d.Error = errText
+		d.NextAttemptAt = &at
+		d.ClaimedBy, d.ClaimExpires = "", nil
+		return true
+	})
+}
+
+func (r *memRepo) FinishDelivery(_ context.Context, id int64, owner, status, errText string) error {
+	return r.updateDelivery(id, func(d *domain.Delivery) bool {
+		if d.ClaimedBy != owner || (d.Status != domain.DeliveryPending && d.Status != domain.DeliverySending) {
+			return false
+		}
+		d.Status = status
+		d.Error = errText
+		d.NextAttemptAt = nil
+		d.ClaimedBy, d.ClaimExpires = "", nil
+		return true
+	})
+}
+
+func (r *memRepo) RetargetDelivery(_ context.Context, id int64, owner string, newChatID int64, at time.Time) error {
+	return r.updateDelivery(id, func(d *domain.Delivery) bool {
+		if d.ClaimedBy != owner || d.Status != domain.DeliverySending {
+			return false
+		}
+		d.ChatID = newChatID
+		d.Status = domain.DeliveryPending
+		d.NextAttemptAt = &at
+		d.ClaimedBy, d.ClaimExpires = "", nil
+		return true
+	})
+}
+
+func (r *memRepo) RecoverDeliveries(_ context.Context, owner string, now time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var n int64
+	for _, d := range r.deliveries {
+		if d.Status != domain.DeliverySending {
+			continue
+		}
+		if (owner != "" && d.ClaimedBy == owner) || d.ClaimExpires == nil || !d.ClaimExpires.After(now) {
+			d.Status = domain.DeliveryUnknown
+			d.Error = "interrupted while sending"
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *memRepo) Close() error { return nil }
+
+func (r *memRepo) delivery(id int64) (*domain.Delivery, bool) {
+	if id < 1 || id > int64(len(r.deliveries)) {
+		return nil, false
+	}
+	return r.deliveries[id-1], true
+}
+
+// updateDelivery applies fn under the lock; fn returning false means the
+// compare-and-set precondition failed.
+func (r *memRepo) updateDelivery(id int64, fn func(d *domain.Delivery) bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	d, ok := r.delivery(id)
+	if !ok {
+		return sql.ErrNoRows
+	}
+	if !fn(d) {
+		return store.ErrConflict
+	}
+	return nil
+}
+
+// allDeliveries returns a snapshot of every delivery in ID order.
+func (r *memRepo) allDeliveries() []domain.Delivery {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	res := make([]domain.Delivery, 0, len(r.deliveries))
+	for _, d := range r.deliveries {
+		res = append(res, *cloneDelivery(d))
+	}
+	return res
+}
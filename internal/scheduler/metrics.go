@@ -0,0 +1,105 @@
+package scheduler
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// lagBuckets are the upper bounds (seconds) of the delivery lag histogram.
+var lagBuckets = []float64{0.5, 1, 2, 5, 10, 30, 60, 120, 300, 600, 1800}
+
+// Metrics counts scheduler activity. It is safe for concurrent use and is
+// exposed in the Prometheus text format by WritePrometheus.
+type Metrics struct {
+	sent            atomic.Int64
+	retried         atomic.Int64
+	failed          atomic.Int64
+	budgetExhausted atomic.Int64
+	queued          atomic.Int64
+
+	mu        sync.Mutex
+	lagCounts []int64 // per bucket, plus +Inf last
+	lagSum    float64
+	lagCount  int64
+	lagMax    float64 // since start
+}
+
+func newMetrics() *Metrics {
+	return &Metrics{lagCounts: make([]int64, len(lagBuckets)+1)}
+}
+
+// observeLag records the delay between a delivery's slot and its send.
+func (m *Metrics) observeLag(d time.Duration) {
+	sec := max(d.Seconds(), 0)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	i := 0
+	for i < len(lagBuckets) && sec > lagBuckets[i] {
+		i++
+	}
+	m.lagCounts[i]++
+	m.lagSum += sec
+	m.lagCount++
+	m.lagMax = max(m.lagMax, sec)
+}
+
+// LagMax returns the largest delivery lag observed so far.
+func (m *Metrics) LagMax() time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return time.Duration(m.lagMax * float64(time.Second))
+}
+
+// WritePrometheus writes all metrics in the Prometheus text exposition format.
+func (m *Metrics) WritePrometheus(w io.Writer) error {
+	m.mu.Lock()
+	counts := append([]int64(nil), m.lagCounts...)
+	sum, count, maxLag := m.lagSum, m.lagCount, m.lagMax
+	m.mu.Unlock()
+
+	pw := &promWriter{w: w}
+	pw.printf("# HELP notification_bot_deliveries_total Send attempts by outcome.\n")
+	pw.printf("# TYPE notification_bot_deliveries_total counter\n")
+	pw.printf("notification_bot_deliveries_total{outcome=\"sent\"} %d\n", m.sent.Load())
+	pw.printf("notification_bot_deliveries_total{outcome=\"retried\"} %d\n", m.retried.Load())
+	pw.printf("notification_bot_deliveries_total{outcome=\"failed\"} %d\n", m.failed.Load())
+
+	pw.printf("# HELP notification_bot_delivery_lag_seconds Delay between next_fire_at and the actual send.\n")
+	pw.printf("# TYPE notification_bot_delivery_lag_seconds histogram\n")
+	var cum int64
+	for i, le := range lagBuckets {
+		cum += counts[i]
+		pw.printf("notification_bot_delivery_lag_seconds_bucket{le=\"%g\"} %d\n", le, cum)
+	}
+	pw.printf("notification_bot_delivery_lag_seconds_bucket{le=\"+Inf\"} %d\n", count)
+	pw.printf("notification_bot_delivery_lag_seconds_sum %g\n", sum)
+	pw.printf("notification_bot_delivery_lag_seconds_count %d\n", count)
+
+	pw.printf("# HELP notification_bot_delivery_lag_max_seconds Largest delivery lag since start.\n")
+	pw.printf("# TYPE notification_bot_delivery_lag_max_seconds gauge\n")
+	pw.printf("notification_bot_delivery_lag_max_seconds %g\n", maxLag)
+
+	pw.printf("# HELP notification_bot_scheduler_queued Slots and retries loaded in memory.\n")
+	pw.printf("# TYPE notification_bot_scheduler_queued gauge\n")
+	pw.printf("notification_bot_scheduler_queued %d\n", m.queued.Load())
+
+	pw.printf("# HELP notification_bot_scheduler_budget_exhausted_total Passes that stopped early to yield.\n")
+	pw.printf("# TYPE notification_bot_scheduler_budget_exhausted_total counter\n")
+	pw.printf("notification_bot_scheduler_budget_exhausted_total %d\n", m.budgetExhausted.Load())
+	return pw.err
+}
+
+// promWriter keeps the first write error so callers can check it once.
+type promWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (p *promWriter) printf(format string, args ...any) {
+	if p.err == nil {
+		_, p.err = fmt.Fprintf(p.w, format, args...)
+	}
+}
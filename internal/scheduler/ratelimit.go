@@ -0,0 +1,67 @@
+package scheduler
+
+import (
+	"sync"
+	"time"
+)
+
+// tokenBucket is a simple thread-safe token bucket limiter.
+// Tokens refill continuously at rate per second up to burst.
+type tokenBucket struct {
+	clock  Clock
+	mu     sync.Mutex
+	rate   float64 // tokens per second
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(clock Clock, ratePerSec float64, burst int) *tokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{
+		clock:  clock,
+		rate:   ratePerSec,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   clock.Now(),
+	}
+}
+
+// reserve takes one token and returns how long the caller must wait before
+// using it. The token is consumed even if the wait is non-zero, so that
+// concurrent callers queue up behind each other instead of stampeding.
+// Without a rate limit only a pause makes callers wait.
+func (b *tokenBucket) reserve() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.clock.Now()
+	if b.rate <= 0 {
+		return b.last.Sub(now)
+	}
+	// During a pause b.last is in the future and nothing refills until then.
+	if now.After(b.last) {
+		b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+		b.last = now
+	}
+
+	b.tokens--
+	wait := b.last.Sub(now)
+	if b.tokens < 0 {
+		wait += time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+	return wait
+}
+
+// PauseUntil blocks all callers until t, e.g. after a 429 with retry_after.
+// The bucket starts empty when the pause ends.
+func (b *tokenBucket) PauseUntil(t time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if t.After(b.last) {
+		b.tokens = min(b.tokens, 0)
+		b.last = t
+	}
+}
@@ -0,0 +1,33 @@
+package scheduler
+
+import (
+	"time"
+)
+
+// RetryPolicy controls how failed sends are retried before being moved to
+// the dead-letter table. Retry state lives on the delivery row (attempts,
+// next_attempt_at), so it survives restarts.
+type RetryPolicy struct {
+	MaxAttempts int           // total attempts per delivery, including the first
+	BaseDelay   time.Duration // delay before the second attempt
+	MaxDelay    time.Duration // cap for exponential growth
+}
+
+// DefaultRetryPolicy returns a policy of 5 attempts spread over ~8 minutes.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 5,
+		BaseDelay:   30 * time.Second,
+		MaxDelay:    30 * time.Minute,
+	}
+}
+
+// Backoff returns the delay after the given number of failed attempts
+// (1-based): BaseDelay, 2*BaseDelay, 4*BaseDelay, ... capped at MaxDelay.
+func (p RetryPolicy) Backoff(attempts int) time.Duration {
+	d := p.BaseDelay
+	for i := 1; i < attempts && d < p.MaxDelay; i++ {
+		d *= 2
+	}
+	return min(d, p.MaxDelay)
+}
@@ -2,6 +2,11 @@ package scheduler
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"go.uber.org/zap"
@@ -10,66 +15,636 @@ import (
 	"github.com/ykvlv/notification-bot/internal/store"
 )
 
-// Sender is a minimal interface the scheduler needs to send a text message.
-// telegram.Router will implement this (method: SendMessage).
+// Reminder is one reminder inside an outgoing message.
+type Reminder struct {
+	DeliveryID int64  // outbox row, e.g. for acknowledgement buttons
+	Text       string // reminder text
+}
+
+// Sender is a minimal interface the scheduler needs to deliver reminders.
+// telegram.Router implements it. SendReminders sends one message carrying
+// one reminder, or several bundled as a digest, and returns its message ID.
 type Sender interface {
-	SendMessage(chatID int64, text string) error
+	SendReminders(chatID int64, items []Reminder) (int, error)
+}
+
+// TargetResolver is implemented by senders that deliver some chats'
+// reminders to another Telegram chat, such as a broadcast channel.
+// TargetChat returns the chat a chat's reminders go to, or chatID itself.
+type TargetResolver interface {
+	TargetChat(chatID int64) int64
+}
+
+const (
+	// reconcileEvery is how often the in-memory queues are rebuilt from the DB.
+	// This is a safety net: normal changes arrive through Notify.
+	reconcileEvery = 5 * time.Minute
+	// horizon is how far ahead fire times are loaded into memory. It must exceed
+	// reconcileEvery so that nothing falls between two reconciliations.
+	horizon = reconcileEvery + time.Minute
+	// pageSize is how many rows one keyset page loads during reconciliation.
+	pageSize = 1000
+	// defaultTickBudget bounds how long one pass over due entries may run
+	// before the loop yields to pending change notifications.
+	defaultTickBudget = time.Second
+	// notifyBuffer is the capacity of the change notification channel.
+	notifyBuffer = 256
+	// defaultLeaseTTL is how long a delivery stays leased to the instance
+	// sending it. It must comfortably exceed one send, including 429 pauses.
+	defaultLeaseTTL = 5 * time.Minute
+)
+
+// change is a notification for the Run loop: either a chat whose schedule
+// may have changed, or a delivery that became pending again.
+type change struct {
+	chatID     int64
+	deliveryID int64
+	all        bool // reload everything, as after an overrun
 }
 
-// Scheduler periodically polls the DB and dispatches due notifications.
+// Scheduler keeps upcoming fire times in min-heaps and sleeps on a single
+// timer until the earliest one is due. Settings changes are pushed in through
+// Notify; the DB is re-read periodically to catch anything missed.
+//
+// Dispatch goes through an outbox in the deliveries table:
+//  1. claim: atomically advance next_fire_at and insert a pending delivery;
+//  2. mark the delivery sending, then send;
+//  3. mark it sent with the Telegram message_id (or pending again for a retry).
+//
+// A crash after step 1 leaves a pending row that is sent after restart; a crash
+// during step 2 leaves a sending row that RecoverDeliveries marks unknown
+// instead of re-sending, so a confirmed or possibly-sent reminder is never
+// delivered twice.
+//
+// Several instances may share one database. Steps 1 and 2 are compare-and-set,
+// so exactly one instance wins each slot and each attempt; step 2 also leases
+// the delivery to the winner for leaseTTL. A lease that expires without step 3
+// means its instance died, and any instance settles the row as unknown.
 type Scheduler struct {
 	repo     store.Repo
 	log      *zap.Logger
-	sender   Sender
-	interval time.Duration
+	dispatch *Dispatcher
+	retry    RetryPolicy
+	instance string        // this instance's ID, recorded as the lease owner
+	leaseTTL time.Duration // how long a delivery is leased while sending
+	budget   time.Duration // time budget of one fireDue pass, on clock
+	clock    Clock
+	metrics  *Metrics
+
+	slots   *fireQueue  // chat ID → next_fire_at; owned by the Run goroutine
+	pending *fireQueue  // delivery ID → next_attempt_at; owned by the Run goroutine
+	changes chan change // schedule changes and deliveries to (re)queue
+	overrun atomic.Bool // set when changes overflowed; forces a reconcile
+	timer   Timer       // fires when the earliest queue head is due
+	wakeAt  time.Time   // when timer is currently set to fire (zero if stopped)
+
+	mu       sync.Mutex
+	inflight map[int64]struct{} // delivery IDs submitted to the dispatcher and not yet done
 }
 
-// New creates a new Scheduler. Poll interval is fixed for MVP (30s).
-func New(repo store.Repo, log *zap.Logger, sender Sender) *Scheduler {
+// Config groups the scheduler's delivery settings.
+type Config struct {
+	Dispatch   DispatchConfig
+	Retry      RetryPolicy
+	InstanceID string        // unique per running process; see DefaultInstanceID
+	LeaseTTL   time.Duration // 0 = defaultLeaseTTL
+	TickBudget time.Duration // 0 = defaultTickBudget
+	Clock      Clock         // nil = RealClock; also the default Dispatch.Clock
+}
+
+// DefaultInstanceID returns an ID unique to this process: host name and PID.
+func DefaultInstanceID() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "localhost"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
+// New creates a new Scheduler that delivers through sender.
+func New(repo store.Repo, log *zap.Logger, sender Sender, cfg Config) *Scheduler {
+	if cfg.Retry.MaxAttempts <= 0 {
+		cfg.Retry = DefaultRetryPolicy()
+	}
+	if cfg.InstanceID == "" {
+		cfg.InstanceID = DefaultInstanceID()
+	}
+	if cfg.LeaseTTL <= 0 {
+		cfg.LeaseTTL = defaultLeaseTTL
+	}
+	if cfg.TickBudget <= 0 {
+		cfg.TickBudget = defaultTickBudget
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = RealClock()
+	}
+	if cfg.Dispatch.Clock == nil {
+		cfg.Dispatch.Clock = cfg.Clock
+	}
 	return &Scheduler{
 		repo:     repo,
 		log:      log,
-		sender:   sender,
-		interval: 30 * time.Second,
+		dispatch: NewDispatcher(sender, cfg.Dispatch),
+		retry:    cfg.Retry,
+		instance: cfg.InstanceID,
+		leaseTTL: cfg.LeaseTTL,
+		budget:   cfg.TickBudget,
+		clock:    cfg.Clock,
+		metrics:  newMetrics(),
+		slots:    newFireQueue(),
+		pending:  newFireQueue(),
+		changes:  make(chan change, notifyBuffer),
+		inflight: make(map[int64]struct{}),
+	}
+}
+
+// Metrics returns the scheduler's delivery metrics.
+func (s *Scheduler) Metrics() *Metrics { return s.metrics }
+
+// Notify tells the scheduler that a chat's schedule (interval, hours, TZ,
+// pause state) may have changed. It never blocks: if the channel is full,
+// the next loop iteration falls back to a full reconciliation.
+func (s *Scheduler) Notify(chatID int64) {
+	s.push(change{chatID: chatID})
+}
+
+// NotifyAll tells the scheduler that any chat's schedule may have changed,
+// e.g. after an admin changed the jitter; it reloads its queues.
+func (s *Scheduler) NotifyAll() {
+	s.push(change{all: true})
+}
+
+// NotifyDelivery asks the Run loop to re-read a delivery (e.g. after a retry
+// was scheduled from a worker goroutine, or a dead letter was replayed).
+func (s *Scheduler) NotifyDelivery(id int64) {
+	s.push(change{deliveryID: id})
+}
+
+func (s *Scheduler) push(c change) {
+	select {
+	case s.changes <- c:
+	default:
+		s.overrun.Store(true)
 	}
 }
 
 // Run starts the loop until ctx is canceled.
 func (s *Scheduler) Run(ctx context.Context) {
-	ticker := time.NewTicker(s.interval)
-	defer ticker.Stop()
+	s.timer = s.clock.NewTimer(time.Hour)
+	s.timer.Stop()
+	defer s.timer.Stop()
+
+	reconcile := s.clock.NewTicker(reconcileEvery)
+	defer reconcile.Stop()
+
+	s.dispatch.Start(ctx)
+	defer s.dispatch.Wait()
+
+	s.log.Info("scheduler starting", zap.String("instance", s.instance))
+	s.recover(ctx, s.instance)
+	s.reconcile(ctx)
+	s.fireDue(ctx)
+	s.rearm()
 
 	for {
 		select {
 		case <-ctx.Done():
 			s.log.Info("scheduler stopping")
 			return
-		case <-ticker.C:
-			s.tick(ctx)
+		case <-s.timer.C():
+			s.wakeAt = time.Time{}
+			s.fireDue(ctx)
+		case c := <-s.changes:
+			s.refresh(ctx, c)
+		case <-reconcile.C():
+			s.recover(ctx, "") // expired leases of instances that died
+			s.reconcile(ctx)
+			s.fireDue(ctx)
+		}
+		if s.overrun.Swap(false) {
+			s.reconcile(ctx)
+			s.fireDue(ctx)
+		}
+		s.rearm()
+	}
+}
+
+// now returns the scheduler clock's current time in UTC.
+func (s *Scheduler) now() time.Time { return s.clock.Now().UTC() }
+
+// nextWake returns the earliest head of both queues.
+func (s *Scheduler) nextWake() (time.Time, bool) {
+	var next time.Time
+	for _, q := range []*fireQueue{s.slots, s.pending} {
+		if head, ok := q.Peek(); ok && (next.IsZero() || head.at.Before(next)) {
+			next = head.at
+		}
+	}
+	return next, !next.IsZero()
+}
+
+// rearm points the timer at the earliest queue head, or stops it if both
+// queues are empty.
+func (s *Scheduler) rearm() {
+	s.metrics.queued.Store(int64(s.slots.Len() + s.pending.Len()))
+	next, ok := s.nextWake()
+	if !ok {
+		s.timer.Stop()
+		s.wakeAt = time.Time{}
+		return
+	}
+	if next.Equal(s.wakeAt) {
+		return
+	}
+	s.wakeAt = next
+	s.timer.Reset(max(next.Sub(s.now()), 0))
+}
+
+// recover finalizes deliveries interrupted mid-send: those of any instance
+// whose lease has expired and, if owner is set, owner's own. Run passes its
+// instance only at startup, for leases left over from a previous run; later
+// its own sending deliveries are live.
+func (s *Scheduler) recover(ctx context.Context, owner string) {
+	n, err := s.repo.RecoverDeliveries(ctx, owner, s.now())
+	if err != nil {
+		s.log.Error("RecoverDeliveries failed", zap.Error(err))
+		return
+	}
+	if n > 0 {
+		s.log.Warn("deliveries interrupted mid-send marked unknown", zap.Int64("count", n))
+	}
+}
+
+// reconcile rebuilds both queues from the DB with everything due within
+// horizon, paging through all rows with a keyset cursor.
+func (s *Scheduler) reconcile(ctx context.Context) {
+	until := s.now().Add(horizon)
+
+	s.slots.Reset()
+	for after := (store.Cursor{}); ; {
+		users, err := s.repo.ListDue(ctx, until, after, pageSize)
+		if err != nil {
+			s.log.Error("ListDue failed", zap.Error(err))
+			return
+		}
+		for _, u := range users {
+			s.slots.Set(u.ChatID, *u.NextFireAt)
+		}
+		if len(users) < pageSize {
+			break
+		}
+		last := users[len(users)-1]
+		after = store.Cursor{At: *last.NextFireAt, ID: last.ChatID}
+	}
+
+	s.pending.Reset()
+	for after := (store.Cursor{}); ; {
+		deliveries, err := s.repo.ListPendingDeliveries(ctx, until, after, pageSize)
+		if err != nil {
+			s.log.Error("ListPendingDeliveries failed", zap.Error(err))
+			return
+		}
+		for _, d := range deliveries {
+			if !s.isInflight(d.ID) {
+				s.pending.Set(d.ID, *d.NextAttemptAt)
+			}
+		}
+		if len(deliveries) < pageSize {
+			break
+		}
+		last := deliveries[len(deliveries)-1]
+		after = store.Cursor{At: *last.NextAttemptAt, ID: last.ID}
+	}
+
+	s.log.Debug("scheduler reconciled",
+		zap.Int("slots", s.slots.Len()), zap.Int("pending", s.pending.Len()))
+}
+
+// refresh re-reads a single chat or delivery and moves, adds or drops its entry.
+func (s *Scheduler) refresh(ctx context.Context, c change) {
+	if c.all {
+		s.reconcile(ctx)
+		return
+	}
+	if c.deliveryID != 0 {
+		d, err := s.repo.GetDelivery(ctx, c.deliveryID)
+		if err != nil {
+			s.log.Warn("refresh: GetDelivery failed", zap.Error(err), zap.Int64("deliveryID", c.deliveryID))
+			s.pending.Remove(c.deliveryID)
+			return
+		}
+		s.enqueueDelivery(d)
+		return
+	}
+
+	u, err := s.repo.GetUser(ctx, c.chatID)
+	if err != nil {
+		s.log.Warn("refresh: GetUser failed", zap.Error(err), zap.Int64("chatID", c.chatID))
+		s.slots.Remove(c.chatID)
+		return
+	}
+	s.enqueueUser(u)
+}
+
+// enqueueUser places the user in the slot queue if it is enabled and due
+// within horizon.
+func (s *Scheduler) enqueueUser(u *domain.User) {
+	if !u.Enabled || u.NextFireAt == nil || u.NextFireAt.After(s.now().Add(horizon)) {
+		s.slots.Remove(u.ChatID)
+		return
+	}
+	s.slots.Set(u.ChatID, *u.NextFireAt)
+}
+
+// enqueueDelivery places a pending delivery in the retry queue if it is due
+// within horizon and not already being sent.
+func (s *Scheduler) enqueueDelivery(d *domain.Delivery) {
+	if d.Status != domain.DeliveryPending || d.NextAttemptAt == nil ||
+		d.NextAttemptAt.After(s.now().Add(horizon)) || s.isInflight(d.ID) {
+		s.pending.Remove(d.ID)
+		return
+	}
+	s.pending.Set(d.ID, *d.NextAttemptAt)
+}
+
+// fireDue claims due slots and submits due deliveries until both queues
+// have nothing due or the tick budget runs out. Entries left over stay at the
+// queue heads, so rearm fires the timer again right away after the loop has
+// handled pending notifications.
+func (s *Scheduler) fireDue(ctx context.Context) {
+	now := s.now()
+	deadline := s.clock.Now().Add(s.budget)
+	for {
+		e, ok := s.slots.PopDue(now)
+		if !ok {
+			break
+		}
+		s.claim(ctx, now, e.key)
+		if !s.clock.Now().Before(deadline) {
+			s.yield()
+			return
+		}
+	}
+	for {
+		e, ok := s.pending.PopDue(now)
+		if !ok {
+			break
+		}
+		s.retryDue(ctx, now, e.key)
+		if !s.clock.Now().Before(deadline) {
+			s.yield()
+			return
 		}
 	}
 }
 
-// tick performs one scheduling cycle: find due users, send, reschedule.
-func (s *Scheduler) tick(ctx context.Context) {
-	now := time.Now().UTC()
+// retryDue re-reads a due delivery and submits it if it is still pending.
+func (s *Scheduler) retryDue(ctx context.Context, now time.Time, deliveryID int64) {
+	d, err := s.repo.GetDelivery(ctx, deliveryID)
+	if err != nil {
+		s.log.Error("GetDelivery failed", zap.Error(err), zap.Int64("deliveryID", deliveryID))
+		return
+	}
+	if d.Status != domain.DeliveryPending || d.NextAttemptAt == nil {
+		return
+	}
+	if d.NextAttemptAt.After(now) {
+		s.enqueueDelivery(d)
+		return
+	}
+	var digest time.Duration
+	if u, err := s.repo.GetUser(ctx, d.ChatID); err == nil {
+		digest = u.DigestWindow()
+	}
+	s.submit(ctx, d, digest)
+}
+
+// yield records that fireDue stopped with due entries left over.
+func (s *Scheduler) yield() {
+	s.metrics.budgetExhausted.Add(1)
+	s.log.Debug("tick budget exhausted, yielding",
+		zap.Int("slots", s.slots.Len()), zap.Int("pending", s.pending.Len()))
+}
+
+// claim turns a due slot into a pending delivery and advances the schedule,
+// then submits the delivery.
+func (s *Scheduler) claim(ctx context.Context, now time.Time, chatID int64) {
+	// Re-read the row: the queue may be stale if a change notification
+	// is still in flight.
+	u, err := s.repo.GetUser(ctx, chatID)
+	if err != nil {
+		s.log.Error("GetUser failed", zap.Error(err), zap.Int64("chatID", chatID))
+		return
+	}
+	if !u.Enabled || u.NextFireAt == nil {
+		return
+	}
+	if u.NextFireAt.After(now) {
+		s.enqueueUser(u)
+		return
+	}
 
-	users, err := s.repo.ListDue(ctx, now, 100)
+	next := domain.NextFire(now, u)
+	d, err := s.repo.ClaimSlot(ctx, u.ChatID, *u.NextFireAt, next, u.Message)
+	if errors.Is(err, store.ErrConflict) {
+		s.Notify(chatID) // changed under us; re-read
+		return
+	}
 	if err != nil {
-		s.log.Error("ListDue failed", zap.Error(err))
+		s.log.Error("ClaimSlot failed", zap.Error(err), zap.Int64("chatID", chatID))
 		return
 	}
-	for _, u := range users {
-		// Send user's message
-		if err := s.sender.SendMessage(u.ChatID, u.Message); err != nil {
-			s.log.Error("send failed", zap.Error(err), zap.Int64("chatID", u.ChatID))
-			continue
+	u.NextFireAt = &next
+	s.enqueueUser(u)
+	s.submit(ctx, d, u.DigestWindow())
+}
+
+// submit marks the delivery in flight and queues it for the dispatcher,
+// to be held for the user's digest window if one is set.
+func (s *Scheduler) submit(ctx context.Context, d *domain.Delivery, digest time.Duration) {
+	if !s.setInflight(d.ID, true) {
+		return // already queued
+	}
+	err := s.dispatch.Submit(ctx, job{
+		chatID: d.ChatID,
+		item:   Reminder{DeliveryID: d.ID, Text: d.Message},
+		digest: digest,
+		start:  func() bool { return s.begin(ctx, d) },
+		done: func(messageID int, err error) {
+			// Settle the attempt even if Run is stopping meanwhile.
+			s.complete(context.WithoutCancel(ctx), d, messageID, err)
+		},
+	})
+	if err != nil {
+		s.setInflight(d.ID, false)
+	}
+}
+
+// begin runs on a dispatcher worker right before the send: it cancels the
+// delivery if the user paused meanwhile and otherwise marks it sending.
+func (s *Scheduler) begin(ctx context.Context, d *domain.Delivery) bool {
+	if u, err := s.repo.GetUser(ctx, d.ChatID); err == nil && !u.Enabled {
+		// Still pending, so nobody holds a lease on it.
+		err := s.repo.FinishDelivery(ctx, d.ID, "", domain.DeliveryCanceled, "paused")
+		if err != nil && !errors.Is(err, store.ErrConflict) {
+			s.log.Error("FinishDelivery failed", zap.Error(err), zap.Int64("deliveryID", d.ID))
+		}
+		s.setInflight(d.ID, false)
+		return false
+	}
+	if err := s.repo.MarkDeliverySending(ctx, d.ID, s.instance, s.now().Add(s.leaseTTL)); err != nil {
+		if !errors.Is(err, store.ErrConflict) {
+			s.log.Error("MarkDeliverySending failed", zap.Error(err), zap.Int64("deliveryID", d.ID))
+		}
+		s.setInflight(d.ID, false)
+		return false
+	}
+	d.Attempts++
+	return true
+}
+
+// complete runs on a dispatcher worker after a send attempt: it settles the
+// delivery and takes it out of flight. Only then is the Run loop told about a
+// delivery that is pending again, since enqueueDelivery skips in-flight ones.
+func (s *Scheduler) complete(ctx context.Context, d *domain.Delivery, messageID int, sendErr error) {
+	requeue := s.settleAttempt(ctx, d, messageID, sendErr)
+	s.setInflight(d.ID, false)
+	if requeue {
+		s.NotifyDelivery(d.ID)
+	}
+}
+
+// settleAttempt confirms the delivery, schedules a retry, or gives up. It
+// reports whether the delivery is pending again.
+func (s *Scheduler) settleAttempt(ctx context.Context, d *domain.Delivery, messageID int, sendErr error) bool {
+	if sendErr == nil {
+		s.metrics.sent.Add(1)
+		s.metrics.observeLag(s.now().Sub(d.ScheduledAt))
+		if err := s.repo.MarkDeliverySent(ctx, d.ID, s.instance, messageID, s.now()); err != nil {
+			// The message is out; the row stays "sending" and becomes "unknown"
+			// once the lease expires rather than being sent again.
+			s.log.Error("MarkDeliverySent failed", zap.Error(err), zap.Int64("deliveryID", d.ID))
+		}
+		return false
+	}
+
+	if handled, requeue := s.handlePermanent(ctx, d, sendErr); handled {
+		return requeue
+	}
+	if d.Attempts < s.retry.MaxAttempts {
+		return s.scheduleRetry(ctx, d, sendErr)
+	}
+	s.deadLetter(ctx, d, sendErr)
+	return false
+}
+
+// handlePermanent reacts to errors that retrying cannot fix. It reports
+// whether err was such an error, and whether the delivery is pending again.
+func (s *Scheduler) handlePermanent(ctx context.Context, d *domain.Delivery, sendErr error) (handled, requeue bool) {
+	var (
+		blocked  *BlockedError
+		rejected *RejectedError
+		migrated *ChatMigratedError
+	)
+	switch {
+	case errors.As(sendErr, &blocked):
+		s.metrics.failed.Add(1)
+		s.log.Info("recipient unreachable, disabling user",
+			zap.Int64("chatID", d.ChatID), zap.String("reason", blocked.Reason))
+		if err := s.repo.DisableUser(ctx, d.ChatID, blocked.Reason); err != nil {
+			s.log.Error("DisableUser failed", zap.Error(err), zap.Int64("chatID", d.ChatID))
 		}
+		if err := s.repo.FinishDelivery(ctx, d.ID, s.instance, domain.DeliveryFailed, sendErr.Error()); err != nil {
+			s.log.Error("FinishDelivery failed", zap.Error(err), zap.Int64("deliveryID", d.ID))
+		}
+		s.Notify(d.ChatID)
+		return true, false
+
+	case errors.As(sendErr, &rejected):
+		s.deadLetter(ctx, d, sendErr)
+		return true, false
 
-		// Compute next fire time and persist
-		next := domain.NextFire(now, &u)
-		if err := s.repo.SetSchedule(ctx, u.ChatID, next, &now); err != nil {
-			s.log.Error("SetSchedule failed", zap.Error(err), zap.Int64("chatID", u.ChatID))
+	case errors.As(sendErr, &migrated):
+		s.log.Info("chat migrated",
+			zap.Int64("chatID", d.ChatID), zap.Int64("newChatID", migrated.NewChatID))
+		if err := s.repo.MigrateChat(ctx, d.ChatID, migrated.NewChatID); err != nil {
+			s.log.Error("MigrateChat failed", zap.Error(err), zap.Int64("chatID", d.ChatID))
 		}
+		// Send the same delivery again to the new chat right away.
+		err := s.repo.RetargetDelivery(ctx, d.ID, s.instance, migrated.NewChatID, s.now())
+		if err != nil {
+			s.log.Error("RetargetDelivery failed", zap.Error(err), zap.Int64("deliveryID", d.ID))
+		}
+		s.Notify(d.ChatID)
+		s.Notify(migrated.NewChatID)
+		return true, err == nil
+	}
+	return false, false
+}
+
+// scheduleRetry returns the delivery to pending after a backoff delay and
+// reports whether it did.
+func (s *Scheduler) scheduleRetry(ctx context.Context, d *domain.Delivery, sendErr error) bool {
+	s.metrics.retried.Add(1)
+	delay := s.retry.Backoff(d.Attempts)
+	s.log.Warn("send failed, will retry",
+		zap.Error(sendErr),
+		zap.Int64("chatID", d.ChatID),
+		zap.Int64("deliveryID", d.ID),
+		zap.Int("attempt", d.Attempts),
+		zap.Duration("retry_in", delay),
+	)
+	if err := s.repo.MarkDeliveryRetry(ctx, d.ID, s.instance, sendErr.Error(), s.now().Add(delay)); err != nil {
+		s.log.Error("MarkDeliveryRetry failed", zap.Error(err), zap.Int64("deliveryID", d.ID))
+		return false
+	}
+	return true
+}
+
+// deadLetter gives up on the delivery and stores it for inspection and replay.
+// The user's schedule was already advanced at claim time.
+func (s *Scheduler) deadLetter(ctx context.Context, d *domain.Delivery, sendErr error) {
+	s.metrics.failed.Add(1)
+	s.log.Error("send failed, giving up",
+		zap.Error(sendErr),
+		zap.Int64("chatID", d.ChatID),
+		zap.Int64("deliveryID", d.ID),
+		zap.Int("attempts", d.Attempts),
+	)
+	if err := s.repo.FinishDelivery(ctx, d.ID, s.instance, domain.DeliveryFailed, sendErr.Error()); err != nil {
+		s.log.Error("FinishDelivery failed", zap.Error(err), zap.Int64("deliveryID", d.ID))
+	}
+	dl := &domain.DeadLetter{
+		ChatID:      d.ChatID,
+		Message:     d.Message,
+		ScheduledAt: d.ScheduledAt,
+		Attempts:    d.Attempts,
+		LastError:   sendErr.Error(),
+	}
+	if err := s.repo.AddDeadLetter(ctx, dl); err != nil {
+		s.log.Error("AddDeadLetter failed", zap.Error(err), zap.Int64("chatID", d.ChatID))
+	}
+}
+
+func (s *Scheduler) isInflight(deliveryID int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.inflight[deliveryID]
+	return ok
+}
+
+// setInflight adds or removes a delivery from the in-flight set. When adding,
+// it reports false if the delivery was already in flight.
+func (s *Scheduler) setInflight(deliveryID int64, on bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !on {
+		delete(s.inflight, deliveryID)
+		return true
+	}
+	if _, ok := s.inflight[deliveryID]; ok {
+		return false
 	}
+	s.inflight[deliveryID] = struct{}{}
+	return true
 }
@@ -0,0 +1,378 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/ykvlv/notification-bot/internal/domain"
+)
+
+// monday is 2025-03-03 00:00 UTC.
+var monday = time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)
+
+func hourly(chatID int64, tz string, fromM, toM int) domain.User {
+	return domain.User{
+		ChatID:      chatID,
+		Enabled:     true,
+		TZ:          tz,
+		IntervalSec: 3600,
+		ActiveFromM: fromM,
+		ActiveToM:   toM,
+		Message:     "drink",
+	}
+}
+
+func TestScheduler_IntervalWithinWindow(t *testing.T) {
+	h := newHarness(t, monday)
+	h.addUser(hourly(1, "UTC", 9*60, 11*60+30))
+	h.runFor(48 * time.Hour)
+
+	h.expectSent(
+		"2025-03-03 09:00:00 #1 drink",
+		"2025-03-03 10:00:00 #1 drink",
+		"2025-03-03 11:00:00 #1 drink",
+		"2025-03-04 09:00:00 #1 drink",
+		"2025-03-04 10:00:00 #1 drink",
+		"2025-03-04 11:00:00 #1 drink",
+	)
+}
+
+func TestScheduler_WindowWrapsMidnight(t *testing.T) {
+	h := newHarness(t, monday.Add(12*time.Hour))
+	h.addUser(hourly(1, "UTC", 22*60, 60+30))
+	h.runFor(48 * time.Hour)
+
+	h.expectSent(
+		"2025-03-03 22:00:00 #1 drink",
+		"2025-03-03 23:00:00 #1 drink",
+		"2025-03-04 00:00:00 #1 drink",
+		"2025-03-04 01:00:00 #1 drink",
+		"2025-03-04 22:00:00 #1 drink",
+		"2025-03-04 23:00:00 #1 drink",
+		"2025-03-05 00:00:00 #1 drink",
+		"2025-03-05 01:00:00 #1 drink",
+	)
+}
+
+func TestScheduler_PauseAndResume(t *testing.T) {
+	h := newHarness(t, monday)
+	h.addUser(hourly(1, "UTC", 9*60, 11*60+30))
+
+	h.runUntil(monday.Add(9*time.Hour + 30*time.Minute))
+	h.pause(1)
+	h.runUntil(monday.Add(24*time.Hour + 10*time.Hour + 30*time.Minute))
+	h.resume(1)
+	h.runUntil(monday.Add(48 * time.Hour))
+
+	h.expectSent(
+		"2025-03-03 09:00:00 #1 drink",
+		"2025-03-04 11:00:00 #1 drink",
+	)
+}
+
+func TestScheduler_TimezoneChange(t *testing.T) {
+	h := newHarness(t, monday)
+	h.addUser(hourly(1, "Europe/Moscow", 9*60, 11*60+30)) // UTC+3
+
+	h.runUntil(monday.Add(10 * time.Hour))
+	h.update(1, func(u *domain.User) { u.TZ = "Asia/Tokyo" }) // UTC+9: 19:00 local, window over
+	h.runUntil(monday.Add(36 * time.Hour))
+
+	h.expectSent(
+		"2025-03-03 06:00:00 #1 drink",
+		"2025-03-03 07:00:00 #1 drink",
+		"2025-03-03 08:00:00 #1 drink",
+		"2025-03-04 00:00:00 #1 drink",
+		"2025-03-04 01:00:00 #1 drink",
+		"2025-03-04 02:00:00 #1 drink",
+	)
+}
+
+func TestScheduler_DSTTransition(t *testing.T) {
+	// New York moves from UTC-5 to UTC-4 on 2025-03-09.
+	h := newHarness(t, time.Date(2025, 3, 7, 0, 0, 0, 0, time.UTC))
+	h.addUser(hourly(1, "America/New_York", 9*60, 9*60+30))
+	h.runFor(4 * 24 * time.Hour)
+
+	h.expectSent(
+		"2025-03-07 14:00:00 #1 drink",
+		"2025-03-08 14:00:00 #1 drink",
+		"2025-03-09 13:00:00 #1 drink",
+		"2025-03-10 13:00:00 #1 drink",
+	)
+}
+
+func TestScheduler_ChatsInterleave(t *testing.T) {
+	h := newHarness(t, monday)
+	h.addUser(hourly(1, "UTC", 9*60, 10*60+30))
+	h.addUser(hourly(2, "Europe/Moscow", 12*60, 13*60+30)) // 09:00–10:30 UTC
+	h.runFor(24 * time.Hour)
+
+	h.expectSent(
+		"2025-03-03 09:00:00 #1 drink",
+		"2025-03-03 09:00:00 #2 drink",
+		"2025-03-03 10:00:00 #1 drink",
+		"2025-03-03 10:00:00 #2 drink",
+	)
+}
+
+func TestScheduler_RetryThenDeliver(t *testing.T) {
+	h := newHarness(t, monday)
+	h.sender.fail = func(_ int64, call int) error {
+		if call < 2 {
+			return errors.New("connection reset")
+		}
+		return nil
+	}
+	h.addUser(hourly(1, "UTC", 9*60, 9*60+30))
+	h.runFor(12 * time.Hour)
+
+	// Backoff: 30s after the first failure, 60s after the second.
+	h.expectSent("2025-03-03 09:01:30 #1 drink")
+	d := h.repo.allDeliveries()
+	if len(d) != 1 || d[0].Status != domain.DeliverySent || d[0].Attempts != 3 {
+		t.Fatalf("unexpected deliveries: %+v", d)
+	}
+}
+
+func TestScheduler_BlockedDisablesUser(t *testing.T) {
+	h := newHarness(t, monday)
+	h.sender.fail = func(chatID int64, _ int) error {
+		if chatID == 1 {
+			return &BlockedError{Reason: "blocked"}
+		}
+		return nil
+	}
+	h.addUser(hourly(1, "UTC", 9*60, 11*60+30))
+	h.addUser(hourly(2, "UTC", 9*60, 9*60+30))
+	h.runFor(48 * time.Hour)
+
+	h.expectSent(
+		"2025-03-03 09:00:00 #2 drink",
+		"2025-03-04 09:00:00 #2 drink",
+	)
+	u, err := h.repo.GetUser(h.ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Enabled || u.DisabledReason != "blocked" {
+		t.Fatalf("user 1 should be disabled as blocked: %+v", u)
+	}
+}
+
+func TestScheduler_RejectedDeadLettersOnly(t *testing.T) {
+	h := newHarness(t, monday)
+	h.sender.fail = func(chatID int64, call int) error {
+		if call == 0 {
+			return &RejectedError{Reason: "webhook returned 410 Gone"}
+		}
+		return nil
+	}
+	h.addUser(hourly(1, "UTC", 9*60, 10*60))
+	h.runFor(24 * time.Hour)
+
+	// The first reminder is given up at once; the user keeps the rest.
+	h.expectSent("2025-03-03 10:00:00 #1 drink")
+	if u, err := h.repo.GetUser(h.ctx, 1); err != nil || !u.Enabled {
+		t.Fatalf("user 1 should stay enabled: %+v, %v", u, err)
+	}
+	if n := len(h.repo.deadLetters); n != 1 || h.repo.deadLetters[0].Attempts != 1 {
+		t.Fatalf("want one dead letter after one attempt, got %+v", h.repo.deadLetters)
+	}
+}
+
+func TestScheduler_DigestWindowOnVirtualTime(t *testing.T) {
+	h := newHarness(t, monday)
+	u := hourly(1, "UTC", 9*60, 10*60)
+	u.DigestSec = 600
+	h.addUser(u)
+	h.runFor(24 * time.Hour)
+
+	h.expectSent(
+		"2025-03-03 09:10:00 #1 drink",
+		"2025-03-03 10:10:00 #1 drink",
+	)
+}
+
+func TestScheduler_PerChatIntervalOnVirtualTime(t *testing.T) {
+	h := newHarnessWith(t, monday, DispatchConfig{Workers: 1, PerChatInterval: 5 * time.Minute})
+	h.sender.fail = func(_ int64, call int) error {
+		if call < 2 {
+			return errors.New("connection reset")
+		}
+		return nil
+	}
+	h.addUser(hourly(1, "UTC", 9*60, 9*60+30))
+	h.runFor(12 * time.Hour)
+
+	// Each retry is due 30s and 60s after a failure, but waits until five
+	// minutes have passed since the previous attempt.
+	h.expectSent("2025-03-03 09:10:00 #1 drink")
+}
+
+func TestScheduler_GlobalRateLimitOnVirtualTime(t *testing.T) {
+	h := newHarnessWith(t, monday, DispatchConfig{Workers: 1, RatePerSec: 1, Burst: 1})
+	for id := int64(1); id <= 3; id++ {
+		h.addUser(hourly(id, "UTC", 9*60, 9*60+30))
+	}
+	h.runFor(12 * time.Hour)
+
+	h.expectSent(
+		"2025-03-03 09:00:00 #1 drink",
+		"2025-03-03 09:00:01 #2 drink",
+		"2025-03-03 09:00:02 #3 drink",
+	)
+}
+
+// TestScheduler_RunOnFakeClock checks that the real Run loop is driven by
+// the injected clock.
+func TestScheduler_RunOnFakeClock(t *testing.T) {
+	clock := NewFakeClock(monday.Add(8 * time.Hour))
+	repo := newMemRepo(clock)
+	sender := &simSender{clock: clock, call: make(map[int64]int)}
+	s := New(repo, zap.NewNop(), sender, Config{
+		Dispatch: DispatchConfig{Workers: 1},
+		Clock:    clock,
+	})
+
+	u := hourly(1, "UTC", 9*60, 9*60+30)
+	next := domain.NextFire(clock.Now(), &u)
+	u.NextFireAt = &next
+	_ = repo.UpsertUser(context.Background(), &u)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// Step through virtual time; the reconcile ticker loads the slot once it
+	// is within the horizon and the fire timer sends it.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		sender.mu.Lock()
+		n := len(sender.sent)
+		sender.mu.Unlock()
+		if n > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("nothing sent; virtual time %s", clock.Now())
+		}
+		if clock.Now().Before(next) {
+			clock.Advance(time.Minute)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	sender.mu.Lock()
+	defer sender.mu.Unlock()
+	if want := "2025-03-03 09:00:00 #1 drink"; sender.sent[0] != want {
+		t.Fatalf("got %q, want %q", sender.sent[0], want)
+	}
+}
+
+// TestScheduler_RunRetriesOnTime checks that a retry scheduled by a worker
+// reaches the Run loop's queue, rather than waiting for the next reconcile.
+func TestScheduler_RunRetriesOnTime(t *testing.T) {
+	clock := NewFakeClock(monday.Add(9*time.Hour - 30*time.Second))
+	repo := newMemRepo(clock)
+	sender := &simSender{clock: clock, call: make(map[int64]int)}
+	sender.fail = func(_ int64, call int) error {
+		if call == 0 {
+			return errors.New("connection reset")
+		}
+		return nil
+	}
+	s := New(repo, zap.NewNop(), sender, Config{
+		Dispatch: DispatchConfig{Workers: 1},
+		Clock:    clock,
+	})
+
+	u := hourly(1, "UTC", 9*60, 9*60+30)
+	next := domain.NextFire(clock.Now(), &u)
+	u.NextFireAt = &next
+	_ = repo.UpsertUser(context.Background(), &u)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	calls := func() int {
+		sender.mu.Lock()
+		defer sender.mu.Unlock()
+		return sender.call[1]
+	}
+	waitCalls := func(n int) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for calls() < n {
+			if time.Now().After(deadline) {
+				t.Fatalf("%d sends by virtual time %s, want %d", calls(), clock.Now(), n)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	time.Sleep(10 * time.Millisecond) // let Run load the slot
+	clock.Set(next)
+	waitCalls(1)
+	// The first retry is due 30s later, well before the next reconcile.
+	clock.Set(next.Add(DefaultRetryPolicy().Backoff(1)))
+	waitCalls(2)
+
+	sender.mu.Lock()
+	defer sender.mu.Unlock()
+	if want := "2025-03-03 09:00:30 #1 drink"; len(sender.sent) != 1 || sender.sent[0] != want {
+		t.Fatalf("got %q, want %q", sender.sent, want)
+	}
+}
+
+func TestScheduler_DrainsLargeBacklog(t *testing.T) {
+	const users = 2*pageSize + 500
+	h := newHarness(t, monday)
+	for id := int64(1); id <= users; id++ {
+		h.addUser(hourly(id, "UTC", 9*60, 9*60+30))
+	}
+	h.runUntil(monday.Add(9 * time.Hour))
+
+	if got := len(h.sent()); got != users {
+		t.Fatalf("sent %d of %d reminders due at 09:00", got, users)
+	}
+	if lag := h.s.Metrics().LagMax(); lag != 0 {
+		t.Fatalf("max lag %s, want 0 on virtual time", lag)
+	}
+}
+
+func TestScheduler_TickBudgetYields(t *testing.T) {
+	const users = 50
+	h := newHarness(t, monday)
+	h.s.budget = 0 // virtual time stands still within a pass: yield after every entry
+	for id := int64(1); id <= users; id++ {
+		h.addUser(hourly(id, "UTC", 9*60, 9*60+30))
+	}
+	h.runUntil(monday.Add(9 * time.Hour))
+
+	if got := len(h.sent()); got != users {
+		t.Fatalf("sent %d of %d reminders", got, users)
+	}
+	if n := h.s.Metrics().budgetExhausted.Load(); n == 0 {
+		t.Fatal("budget never exhausted")
+	}
+}
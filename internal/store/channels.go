@@ -0,0 +1,46 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/ykvlv/notification-bot/internal/domain"
+)
+
+// GetChannel returns the chat's notification channel, or sql.ErrNoRows if
+// it has none (reminders then go to Telegram).
+func (r *SQLiteRepo) GetChannel(ctx context.Context, chatID int64) (*domain.Channel, error) {
+	var (
+		c         domain.Channel
+		updatedAt int64
+	)
+	err := r.db.QueryRowContext(ctx, `
+		SELECT chat_id, kind, target, updated_at
+		FROM channels WHERE chat_id = ?`, chatID,
+	).Scan(&c.ChatID, &c.Kind, &c.Target, &updatedAt)
+	if err != nil {
+		return nil, err
+	}
+	c.UpdatedAt = time.Unix(updatedAt, 0).UTC()
+	return &c, nil
+}
+
+// SetChannel stores the chat's notification channel, replacing any previous one.
+func (r *SQLiteRepo) SetChannel(ctx context.Context, c *domain.Channel) error {
+	if c.UpdatedAt.IsZero() {
+		c.UpdatedAt = time.Now().UTC()
+	}
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO channels (chat_id, kind, target, updated_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(chat_id) DO UPDATE SET
+			kind = excluded.kind, target = excluded.target, updated_at = excluded.updated_at`,
+		c.ChatID, c.Kind, c.Target, c.UpdatedAt.Unix(),
+	)
+	return err
+}
+
+// DeleteChannel resets the chat to the default Telegram channel.
+func (r *SQLiteRepo) DeleteChannel(ctx context.Context, chatID int64) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM channels WHERE chat_id = ?`, chatID)
+	return err
+}
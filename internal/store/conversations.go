@@ -0,0 +1,55 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/ykvlv/notification-bot/internal/domain"
+)
+
+// GetConversation returns a member's pending input, or sql.ErrNoRows if
+// there is none. Expired rows are returned too, so the caller can say so.
+func (r *SQLiteRepo) GetConversation(ctx context.Context, chatID, userID int64) (*domain.Conversation, error) {
+	var (
+		c         domain.Conversation
+		expiresAt int64
+	)
+	err := r.db.QueryRowContext(ctx, `
+		SELECT chat_id, user_id, state, expires_at
+		FROM conversations WHERE chat_id = ? AND user_id = ?`, chatID, userID,
+	).Scan(&c.ChatID, &c.UserID, &c.State, &expiresAt)
+	if err != nil {
+		return nil, err
+	}
+	c.ExpiresAt = time.Unix(expiresAt, 0).UTC()
+	return &c, nil
+}
+
+// SetConversation stores a member's pending input, replacing any previous one.
+func (r *SQLiteRepo) SetConversation(ctx context.Context, c *domain.Conversation) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO conversations (chat_id, user_id, state, expires_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(chat_id, user_id) DO UPDATE SET
+			state = excluded.state, expires_at = excluded.expires_at`,
+		c.ChatID, c.UserID, c.State, c.ExpiresAt.UTC().Unix(),
+	)
+	return err
+}
+
+// DeleteConversation drops a member's pending input, if any.
+func (r *SQLiteRepo) DeleteConversation(ctx context.Context, chatID, userID int64) error {
+	_, err := r.db.ExecContext(ctx,
+		`DELETE FROM conversations WHERE chat_id = ? AND user_id = ?`, chatID, userID)
+	return err
+}
+
+// DeleteExpiredConversations drops pending inputs that expired before
+// cutoff and returns how many were removed.
+func (r *SQLiteRepo) DeleteExpiredConversations(ctx context.Context, cutoff time.Time) (int64, error) {
+	res, err := r.db.ExecContext(ctx,
+		`DELETE FROM conversations WHERE expires_at < ?`, cutoff.UTC().Unix())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
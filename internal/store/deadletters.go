@@ -0,0 +1,149 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/ykvlv/notification-bot/internal/domain"
+)
+
+// AddDeadLetter records a delivery that exhausted its retries and sets d.ID.
+func (r *SQLiteRepo) AddDeadLetter(ctx context.Context, d *domain.DeadLetter) error {
+	if d == nil {
+		return errors.New("nil dead letter")
+	}
+	created := d.CreatedAt
+	if created.IsZero() {
+		created = time.Now().UTC()
+	}
+	res, err := r.db.ExecContext(ctx, `
+		INSERT INTO dead_letters (
+			chat_id, message, scheduled_at, attempts, last_error, created_at
+		) VALUES (?, ?, ?, ?, ?, ?)`,
+		d.ChatID, d.Message, d.ScheduledAt.UTC().Unix(), d.Attempts, d.LastError, created.UTC().Unix(),
+	)
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	d.ID, d.CreatedAt = id, created.UTC()
+	return nil
+}
+
+// ListDeadLetters returns up to `limit` dead letters that have not been replayed,
+// newest first.
+func (r *SQLiteRepo) ListDeadLetters(ctx context.Context, limit int) ([]domain.DeadLetter, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, chat_id, message, scheduled_at, attempts, last_error, created_at, replayed_at
+		FROM dead_letters
+		WHERE replayed_at IS NULL
+		ORDER BY id DESC
+		LIMIT ?`,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []domain.DeadLetter
+	for rows.Next() {
+		d, err := scanDeadLetter(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, *d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// GetDeadLetter returns a dead letter by id or an error if not found.
+func (r *SQLiteRepo) GetDeadLetter(ctx context.Context, id int64) (*domain.DeadLetter, error) {
+	row := r.db.QueryRowContext(ctx, `
+		SELECT id, chat_id, message, scheduled_at, attempts, last_error, created_at, replayed_at
+		FROM dead_letters
+		WHERE id = ?`,
+		id,
+	)
+	return scanDeadLetter(row)
+}
+
+// ReplayDeadLetter marks a dead letter replayed and queues its message as a
+// new pending delivery due at now, in one transaction, so it goes out
+// through the outbox like any reminder. It returns sql.ErrNoRows if there
+// is no such dead letter and ErrConflict if it was replayed already.
+func (r *SQLiteRepo) ReplayDeadLetter(ctx context.Context, id int64, now time.Time) (*domain.Delivery, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	dl, err := scanDeadLetter(tx.QueryRowContext(ctx, `
+		SELECT id, chat_id, message, scheduled_at, attempts, last_error, created_at, replayed_at
+		FROM dead_letters
+		WHERE id = ?`,
+		id,
+	))
+	if err != nil {
+		return nil, err
+	}
+	due := now.UTC()
+	if err := expectOne(tx.ExecContext(ctx, `
+		UPDATE dead_letters
+		SET replayed_at = ?
+		WHERE id = ? AND replayed_at IS NULL`,
+		due.Unix(), id,
+	)); err != nil {
+		return nil, err
+	}
+
+	// Scheduled at the replay, so its lag does not count the time it lay dead.
+	d := &domain.Delivery{
+		ChatID:        dl.ChatID,
+		Message:       dl.Message,
+		ScheduledAt:   due,
+		Status:        domain.DeliveryPending,
+		NextAttemptAt: &due,
+		CreatedAt:     due,
+	}
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO deliveries (
+			chat_id, message, scheduled_at, status, next_attempt_at, created_at
+		) VALUES (?, ?, ?, ?, ?, ?)`,
+		d.ChatID, d.Message, due.Unix(), d.Status, due.Unix(), due.Unix(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if d.ID, err = res.LastInsertId(); err != nil {
+		return nil, err
+	}
+	return d, tx.Commit()
+}
+
+func scanDeadLetter(s scanner) (*domain.DeadLetter, error) {
+	var (
+		d           domain.DeadLetter
+		scheduledAt int64
+		createdAt   int64
+		replayedNS  sql.NullInt64
+	)
+	if err := s.Scan(
+		&d.ID, &d.ChatID, &d.Message, &scheduledAt, &d.Attempts, &d.LastError, &createdAt, &replayedNS,
+	); err != nil {
+		return nil, err
+	}
+	d.ScheduledAt = time.Unix(scheduledAt, 0).UTC()
+	d.CreatedAt = time.Unix(createdAt, 0).UTC()
+	d.ReplayedAt = fromNullInt64(replayedNS)
+	return &d, nil
+}
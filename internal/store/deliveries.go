@@ -0,0 +1,352 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/ykvlv/notification-bot/internal/domain"
+)
+
+// DeliveryFilter selects deliveries for history and export.
+// Zero values mean "no constraint"; results are newest first.
+type DeliveryFilter struct {
+	ChatID int64     // 0 = all chats
+	From   time.Time // scheduled_at >= From
+	To     time.Time // scheduled_at < To
+	Offset int
+	Limit  int // 0 = no limit
+}
+
+// where builds the WHERE clause and args for f.
+func (f DeliveryFilter) where() (string, []any) {
+	var (
+		conds []string
+		args  []any
+	)
+	if f.ChatID != 0 {
+		conds = append(conds, "chat_id = ?")
+		args = append(args, f.ChatID)
+	}
+	if !f.From.IsZero() {
+		conds = append(conds, "scheduled_at >= ?")
+		args = append(args, f.From.UTC().Unix())
+	}
+	if !f.To.IsZero() {
+		conds = append(conds, "scheduled_at < ?")
+		args = append(args, f.To.UTC().Unix())
+	}
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return "WHERE " + strings.Join(conds, " AND "), args
+}
+
+// ErrConflict is returned when a compare-and-set update finds the row
+// already changed, e.g. a slot claimed by someone else.
+var ErrConflict = errors.New("row changed concurrently")
+
+// deliveryColumns is the column list shared by all delivery SELECTs; see scanDelivery.
+const deliveryColumns = `
+	id, chat_id, message, scheduled_at, sent_at, message_id,
+	status, error, attempts, next_attempt_at, claimed_by, claim_expires_at,
+	acked_at, created_at`
+
+func scanDelivery(s scanner) (*domain.Delivery, error) {
+	var (
+		d           domain.Delivery
+		scheduledAt int64
+		createdAt   int64
+		sentNS      sql.NullInt64
+		nextNS      sql.NullInt64
+		leaseNS     sql.NullInt64
+		ackedNS     sql.NullInt64
+	)
+	if err := s.Scan(
+		&d.ID, &d.ChatID, &d.Message, &scheduledAt, &sentNS, &d.MessageID,
+		&d.Status, &d.Error, &d.Attempts, &nextNS, &d.ClaimedBy, &leaseNS,
+		&ackedNS, &createdAt,
+	); err != nil {
+		return nil, err
+	}
+	d.ScheduledAt = time.Unix(scheduledAt, 0).UTC()
+	d.SentAt = fromNullInt64(sentNS)
+	d.NextAttemptAt = fromNullInt64(nextNS)
+	d.ClaimExpires = fromNullInt64(leaseNS)
+	d.AckedAt = fromNullInt64(ackedNS)
+	d.CreatedAt = time.Unix(createdAt, 0).UTC()
+	return &d, nil
+}
+
+// ClaimSlot atomically advances a user's next_fire_at from slot to next and
+// writes a pending delivery for slot. It returns ErrConflict if next_fire_at
+// is no longer slot (already claimed, or settings changed).
+func (r *SQLiteRepo) ClaimSlot(ctx context.Context, chatID int64, slot, next time.Time, message string) (*domain.Delivery, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE users
+		SET next_fire_at = ?
+		WHERE chat_id = ? AND next_fire_at = ? AND enabled = 1`,
+		next.UTC().Unix(), chatID, slot.UTC().Unix(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return nil, err
+	} else if n == 0 {
+		return nil, ErrConflict
+	}
+
+	// The first attempt is due at the slot itself, which has already passed.
+	due := slot.UTC()
+	d := &domain.Delivery{
+		ChatID:        chatID,
+		Message:       message,
+		ScheduledAt:   due,
+		Status:        domain.DeliveryPending,
+		NextAttemptAt: &due,
+		CreatedAt:     time.Now().UTC(),
+	}
+	res, err = tx.ExecContext(ctx, `
+		INSERT INTO deliveries (
+			chat_id, message, scheduled_at, status, next_attempt_at, created_at
+		) VALUES (?, ?, ?, ?, ?, ?)`,
+		d.ChatID, d.Message, due.Unix(), d.Status, due.Unix(), d.CreatedAt.Unix(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if d.ID, err = res.LastInsertId(); err != nil {
+		return nil, err
+	}
+	return d, tx.Commit()
+}
+
+// GetDelivery returns a delivery by id or an error if not found.
+func (r *SQLiteRepo) GetDelivery(ctx context.Context, id int64) (*domain.Delivery, error) {
+	row := r.db.QueryRowContext(ctx, `
+		SELECT `+deliveryColumns+`
+		FROM deliveries
+		WHERE id = ?`,
+		id,
+	)
+	return scanDelivery(row)
+}
+
+// ListPendingDeliveries returns up to `limit` pending deliveries whose next
+// attempt is due at or before `before`, ordered by (next_attempt_at, id) and
+// starting after the `after` cursor.
+func (r *SQLiteRepo) ListPendingDeliveries(ctx context.Context, before time.Time, after Cursor, limit int) ([]domain.Delivery, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+deliveryColumns+`
+		FROM deliveries
+		WHERE status = ? AND next_attempt_at <= ?
+		  AND (next_attempt_at, id) > (?, ?)
+		ORDER BY next_attempt_at ASC, id ASC
+		LIMIT ?`,
+		domain.DeliveryPending, before.UTC().Unix(), after.unix(), after.ID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return collectDeliveries(rows)
+}
+
+// MarkDeliverySending moves a pending delivery to sending, counts the attempt
+// and leases it to owner until leaseUntil. It returns ErrConflict if the
+// delivery is no longer pending (e.g. another instance took it).
+func (r *SQLiteRepo) MarkDeliverySending(ctx context.Context, id int64, owner string, leaseUntil time.Time) error {
+	return expectOne(r.db.ExecContext(ctx, `
+		UPDATE deliveries
+		SET status = ?, attempts = attempts + 1, next_attempt_at = NULL,
+		    claimed_by = ?, claim_expires_at = ?
+		WHERE id = ? AND status = ?`,
+		domain.DeliverySending, owner, leaseUntil.UTC().Unix(), id, domain.DeliveryPending,
+	))
+}
+
+// MarkDeliverySent confirms a delivery held by owner and updates the user's
+// last_sent_at. A delivery already marked unknown because the lease expired
+// is still confirmed: the message did go out. It returns ErrConflict if the
+// delivery is not owner's.
+func (r *SQLiteRepo) MarkDeliverySent(ctx context.Context, id int64, owner string, messageID int, at time.Time) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := expectOne(tx.ExecContext(ctx, `
+		UPDATE deliveries
+		SET status = ?, sent_at = ?, message_id = ?, error = '',
+		    claimed_by = '', claim_expires_at = NULL
+		WHERE id = ? AND claimed_by = ? AND status IN (?, ?)`,
+		domain.DeliverySent, at.UTC().Unix(), messageID,
+		id, owner, domain.DeliverySending, domain.DeliveryUnknown,
+	)); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE users
+		SET last_sent_at = ?
+		WHERE chat_id = (SELECT chat_id FROM deliveries WHERE id = ?)`,
+		at.UTC().Unix(), id,
+	); err != nil {
+		return err
+	}
+	// Queue a webhook event in the same transaction if the chat has a webhook.
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO webhook_events (chat_id, delivery_id, status, next_attempt_at, created_at)
+		SELECT d.chat_id, d.id, ?, ?, ?
+		FROM deliveries d JOIN webhooks w ON w.chat_id = d.chat_id
+		WHERE d.id = ?`,
+		domain.WebhookPending, at.UTC().Unix(), at.UTC().Unix(), id,
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// MarkDeliveryRetry returns a delivery sending under owner's lease to
+// pending with the next attempt at `at`. It returns ErrConflict if the
+// lease is no longer owner's (it expired and the row was settled or taken).
+func (r *SQLiteRepo) MarkDeliveryRetry(ctx context.Context, id int64, owner, errText string, at time.Time) error {
+	return expectOne(r.db.ExecContext(ctx, `
+		UPDATE deliveries
+		SET status = ?, error = ?, next_attempt_at = ?,
+		    claimed_by = '', claim_expires_at = NULL
+		WHERE id = ? AND claimed_by = ? AND status = ?`,
+		domain.DeliveryPending, errText, at.UTC().Unix(), id, owner, domain.DeliverySending,
+	))
+}
+
+// FinishDelivery sets a terminal status (failed, canceled) with a reason on
+// a delivery that is pending or sending under owner's lease; owner is empty
+// for a pending delivery, which nobody holds. It returns ErrConflict if the
+// delivery has moved on.
+func (r *SQLiteRepo) FinishDelivery(ctx context.Context, id int64, owner, status, errText string) error {
+	return expectOne(r.db.ExecContext(ctx, `
+		UPDATE deliveries
+		SET status = ?, error = ?, next_attempt_at = NULL,
+		    claimed_by = '', claim_expires_at = NULL
+		WHERE id = ? AND claimed_by = ? AND status IN (?, ?)`,
+		status, errText, id, owner, domain.DeliveryPending, domain.DeliverySending,
+	))
+}
+
+// RetargetDelivery points a delivery sending under owner's lease at a new
+// chat and makes it due at `at` (used when a group migrates to a
+// supergroup). It returns ErrConflict if the lease is no longer owner's.
+func (r *SQLiteRepo) RetargetDelivery(ctx context.Context, id int64, owner string, newChatID int64, at time.Time) error {
+	return expectOne(r.db.ExecContext(ctx, `
+		UPDATE deliveries
+		SET chat_id = ?, status = ?, next_attempt_at = ?,
+		    claimed_by = '', claim_expires_at = NULL
+		WHERE id = ? AND claimed_by = ? AND status = ?`,
+		newChatID, domain.DeliveryPending, at.UTC().Unix(), id, owner, domain.DeliverySending,
+	))
+}
+
+// RecoverDeliveries marks deliveries stuck in sending as unknown, so they are
+// never sent twice: those whose lease expired before now (their instance
+// died) and, if owner is set, those leased by owner. Pass owner only at
+// startup, for leases left over from the instance's previous run: later its
+// own sending rows are live. Live leases of other instances are left alone,
+// as are pending deliveries, which were not attempted and will be picked up
+// normally. The lease owner is kept so a late MarkDeliverySent can still
+// confirm the delivery.
+func (r *SQLiteRepo) RecoverDeliveries(ctx context.Context, owner string, now time.Time) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE deliveries
+		SET status = ?, error = 'interrupted while sending', next_attempt_at = NULL
+		WHERE status = ? AND ((? <> '' AND claimed_by = ?) OR claim_expires_at IS NULL OR claim_expires_at <= ?)`,
+		domain.DeliveryUnknown, domain.DeliverySending, owner, owner, now.UTC().Unix(),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// AckDelivery records that the user acknowledged a delivery sent to chatID.
+// It returns ErrConflict if there is no such delivery in that chat or it was
+// already acknowledged.
+func (r *SQLiteRepo) AckDelivery(ctx context.Context, id, chatID int64, at time.Time) error {
+	return expectOne(r.db.ExecContext(ctx, `
+		UPDATE deliveries
+		SET acked_at = ?
+		WHERE id = ? AND chat_id = ? AND acked_at IS NULL`,
+		at.UTC().Unix(), id, chatID,
+	))
+}
+
+// ListDeliveries returns deliveries matching f, newest first.
+func (r *SQLiteRepo) ListDeliveries(ctx context.Context, f DeliveryFilter) ([]domain.Delivery, error) {
+	where, args := f.where()
+	limit := f.Limit
+	if limit <= 0 {
+		limit = -1 // SQLite: no limit
+	}
+	args = append(args, limit, f.Offset)
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+deliveryColumns+`
+		FROM deliveries
+		`+where+`
+		ORDER BY id DESC
+		LIMIT ? OFFSET ?`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return collectDeliveries(rows)
+}
+
+// CountDeliveries returns the number of deliveries matching f (Offset/Limit ignored).
+func (r *SQLiteRepo) CountDeliveries(ctx context.Context, f DeliveryFilter) (int, error) {
+	where, args := f.where()
+	var n int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM deliveries `+where, args...).Scan(&n)
+	return n, err
+}
+
+// collectDeliveries scans and closes rows.
+func collectDeliveries(rows *sql.Rows) ([]domain.Delivery, error) {
+	defer rows.Close()
+	var res []domain.Delivery
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, *d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// expectOne turns an UPDATE result that touched no rows into ErrConflict.
+func expectOne(res sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrConflict
+	}
+	return nil
+}
@@ -0,0 +1,266 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ykvlv/notification-bot/internal/domain"
+)
+
+var t0 = time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)
+
+// openTest opens a migrated in-memory database. The pool holds a single
+// connection, so every query sees the same database.
+func openTest(t *testing.T) *SQLiteRepo {
+	t.Helper()
+	r, err := OpenSQLite(context.Background(), ":memory:")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() { _ = r.Close() })
+	return r
+}
+
+// addUser stores an enabled user due at next.
+func addUser(t *testing.T, r *SQLiteRepo, chatID int64, next time.Time) {
+	t.Helper()
+	u := &domain.User{
+		ChatID: chatID, Enabled: true, TZ: "UTC", IntervalSec: 3600,
+		ActiveFromM: 0, ActiveToM: 0, Message: "drink", NextFireAt: &next,
+	}
+	if err := r.UpsertUser(context.Background(), u); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+}
+
+func mustStatus(t *testing.T, r *SQLiteRepo, id int64, want string) *domain.Delivery {
+	t.Helper()
+	d, err := r.GetDelivery(context.Background(), id)
+	if err != nil {
+		t.Fatalf("get delivery %d: %v", id, err)
+	}
+	if d.Status != want {
+		t.Fatalf("delivery %d: status %q, want %q", id, d.Status, want)
+	}
+	return d
+}
+
+func TestClaimSlot_OnlyOnce(t *testing.T) {
+	r := openTest(t)
+	ctx := context.Background()
+	addUser(t, r, 1, t0)
+
+	d, err := r.ClaimSlot(ctx, 1, t0, t0.Add(time.Hour), "drink")
+	if err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	if d.Status != domain.DeliveryPending || !d.ScheduledAt.Equal(t0) {
+		t.Fatalf("claimed %+v", d)
+	}
+	if _, err := r.ClaimSlot(ctx, 1, t0, t0.Add(time.Hour), "drink"); !errors.Is(err, ErrConflict) {
+		t.Fatalf("second claim: want ErrConflict, got %v", err)
+	}
+	u, _ := r.GetUser(ctx, 1)
+	if !u.NextFireAt.Equal(t0.Add(time.Hour)) {
+		t.Fatalf("next_fire_at %v", u.NextFireAt)
+	}
+	if n, _ := r.CountDeliveries(ctx, DeliveryFilter{}); n != 1 {
+		t.Fatalf("%d deliveries, want 1", n)
+	}
+
+	// A paused user's slot cannot be claimed.
+	if err := r.SetEnabled(ctx, 1, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.ClaimSlot(ctx, 1, t0.Add(time.Hour), t0.Add(2*time.Hour), "drink"); !errors.Is(err, ErrConflict) {
+		t.Fatalf("paused claim: want ErrConflict, got %v", err)
+	}
+}
+
+func TestDelivery_LeaseOwnership(t *testing.T) {
+	r := openTest(t)
+	ctx := context.Background()
+	addUser(t, r, 1, t0)
+	d, err := r.ClaimSlot(ctx, 1, t0, t0.Add(time.Hour), "drink")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.MarkDeliverySending(ctx, d.ID, "a", t0.Add(time.Minute)); err != nil {
+		t.Fatalf("sending: %v", err)
+	}
+	if err := r.MarkDeliverySending(ctx, d.ID, "b", t0.Add(time.Minute)); !errors.Is(err, ErrConflict) {
+		t.Fatalf("second sending: want ErrConflict, got %v", err)
+	}
+	for name, err := range map[string]error{
+		"sent":     r.MarkDeliverySent(ctx, d.ID, "b", 5, t0),
+		"retry":    r.MarkDeliveryRetry(ctx, d.ID, "b", "boom", t0),
+		"finish":   r.FinishDelivery(ctx, d.ID, "b", domain.DeliveryFailed, "boom"),
+		"retarget": r.RetargetDelivery(ctx, d.ID, "b", 2, t0),
+	} {
+		if !errors.Is(err, ErrConflict) {
+			t.Errorf("%s by non-owner: want ErrConflict, got %v", name, err)
+		}
+	}
+	got := mustStatus(t, r, d.ID, domain.DeliverySending)
+	if got.ClaimedBy != "a" || got.Attempts != 1 || got.ChatID != 1 {
+		t.Fatalf("lease changed: %+v", got)
+	}
+
+	// The owner's retry releases the lease; the next attempt can go to anyone.
+	if err := r.MarkDeliveryRetry(ctx, d.ID, "a", "boom", t0.Add(time.Minute)); err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	if err := r.MarkDeliverySent(ctx, d.ID, "a", 5, t0); !errors.Is(err, ErrConflict) {
+		t.Fatalf("sent after retry: want ErrConflict, got %v", err)
+	}
+	if err := r.MarkDeliverySending(ctx, d.ID, "b", t0.Add(2*time.Minute)); err != nil {
+		t.Fatalf("sending by b: %v", err)
+	}
+	if err := r.MarkDeliverySent(ctx, d.ID, "b", 5, t0.Add(time.Minute)); err != nil {
+		t.Fatalf("sent: %v", err)
+	}
+	got = mustStatus(t, r, d.ID, domain.DeliverySent)
+	if got.MessageID != 5 || got.Attempts != 2 || got.ClaimedBy != "" {
+		t.Fatalf("sent delivery %+v", got)
+	}
+	if u, _ := r.GetUser(ctx, 1); u.LastSentAt == nil || !u.LastSentAt.Equal(t0.Add(time.Minute)) {
+		t.Fatalf("last_sent_at %v", u.LastSentAt)
+	}
+}
+
+func TestRecoverDeliveries(t *testing.T) {
+	r := openTest(t)
+	ctx := context.Background()
+	lease := func(chatID int64, owner string, until time.Time) int64 {
+		t.Helper()
+		addUser(t, r, chatID, t0)
+		d, err := r.ClaimSlot(ctx, chatID, t0, t0.Add(time.Hour), "drink")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := r.MarkDeliverySending(ctx, d.ID, owner, until); err != nil {
+			t.Fatal(err)
+		}
+		return d.ID
+	}
+	own := lease(1, "a", t0.Add(time.Hour))
+	live := lease(2, "b", t0.Add(time.Hour))
+	expired := lease(3, "b", t0.Add(time.Minute))
+	addUser(t, r, 4, t0)
+	pending, err := r.ClaimSlot(ctx, 4, t0, t0.Add(time.Hour), "drink")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Periodic recovery touches only expired leases.
+	now := t0.Add(2 * time.Minute)
+	if n, err := r.RecoverDeliveries(ctx, "", now); err != nil || n != 1 {
+		t.Fatalf("periodic recovery: %d, %v", n, err)
+	}
+	mustStatus(t, r, expired, domain.DeliveryUnknown)
+	mustStatus(t, r, own, domain.DeliverySending)
+	mustStatus(t, r, live, domain.DeliverySending)
+	mustStatus(t, r, pending.ID, domain.DeliveryPending)
+
+	// Startup recovery also settles the owner's leases from its previous run.
+	if n, err := r.RecoverDeliveries(ctx, "a", now); err != nil || n != 1 {
+		t.Fatalf("startup recovery: %d, %v", n, err)
+	}
+	mustStatus(t, r, own, domain.DeliveryUnknown)
+	mustStatus(t, r, live, domain.DeliverySending)
+
+	// A late confirmation from the lease owner still wins; nothing else does.
+	if err := r.MarkDeliveryRetry(ctx, expired, "b", "boom", now); !errors.Is(err, ErrConflict) {
+		t.Fatalf("retry of unknown: want ErrConflict, got %v", err)
+	}
+	if err := r.MarkDeliverySent(ctx, expired, "b", 9, now); err != nil {
+		t.Fatalf("late sent: %v", err)
+	}
+	mustStatus(t, r, expired, domain.DeliverySent)
+}
+
+func TestListPendingDeliveries_Keyset(t *testing.T) {
+	r := openTest(t)
+	ctx := context.Background()
+	// Four deliveries, two of them due at the same second.
+	for i, at := range []time.Time{t0, t0, t0.Add(time.Minute), t0.Add(time.Hour)} {
+		chatID := int64(i + 1)
+		addUser(t, r, chatID, at)
+		if _, err := r.ClaimSlot(ctx, chatID, at, at.Add(24*time.Hour), "drink"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var (
+		ids   []int64
+		after Cursor
+	)
+	for {
+		page, err := r.ListPendingDeliveries(ctx, t0.Add(time.Minute), after, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page) == 0 {
+			break
+		}
+		d := page[0]
+		ids = append(ids, d.ID)
+		after = Cursor{At: *d.NextAttemptAt, ID: d.ID}
+	}
+	if len(ids) != 3 || ids[0] != 1 || ids[1] != 2 || ids[2] != 3 {
+		t.Fatalf("pages returned %v, want [1 2 3]", ids)
+	}
+
+	var chats []int64
+	after = Cursor{}
+	for {
+		page, err := r.ListDue(ctx, t0.Add(24*time.Hour+time.Minute), after, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page) == 0 {
+			break
+		}
+		u := page[0]
+		chats = append(chats, u.ChatID)
+		after = Cursor{At: *u.NextFireAt, ID: u.ChatID}
+	}
+	if len(chats) != 3 || chats[0] != 1 || chats[1] != 2 || chats[2] != 3 {
+		t.Fatalf("due pages returned %v, want [1 2 3]", chats)
+	}
+}
+
+func TestReplayDeadLetter_QueuesOnce(t *testing.T) {
+	r := openTest(t)
+	ctx := context.Background()
+	addUser(t, r, 1, t0)
+	dl := &domain.DeadLetter{ChatID: 1, Message: "drink", ScheduledAt: t0, Attempts: 5, LastError: "boom"}
+	if err := r.AddDeadLetter(ctx, dl); err != nil {
+		t.Fatal(err)
+	}
+
+	now := t0.Add(time.Hour)
+	d, err := r.ReplayDeadLetter(ctx, dl.ID, now)
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	got := mustStatus(t, r, d.ID, domain.DeliveryPending)
+	if got.ChatID != 1 || got.Message != "drink" || !got.ScheduledAt.Equal(now) ||
+		got.NextAttemptAt == nil || !got.NextAttemptAt.Equal(now) {
+		t.Fatalf("replayed delivery: %+v", got)
+	}
+	if back, err := r.GetDeadLetter(ctx, dl.ID); err != nil || back.ReplayedAt == nil {
+		t.Fatalf("dead letter not marked replayed: %+v, %v", back, err)
+	}
+
+	if _, err := r.ReplayDeadLetter(ctx, dl.ID, now); !errors.Is(err, ErrConflict) {
+		t.Fatalf("second replay: want ErrConflict, got %v", err)
+	}
+	if _, err := r.ReplayDeadLetter(ctx, dl.ID+1, now); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("missing: want sql.ErrNoRows, got %v", err)
+	}
+}
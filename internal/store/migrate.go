@@ -3,8 +3,10 @@ package store
 import (
 	"context"
 	"embed"
+	"fmt"
 	"io/fs"
 	"sort"
+	"time"
 
 	"database/sql"
 )
@@ -13,8 +15,17 @@ import (
 var migrationsFS embed.FS
 
 // RunMigrations executes SQL files in alphabetical order within the migrations folder.
-// Each file is executed in a single transaction.
+// Each file is executed in a single transaction and recorded in schema_migrations,
+// so it runs only once per database.
 func RunMigrations(ctx context.Context, db *sql.DB) error {
+	if _, err := db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			name       TEXT PRIMARY KEY,
+			applied_at INTEGER NOT NULL
+		)`); err != nil {
+		return err
+	}
+
 	entries, err := fs.ReadDir(migrationsFS, "migrations")
 	if err != nil {
 		return err
@@ -26,6 +37,16 @@ func RunMigrations(ctx context.Context, db *sql.DB) error {
 		if e.IsDir() {
 			continue
 		}
+		var applied int
+		if err := db.QueryRowContext(ctx,
+			`SELECT COUNT(*) FROM schema_migrations WHERE name = ?`, e.Name(),
+		).Scan(&applied); err != nil {
+			return err
+		}
+		if applied > 0 {
+			continue
+		}
+
 		sqlBytes, err := fs.ReadFile(migrationsFS, "migrations/"+e.Name())
 		if err != nil {
 			return err
@@ -36,6 +57,13 @@ func RunMigrations(ctx context.Context, db *sql.DB) error {
 			return err
 		}
 		if _, err := tx.ExecContext(ctx, string(sqlBytes)); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("%s: %w", e.Name(), err)
+		}
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO schema_migrations (name, applied_at) VALUES (?, ?)`,
+			e.Name(), time.Now().UTC().Unix(),
+		); err != nil {
 			_ = tx.Rollback()
 			return err
 		}
@@ -0,0 +1,14 @@
+-- reminders that exhausted all send attempts
+CREATE TABLE IF NOT EXISTS dead_letters (
+    id            INTEGER PRIMARY KEY AUTOINCREMENT,
+    chat_id       INTEGER NOT NULL,
+    message       TEXT NOT NULL,
+    scheduled_at  INTEGER NOT NULL,
+    attempts      INTEGER NOT NULL,
+    last_error    TEXT NOT NULL,
+    created_at    INTEGER NOT NULL,
+    replayed_at   INTEGER
+);
+
+
+CREATE INDEX IF NOT EXISTS idx_dead_letters_pending ON dead_letters(replayed_at, id);
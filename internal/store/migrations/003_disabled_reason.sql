@@ -0,0 +1,2 @@
+-- why a user was disabled by the bot (e.g. blocked); empty when paused by the user
+ALTER TABLE users ADD COLUMN disabled_reason TEXT NOT NULL DEFAULT '';
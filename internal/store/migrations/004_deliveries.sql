@@ -0,0 +1,16 @@
+-- history of every send attempt
+CREATE TABLE IF NOT EXISTS deliveries (
+    id            INTEGER PRIMARY KEY AUTOINCREMENT,
+    chat_id       INTEGER NOT NULL,
+    message       TEXT NOT NULL,
+    scheduled_at  INTEGER NOT NULL,
+    sent_at       INTEGER,
+    message_id    INTEGER NOT NULL DEFAULT 0,
+    status        TEXT NOT NULL,
+    error         TEXT NOT NULL DEFAULT '',
+    created_at    INTEGER NOT NULL
+);
+
+
+CREATE INDEX IF NOT EXISTS idx_deliveries_chat ON deliveries(chat_id, id);
+CREATE INDEX IF NOT EXISTS idx_deliveries_scheduled ON deliveries(scheduled_at);
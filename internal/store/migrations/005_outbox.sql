@@ -0,0 +1,6 @@
+-- deliveries become an outbox: rows are written before sending and updated after
+ALTER TABLE deliveries ADD COLUMN attempts INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE deliveries ADD COLUMN next_attempt_at INTEGER;
+
+
+CREATE INDEX IF NOT EXISTS idx_deliveries_pending ON deliveries(status, next_attempt_at);
@@ -0,0 +1,6 @@
+-- delivery leases: the instance sending a delivery and until when it owns it
+ALTER TABLE deliveries ADD COLUMN claimed_by TEXT NOT NULL DEFAULT '';
+ALTER TABLE deliveries ADD COLUMN claim_expires_at INTEGER;
+
+
+CREATE INDEX IF NOT EXISTS idx_deliveries_leases ON deliveries(status, claim_expires_at);
@@ -0,0 +1,4 @@
+-- keyset pagination over due rows: (next_fire_at, chat_id) and (next_attempt_at, id)
+CREATE INDEX IF NOT EXISTS idx_users_due ON users(next_fire_at, chat_id) WHERE enabled = 1;
+DROP INDEX IF EXISTS idx_deliveries_pending;
+CREATE INDEX IF NOT EXISTS idx_deliveries_pending ON deliveries(status, next_attempt_at, id);
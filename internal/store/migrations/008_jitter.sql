@@ -0,0 +1,7 @@
+-- per-user jitter (seconds added to every slot) and runtime settings
+ALTER TABLE users ADD COLUMN jitter_sec INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS settings (
+    key   TEXT PRIMARY KEY,
+    value TEXT NOT NULL
+);
@@ -0,0 +1,3 @@
+-- per-user digest window and per-reminder acknowledgements
+ALTER TABLE users ADD COLUMN digest_sec INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE deliveries ADD COLUMN acked_at INTEGER;
@@ -0,0 +1,7 @@
+-- per-user notification channel; users without a row get Telegram
+CREATE TABLE IF NOT EXISTS channels (
+    chat_id    INTEGER PRIMARY KEY,
+    kind       TEXT    NOT NULL,
+    target     TEXT    NOT NULL DEFAULT '',
+    updated_at INTEGER NOT NULL
+);
@@ -0,0 +1,24 @@
+-- outgoing webhooks: one per chat, and a log/outbox of events to POST
+CREATE TABLE IF NOT EXISTS webhooks (
+    chat_id    INTEGER PRIMARY KEY,
+    url        TEXT    NOT NULL,
+    secret     TEXT    NOT NULL,
+    created_at INTEGER NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS webhook_events (
+    id              INTEGER PRIMARY KEY AUTOINCREMENT,
+    chat_id         INTEGER NOT NULL,
+    delivery_id     INTEGER NOT NULL,
+    status          TEXT    NOT NULL,
+    attempts        INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at INTEGER,
+    lease_until     INTEGER,
+    response_code   INTEGER NOT NULL DEFAULT 0,
+    error           TEXT    NOT NULL DEFAULT '',
+    created_at      INTEGER NOT NULL,
+    sent_at         INTEGER
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_events_due ON webhook_events(status, next_attempt_at);
+CREATE INDEX IF NOT EXISTS idx_webhook_events_chat ON webhook_events(chat_id, id);
@@ -0,0 +1,2 @@
+-- forum topic (message_thread_id) reminders are posted to; 0 = General / none
+ALTER TABLE users ADD COLUMN thread_id INTEGER NOT NULL DEFAULT 0;
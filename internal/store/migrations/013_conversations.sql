@@ -0,0 +1,10 @@
+-- pending free-form input per chat member (e.g. "enter a custom interval")
+CREATE TABLE IF NOT EXISTS conversations (
+    chat_id    INTEGER NOT NULL,
+    user_id    INTEGER NOT NULL,
+    state      TEXT    NOT NULL,
+    expires_at INTEGER NOT NULL,
+    PRIMARY KEY (chat_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_conversations_expires ON conversations(expires_at);
@@ -0,0 +1,2 @@
+-- language of the bot's texts in the chat (e.g. "ru", "en"); '' = not chosen
+ALTER TABLE users ADD COLUMN language TEXT NOT NULL DEFAULT '';
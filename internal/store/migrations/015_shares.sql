@@ -0,0 +1,10 @@
+-- settings snapshots behind /share links, addressed by an opaque code
+CREATE TABLE IF NOT EXISTS shares (
+    code          TEXT    PRIMARY KEY,
+    interval_sec  INTEGER NOT NULL,
+    active_from_m INTEGER NOT NULL,
+    active_to_m   INTEGER NOT NULL,
+    tz            TEXT    NOT NULL,
+    message       TEXT    NOT NULL,
+    created_at    INTEGER NOT NULL
+);
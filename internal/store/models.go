@@ -2,9 +2,15 @@ package store
 
 import (
 	"database/sql"
+	"math"
 	"time"
 )
 
+// scanner is satisfied by *sql.Row and *sql.Rows.
+type scanner interface {
+	Scan(dest ...any) error
+}
+
 func toNullInt64(t *time.Time) sql.NullInt64 {
 	if t == nil {
 		return sql.NullInt64{}
@@ -19,3 +25,11 @@ func fromNullInt64(ns sql.NullInt64) *time.Time {
 	t := time.Unix(ns.Int64, 0).UTC()
 	return &t
 }
+
+// unix returns the cursor time as stored; the zero cursor sorts before everything.
+func (c Cursor) unix() int64 {
+	if c.At.IsZero() {
+		return math.MinInt64
+	}
+	return c.At.UTC().Unix()
+}
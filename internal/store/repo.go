@@ -7,12 +7,81 @@ import (
 	"github.com/ykvlv/notification-bot/internal/domain"
 )
 
+// Cursor is a keyset position for paging through due rows ordered by
+// (time, ID). The zero Cursor starts from the beginning; pass the values of
+// the last row returned to get the next page.
+type Cursor struct {
+	At time.Time
+	ID int64
+}
+
 // Repo defines storage operations for users and scheduling.
 type Repo interface {
 	UpsertUser(ctx context.Context, u *domain.User) error
 	GetUser(ctx context.Context, chatID int64) (*domain.User, error)
-	ListDue(ctx context.Context, now time.Time, limit int) ([]domain.User, error)
+	ListDue(ctx context.Context, now time.Time, after Cursor, limit int) ([]domain.User, error)
 	SetSchedule(ctx context.Context, chatID int64, next time.Time, last *time.Time) error
 	SetEnabled(ctx context.Context, chatID int64, enabled bool) error
+	DisableUser(ctx context.Context, chatID int64, reason string) error
+	MigrateChat(ctx context.Context, oldChatID, newChatID int64) error
+	ApplyJitter(ctx context.Context, maxJitter time.Duration, now time.Time) (int64, error)
+
+	// Runtime settings changed by admins (see Setting* keys).
+	GetSetting(ctx context.Context, key string) (string, error)
+	SetSetting(ctx context.Context, key, value string) error
+
+	// Notification channels; a chat without one is notified on Telegram.
+	GetChannel(ctx context.Context, chatID int64) (*domain.Channel, error)
+	SetChannel(ctx context.Context, c *domain.Channel) error
+	DeleteChannel(ctx context.Context, chatID int64) error
+
+	// Conversations: pending free-form input per chat member, with expiry.
+	GetConversation(ctx context.Context, chatID, userID int64) (*domain.Conversation, error)
+	SetConversation(ctx context.Context, c *domain.Conversation) error
+	DeleteConversation(ctx context.Context, chatID, userID int64) error
+	DeleteExpiredConversations(ctx context.Context, cutoff time.Time) (int64, error)
+
+	// Shares: settings snapshots behind /share links.
+	GetShare(ctx context.Context, code string) (*domain.Share, error)
+	AddShare(ctx context.Context, s *domain.Share) error
+
+	// Outgoing webhooks. MarkDeliverySent queues a webhook event for chats
+	// that have one; events are claimed with a lease like deliveries and
+	// kept afterwards as the webhook delivery log.
+	GetWebhook(ctx context.Context, chatID int64) (*domain.Webhook, error)
+	SetWebhook(ctx context.Context, w *domain.Webhook) error
+	DeleteWebhook(ctx context.Context, chatID int64) error
+	ClaimWebhookEvents(ctx context.Context, now, leaseUntil time.Time, limit int) ([]domain.WebhookEvent, error)
+	FinishWebhookEvent(ctx context.Context, id int64, status string, code int, errText string, at time.Time) error
+	RetryWebhookEvent(ctx context.Context, id int64, code int, errText string, next time.Time) error
+	ListWebhookEvents(ctx context.Context, chatID int64, limit int) ([]domain.WebhookEvent, error)
+
+	// Dead letters: deliveries that exhausted their retries.
+	AddDeadLetter(ctx context.Context, d *domain.DeadLetter) error
+	ListDeadLetters(ctx context.Context, limit int) ([]domain.DeadLetter, error)
+	GetDeadLetter(ctx context.Context, id int64) (*domain.DeadLetter, error)
+	ReplayDeadLetter(ctx context.Context, id int64, now time.Time) (*domain.Delivery, error)
+
+	// Deliveries: an outbox of claimed reminders, kept afterwards as history.
+	// ClaimSlot, MarkDeliverySending and MarkDeliverySent are compare-and-set
+	// and return ErrConflict when the row has moved on, so several scheduler
+	// instances can share one store: whoever wins the update owns the row.
+	// MarkDeliverySending leases the delivery to owner until leaseUntil;
+	// MarkDeliveryRetry, FinishDelivery and RetargetDelivery are guarded by
+	// the lease owner too. RecoverDeliveries settles expired leases, plus the
+	// owner's own when owner is set (at startup only).
+	ClaimSlot(ctx context.Context, chatID int64, slot, next time.Time, message string) (*domain.Delivery, error)
+	GetDelivery(ctx context.Context, id int64) (*domain.Delivery, error)
+	ListPendingDeliveries(ctx context.Context, before time.Time, after Cursor, limit int) ([]domain.Delivery, error)
+	MarkDeliverySending(ctx context.Context, id int64, owner string, leaseUntil time.Time) error
+	MarkDeliverySent(ctx context.Context, id int64, owner string, messageID int, at time.Time) error
+	MarkDeliveryRetry(ctx context.Context, id int64, owner, errText string, at time.Time) error
+	FinishDelivery(ctx context.Context, id int64, owner, status, errText string) error
+	RetargetDelivery(ctx context.Context, id int64, owner string, newChatID int64, at time.Time) error
+	RecoverDeliveries(ctx context.Context, owner string, now time.Time) (int64, error)
+	AckDelivery(ctx context.Context, id, chatID int64, at time.Time) error
+	ListDeliveries(ctx context.Context, f DeliveryFilter) ([]domain.Delivery, error)
+	CountDeliveries(ctx context.Context, f DeliveryFilter) (int, error)
+
 	Close() error
 }
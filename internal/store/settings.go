@@ -0,0 +1,86 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/ykvlv/notification-bot/internal/domain"
+)
+
+// Setting keys.
+const (
+	// SettingJitterMax is the maximum per-user jitter as a Go duration ("5m");
+	// empty or "0s" means jitter is off.
+	SettingJitterMax = "jitter_max"
+)
+
+// GetSetting returns a runtime setting, or "" if it was never set.
+func (r *SQLiteRepo) GetSetting(ctx context.Context, key string) (string, error) {
+	var v string
+	err := r.db.QueryRowContext(ctx, `SELECT value FROM settings WHERE key = ?`, key).Scan(&v)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return v, err
+}
+
+// SetSetting stores a runtime setting.
+func (r *SQLiteRepo) SetSetting(ctx context.Context, key, value string) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO settings (key, value) VALUES (?, ?)
+		ON CONFLICT(key) DO UPDATE SET value = excluded.value`,
+		key, value,
+	)
+	return err
+}
+
+// ApplyJitter recomputes every user's jitter_sec for the given maximum
+// (0 turns jitter off) and returns the number of users updated. A scheduled
+// user whose offset changes gets next_fire_at recomputed from now in the
+// same transaction: a slot computed with the old offset would not line up
+// with the new schedule and fire twice.
+func (r *SQLiteRepo) ApplyJitter(ctx context.Context, maxJitter time.Duration, now time.Time) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	rows, err := tx.QueryContext(ctx, `SELECT `+userColumns+` FROM users`)
+	if err != nil {
+		return 0, err
+	}
+	var users []*domain.User
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			_ = rows.Close()
+			return 0, err
+		}
+		users = append(users, u)
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `UPDATE users SET jitter_sec = ?, next_fire_at = ? WHERE chat_id = ?`)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+	for _, u := range users {
+		jitter := domain.JitterFor(u.ChatID, maxJitter)
+		if jitter != u.JitterSec && u.Enabled && u.NextFireAt != nil {
+			u.JitterSec = jitter
+			next := domain.NextFire(now.UTC(), u)
+			u.NextFireAt = &next
+		}
+		if _, err := stmt.ExecContext(ctx, jitter, toNullInt64(u.NextFireAt), u.ChatID); err != nil {
+			return 0, err
+		}
+	}
+	return int64(len(users)), tx.Commit()
+}
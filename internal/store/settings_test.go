@@ -0,0 +1,46 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ykvlv/notification-bot/internal/domain"
+)
+
+func TestApplyJitter_ReschedulesNextFire(t *testing.T) {
+	r := openTest(t)
+	ctx := context.Background()
+	next := t0.Add(time.Hour) // 10:00, computed without jitter
+	u := &domain.User{
+		ChatID: 1, Enabled: true, TZ: "UTC", IntervalSec: 3600,
+		ActiveFromM: 9 * 60, ActiveToM: 18 * 60, Message: "drink", NextFireAt: &next,
+	}
+	if err := r.UpsertUser(ctx, u); err != nil {
+		t.Fatal(err)
+	}
+
+	// check applies maxJitter at now and expects the stored slot at want,
+	// followed by want+1h once it has fired.
+	check := func(name string, maxJitter time.Duration, now, want time.Time) {
+		t.Helper()
+		if n, err := r.ApplyJitter(ctx, maxJitter, now); err != nil || n != 1 {
+			t.Fatalf("%s: apply: %d, %v", name, n, err)
+		}
+		got, err := r.GetUser(ctx, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.JitterSec != domain.JitterFor(1, maxJitter) || !got.NextFireAt.Equal(want) {
+			t.Fatalf("%s: jitter %ds, next %s; want next %s", name, got.JitterSec, got.NextFireAt, want)
+		}
+		if after := domain.NextFire(want, got); !after.Equal(want.Add(time.Hour)) {
+			t.Fatalf("%s: slot after %s is %s, want one interval later", name, want, after)
+		}
+	}
+
+	// Chat 1 gets 99s of 5m and 147s of 10m.
+	check("off to on", 5*time.Minute, t0.Add(30*time.Minute), t0.Add(time.Hour+99*time.Second))
+	check("raise", 10*time.Minute, t0.Add(30*time.Minute), t0.Add(time.Hour+147*time.Second))
+	check("off", 0, t0.Add(90*time.Minute), t0.Add(2*time.Hour))
+}
@@ -0,0 +1,40 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/ykvlv/notification-bot/internal/domain"
+)
+
+// GetShare returns the settings behind a share code, or sql.ErrNoRows.
+func (r *SQLiteRepo) GetShare(ctx context.Context, code string) (*domain.Share, error) {
+	var (
+		s         domain.Share
+		createdAt int64
+	)
+	err := r.db.QueryRowContext(ctx, `
+		SELECT code, interval_sec, active_from_m, active_to_m, tz, message, created_at
+		FROM shares WHERE code = ?`, code,
+	).Scan(&s.Code, &s.IntervalSec, &s.ActiveFromM, &s.ActiveToM, &s.TZ, &s.Message, &createdAt)
+	if err != nil {
+		return nil, err
+	}
+	s.CreatedAt = time.Unix(createdAt, 0).UTC()
+	return &s, nil
+}
+
+// AddShare stores a share. Codes are derived from the settings, so an
+// existing code already holds the same settings and is kept as is.
+func (r *SQLiteRepo) AddShare(ctx context.Context, s *domain.Share) error {
+	if s.CreatedAt.IsZero() {
+		s.CreatedAt = time.Now().UTC()
+	}
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO shares (code, interval_sec, active_from_m, active_to_m, tz, message, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(code) DO NOTHING`,
+		s.Code, s.IntervalSec, s.ActiveFromM, s.ActiveToM, s.TZ, s.Message, s.CreatedAt.Unix(),
+	)
+	return err
+}
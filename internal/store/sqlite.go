@@ -85,8 +85,9 @@ func (r *SQLiteRepo) UpsertUser(ctx context.Context, u *domain.User) error {
 	_, err := r.db.ExecContext(ctx, `
 		INSERT INTO users (
 			chat_id, created_at, enabled, tz, interval_sec,
-			active_from_m, active_to_m, message, next_fire_at, last_sent_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			active_from_m, active_to_m, message, next_fire_at, last_sent_at,
+			disabled_reason, jitter_sec, digest_sec, thread_id, language
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(chat_id) DO UPDATE SET
 			enabled       = excluded.enabled,
 			tz            = excluded.tz,
@@ -95,72 +96,73 @@ func (r *SQLiteRepo) UpsertUser(ctx context.Context, u *domain.User) error {
 			active_to_m   = excluded.active_to_m,
 			message       = excluded.message,
 			next_fire_at  = excluded.next_fire_at,
-			last_sent_at  = excluded.last_sent_at`,
+			last_sent_at  = excluded.last_sent_at,
+			disabled_reason = excluded.disabled_reason,
+			jitter_sec    = excluded.jitter_sec,
+			digest_sec    = excluded.digest_sec,
+			thread_id     = excluded.thread_id,
+			language      = excluded.language`,
 		u.ChatID, created, boolToInt(u.Enabled), u.TZ, u.IntervalSec,
 		u.ActiveFromM, u.ActiveToM, u.Message,
 		toNullInt64(u.NextFireAt), toNullInt64(u.LastSentAt),
+		u.DisabledReason, u.JitterSec, u.DigestSec, u.ThreadID, u.Language,
 	)
 	return err
 }
 
-// GetUser returns a user's settings by chatID or an error if not found.
-func (r *SQLiteRepo) GetUser(ctx context.Context, chatID int64) (*domain.User, error) {
-	row := r.db.QueryRowContext(ctx, `
-		SELECT chat_id, created_at, enabled, tz, interval_sec,
-		       active_from_m, active_to_m, message,
-		       next_fire_at, last_sent_at
-		FROM users
-		WHERE chat_id = ?`,
-		chatID,
-	)
+// userColumns is the column list shared by all user SELECTs; see scanUser.
+const userColumns = `
+	chat_id, created_at, enabled, tz, interval_sec,
+	active_from_m, active_to_m, message,
+	next_fire_at, last_sent_at, disabled_reason, jitter_sec, digest_sec, thread_id, language`
 
+// scanUser reads a row selected with userColumns.
+func scanUser(s scanner) (*domain.User, error) {
 	var (
-		chatIDOut   int64
-		createdAt   int64
-		enabledInt  int
-		tz          string
-		intervalSec int
-		activeFromM int
-		activeToM   int
-		message     string
-		nextNS      sql.NullInt64
-		lastNS      sql.NullInt64
+		u          domain.User
+		createdAt  int64
+		enabledInt int
+		nextNS     sql.NullInt64
+		lastNS     sql.NullInt64
 	)
-
-	if err := row.Scan(
-		&chatIDOut, &createdAt, &enabledInt, &tz, &intervalSec,
-		&activeFromM, &activeToM, &message, &nextNS, &lastNS,
+	if err := s.Scan(
+		&u.ChatID, &createdAt, &enabledInt, &u.TZ, &u.IntervalSec,
+		&u.ActiveFromM, &u.ActiveToM, &u.Message,
+		&nextNS, &lastNS, &u.DisabledReason, &u.JitterSec, &u.DigestSec, &u.ThreadID, &u.Language,
 	); err != nil {
 		return nil, err
 	}
+	u.Enabled = enabledInt != 0
+	u.NextFireAt = fromNullInt64(nextNS)
+	u.LastSentAt = fromNullInt64(lastNS)
+	u.CreatedAt = time.Unix(createdAt, 0).UTC()
+	return &u, nil
+}
 
-	return &domain.User{
-		ChatID:      chatIDOut,
-		Enabled:     enabledInt != 0,
-		TZ:          tz,
-		IntervalSec: intervalSec,
-		ActiveFromM: activeFromM,
-		ActiveToM:   activeToM,
-		Message:     message,
-		NextFireAt:  fromNullInt64(nextNS),
-		LastSentAt:  fromNullInt64(lastNS),
-		CreatedAt:   time.Unix(createdAt, 0).UTC(),
-	}, nil
+// GetUser returns a user's settings by chatID or an error if not found.
+func (r *SQLiteRepo) GetUser(ctx context.Context, chatID int64) (*domain.User, error) {
+	row := r.db.QueryRowContext(ctx, `
+		SELECT `+userColumns+`
+		FROM users
+		WHERE chat_id = ?`,
+		chatID,
+	)
+	return scanUser(row)
 }
 
-// ListDue returns up to `limit` users whose next_fire_at is <= now and are enabled.
-// Results are ordered by next_fire_at ascending.
-func (r *SQLiteRepo) ListDue(ctx context.Context, now time.Time, limit int) ([]domain.User, error) {
+// ListDue returns up to `limit` enabled users whose next_fire_at is <= now,
+// ordered by (next_fire_at, chat_id) and starting after the `after` cursor.
+func (r *SQLiteRepo) ListDue(ctx context.Context, now time.Time, after Cursor, limit int) ([]domain.User, error) {
 	rows, err := r.db.QueryContext(ctx, `
-		SELECT chat_id, created_at, enabled, tz, interval_sec,
-		       active_from_m, active_to_m, message, next_fire_at, last_sent_at
+		SELECT `+userColumns+`
 		FROM users
 		WHERE enabled = 1
 		  AND next_fire_at IS NOT NULL
 		  AND next_fire_at <= ?
-		ORDER BY next_fire_at ASC
+		  AND (next_fire_at, chat_id) > (?, ?)
+		ORDER BY next_fire_at ASC, chat_id ASC
 		LIMIT ?`,
-		now.UTC().Unix(), limit,
+		now.UTC().Unix(), after.unix(), after.ID, limit,
 	)
 	if err != nil {
 		return nil, err
@@ -169,38 +171,11 @@ func (r *SQLiteRepo) ListDue(ctx context.Context, now time.Time, limit int) ([]d
 
 	var res []domain.User
 	for rows.Next() {
-		var (
-			chatIDOut   int64
-			createdAt   int64
-			enabledInt  int
-			tz          string
-			intervalSec int
-			activeFromM int
-			activeToM   int
-			message     string
-			nextNS      sql.NullInt64
-			lastNS      sql.NullInt64
-		)
-
-		if err := rows.Scan(
-			&chatIDOut, &createdAt, &enabledInt, &tz, &intervalSec,
-			&activeFromM, &activeToM, &message, &nextNS, &lastNS,
-		); err != nil {
+		u, err := scanUser(rows)
+		if err != nil {
 			return nil, err
 		}
-
-		res = append(res, domain.User{
-			ChatID:      chatIDOut,
-			Enabled:     enabledInt != 0,
-			TZ:          tz,
-			IntervalSec: intervalSec,
-			ActiveFromM: activeFromM,
-			ActiveToM:   activeToM,
-			Message:     message,
-			NextFireAt:  fromNullInt64(nextNS),
-			LastSentAt:  fromNullInt64(lastNS),
-			CreatedAt:   time.Unix(createdAt, 0).UTC(),
-		})
+		res = append(res, *u)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, err
@@ -219,17 +194,61 @@ func (r *SQLiteRepo) SetSchedule(ctx context.Context, chatID int64, next time.Ti
 	return err
 }
 
-// SetEnabled toggles the enabled flag for a user.
+// SetEnabled toggles the enabled flag for a user and clears any disabled reason.
 func (r *SQLiteRepo) SetEnabled(ctx context.Context, chatID int64, enabled bool) error {
 	_, err := r.db.ExecContext(ctx, `
 		UPDATE users
-		SET enabled = ?
+		SET enabled = ?, disabled_reason = ''
 		WHERE chat_id = ?`,
 		boolToInt(enabled), chatID,
 	)
 	return err
 }
 
+// DisableUser turns a user off on the bot's initiative and records why.
+func (r *SQLiteRepo) DisableUser(ctx context.Context, chatID int64, reason string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE users
+		SET enabled = 0, disabled_reason = ?
+		WHERE chat_id = ?`,
+		reason, chatID,
+	)
+	return err
+}
+
+// MigrateChat moves a user row, its channel and webhook to a new chat ID (group upgraded to supergroup)
+// and drops pending conversations.
+// If a user row for newChatID already exists, the old rows are dropped instead.
+func (r *SQLiteRepo) MigrateChat(ctx context.Context, oldChatID, newChatID int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var exists int
+	if err := tx.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM users WHERE chat_id = ?`, newChatID,
+	).Scan(&exists); err != nil {
+		return err
+	}
+	for _, table := range []string{"users", "channels", "webhooks"} {
+		if exists > 0 {
+			_, err = tx.ExecContext(ctx, `DELETE FROM `+table+` WHERE chat_id = ?`, oldChatID)
+		} else {
+			_, err = tx.ExecContext(ctx, `UPDATE `+table+` SET chat_id = ? WHERE chat_id = ?`, newChatID, oldChatID)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	// Pending input is not worth carrying over.
+	if _, err := tx.ExecContext(ctx, `DELETE FROM conversations WHERE chat_id = ?`, oldChatID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
 // boolToInt converts a boolean to 1/0 for SQLite.
 func boolToInt(b bool) int {
 	if b {
@@ -0,0 +1,195 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/ykvlv/notification-bot/internal/domain"
+)
+
+// GetWebhook returns the chat's outgoing webhook, or sql.ErrNoRows if none.
+func (r *SQLiteRepo) GetWebhook(ctx context.Context, chatID int64) (*domain.Webhook, error) {
+	var (
+		w         domain.Webhook
+		createdAt int64
+	)
+	err := r.db.QueryRowContext(ctx, `
+		SELECT chat_id, url, secret, created_at
+		FROM webhooks WHERE chat_id = ?`, chatID,
+	).Scan(&w.ChatID, &w.URL, &w.Secret, &createdAt)
+	if err != nil {
+		return nil, err
+	}
+	w.CreatedAt = time.Unix(createdAt, 0).UTC()
+	return &w, nil
+}
+
+// SetWebhook stores the chat's outgoing webhook, replacing any previous one.
+func (r *SQLiteRepo) SetWebhook(ctx context.Context, w *domain.Webhook) error {
+	if w.CreatedAt.IsZero() {
+		w.CreatedAt = time.Now().UTC()
+	}
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO webhooks (chat_id, url, secret, created_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(chat_id) DO UPDATE SET
+			url = excluded.url, secret = excluded.secret, created_at = excluded.created_at`,
+		w.ChatID, w.URL, w.Secret, w.CreatedAt.Unix(),
+	)
+	return err
+}
+
+// DeleteWebhook removes the chat's webhook. Events not sent yet fail with
+// "webhook removed" when they come up.
+func (r *SQLiteRepo) DeleteWebhook(ctx context.Context, chatID int64) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM webhooks WHERE chat_id = ?`, chatID)
+	return err
+}
+
+// webhookEventColumns is the column list shared by webhook event SELECTs
+// (e = webhook_events, d = deliveries); see scanWebhookEvent.
+const webhookEventColumns = `
+	e.id, e.chat_id, e.delivery_id, e.status, e.attempts, e.next_attempt_at,
+	e.lease_until, e.response_code, e.error, e.created_at, e.sent_at,
+	d.message, d.scheduled_at, d.sent_at`
+
+func scanWebhookEvent(s scanner) (*domain.WebhookEvent, error) {
+	var (
+		e           domain.WebhookEvent
+		createdAt   int64
+		scheduledAt int64
+		nextNS      sql.NullInt64
+		leaseNS     sql.NullInt64
+		sentNS      sql.NullInt64
+		deliveredNS sql.NullInt64
+	)
+	if err := s.Scan(
+		&e.ID, &e.ChatID, &e.DeliveryID, &e.Status, &e.Attempts, &nextNS,
+		&leaseNS, &e.ResponseCode, &e.Error, &createdAt, &sentNS,
+		&e.Message, &scheduledAt, &deliveredNS,
+	); err != nil {
+		return nil, err
+	}
+	e.NextAttemptAt = fromNullInt64(nextNS)
+	e.LeaseUntil = fromNullInt64(leaseNS)
+	e.CreatedAt = time.Unix(createdAt, 0).UTC()
+	e.SentAt = fromNullInt64(sentNS)
+	e.ScheduledAt = time.Unix(scheduledAt, 0).UTC()
+	e.DeliveredAt = fromNullInt64(deliveredNS)
+	return &e, nil
+}
+
+func collectWebhookEvents(rows *sql.Rows) ([]domain.WebhookEvent, error) {
+	defer rows.Close()
+	var res []domain.WebhookEvent
+	for rows.Next() {
+		e, err := scanWebhookEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, *e)
+	}
+	return res, rows.Err()
+}
+
+// ClaimWebhookEvents leases up to `limit` events that are due at `now`
+// (pending ones whose attempt is due, and sending ones whose lease expired)
+// until leaseUntil, counting the attempt. Each event is claimed with a
+// compare-and-set update, so instances sharing the store never claim the
+// same event twice.
+func (r *SQLiteRepo) ClaimWebhookEvents(ctx context.Context, now, leaseUntil time.Time, limit int) ([]domain.WebhookEvent, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id FROM webhook_events
+		WHERE (status = ? AND next_attempt_at <= ?)
+		   OR (status = ? AND lease_until <= ?)
+		ORDER BY id ASC
+		LIMIT ?`,
+		domain.WebhookPending, now.UTC().Unix(), domain.WebhookSending, now.UTC().Unix(), limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var res []domain.WebhookEvent
+	for _, id := range ids {
+		err := expectOne(r.db.ExecContext(ctx, `
+			UPDATE webhook_events
+			SET status = ?, attempts = attempts + 1, next_attempt_at = NULL, lease_until = ?
+			WHERE id = ? AND ((status = ? AND next_attempt_at <= ?) OR (status = ? AND lease_until <= ?))`,
+			domain.WebhookSending, leaseUntil.UTC().Unix(),
+			id, domain.WebhookPending, now.UTC().Unix(), domain.WebhookSending, now.UTC().Unix(),
+		))
+		if errors.Is(err, ErrConflict) {
+			continue // claimed by another instance
+		}
+		if err != nil {
+			return res, err
+		}
+		e, err := scanWebhookEvent(r.db.QueryRowContext(ctx, `
+			SELECT `+webhookEventColumns+`
+			FROM webhook_events e JOIN deliveries d ON d.id = e.delivery_id
+			WHERE e.id = ?`, id))
+		if err != nil {
+			return res, err
+		}
+		res = append(res, *e)
+	}
+	return res, nil
+}
+
+// FinishWebhookEvent records the final outcome (sent or failed) of an event.
+func (r *SQLiteRepo) FinishWebhookEvent(ctx context.Context, id int64, status string, code int, errText string, at time.Time) error {
+	var sentAt *time.Time
+	if status == domain.WebhookSent {
+		sentAt = &at
+	}
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE webhook_events
+		SET status = ?, response_code = ?, error = ?, sent_at = ?,
+		    next_attempt_at = NULL, lease_until = NULL
+		WHERE id = ?`,
+		status, code, errText, toNullInt64(sentAt), id,
+	)
+	return err
+}
+
+// RetryWebhookEvent returns an event to pending with the next attempt at `next`.
+func (r *SQLiteRepo) RetryWebhookEvent(ctx context.Context, id int64, code int, errText string, next time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE webhook_events
+		SET status = ?, response_code = ?, error = ?, next_attempt_at = ?, lease_until = NULL
+		WHERE id = ?`,
+		domain.WebhookPending, code, errText, next.UTC().Unix(), id,
+	)
+	return err
+}
+
+// ListWebhookEvents returns the chat's most recent webhook events, newest first.
+func (r *SQLiteRepo) ListWebhookEvents(ctx context.Context, chatID int64, limit int) ([]domain.WebhookEvent, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+webhookEventColumns+`
+		FROM webhook_events e JOIN deliveries d ON d.id = e.delivery_id
+		WHERE e.chat_id = ?
+		ORDER BY e.id DESC
+		LIMIT ?`,
+		chatID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return collectWebhookEvents(rows)
+}
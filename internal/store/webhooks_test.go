@@ -0,0 +1,61 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ykvlv/notification-bot/internal/domain"
+)
+
+func TestClaimWebhookEvents(t *testing.T) {
+	r := openTest(t)
+	ctx := context.Background()
+	addUser(t, r, 1, t0)
+	if err := r.SetWebhook(ctx, &domain.Webhook{ChatID: 1, URL: "https://example.com/hook", Secret: "00"}); err != nil {
+		t.Fatal(err)
+	}
+	// Sending a delivery queues its event, due at once.
+	d, err := r.ClaimSlot(ctx, 1, t0, t0.Add(time.Hour), "drink")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.MarkDeliverySending(ctx, d.ID, "a", t0.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.MarkDeliverySent(ctx, d.ID, "a", 5, t0); err != nil {
+		t.Fatal(err)
+	}
+
+	lease := t0.Add(5 * time.Minute)
+	events, err := r.ClaimWebhookEvents(ctx, t0, lease, 10)
+	if err != nil || len(events) != 1 {
+		t.Fatalf("claim: %+v, %v", events, err)
+	}
+	e := events[0]
+	if e.DeliveryID != d.ID || e.Status != domain.WebhookSending || e.Attempts != 1 ||
+		e.Message != "drink" || e.DeliveredAt == nil || !e.LeaseUntil.Equal(lease) {
+		t.Fatalf("claimed %+v", e)
+	}
+
+	// A leased event is not claimed again until its lease runs out.
+	if events, err := r.ClaimWebhookEvents(ctx, t0.Add(time.Minute), lease, 10); err != nil || len(events) != 0 {
+		t.Fatalf("claimed under a live lease: %+v, %v", events, err)
+	}
+	events, err = r.ClaimWebhookEvents(ctx, lease, lease.Add(5*time.Minute), 10)
+	if err != nil || len(events) != 1 || events[0].Attempts != 2 {
+		t.Fatalf("reclaim after expiry: %+v, %v", events, err)
+	}
+
+	// A retry is due at its next attempt, not before.
+	next := lease.Add(time.Minute)
+	if err := r.RetryWebhookEvent(ctx, e.ID, 500, "boom", next); err != nil {
+		t.Fatal(err)
+	}
+	if events, _ := r.ClaimWebhookEvents(ctx, next.Add(-time.Second), next, 10); len(events) != 0 {
+		t.Fatalf("claimed before its retry: %+v", events)
+	}
+	if events, _ := r.ClaimWebhookEvents(ctx, next, next.Add(time.Minute), 10); len(events) != 1 {
+		t.Fatalf("retry not claimed: %+v", events)
+	}
+}
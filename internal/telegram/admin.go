@@ -0,0 +1,131 @@
+package telegram
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"github.com/ykvlv/notification-bot/internal/domain"
+	"github.com/ykvlv/notification-bot/internal/store"
+)
+
+// deadLettersPageSize is how many dead letters /deadletters shows.
+const deadLettersPageSize = 20
+
+// isAdmin reports whether the sender is listed in ADMIN_IDS.
+func (r *Router) isAdmin(from *tgbotapi.User) bool {
+	return from != nil && r.admins[from.ID]
+}
+
+// handleDeadLetters lists the most recent dead letters that were not replayed.
+func (r *Router) handleDeadLetters(ctx context.Context, chatID int64) {
+	items, err := r.repo.ListDeadLetters(ctx, deadLettersPageSize)
+	if err != nil {
+		r.log.Error("ListDeadLetters failed", zap.Error(err))
+		r.sendText(chatID, r.tr(chatID, "dead.failed"))
+		return
+	}
+	if len(items) == 0 {
+		r.sendText(chatID, r.tr(chatID, "dead.none"))
+		return
+	}
+
+	l := r.lang(chatID)
+	var b strings.Builder
+	b.WriteString(l.T("dead.title", len(items)) + "\n\n")
+	for _, d := range items {
+		b.WriteString(l.T("dead.item",
+			d.ID, d.ChatID, d.ScheduledAt.Format("2006-01-02 15:04"), d.Attempts, d.LastError) + "\n")
+	}
+	b.WriteString("\n" + l.T("dead.hint"))
+	r.sendText(chatID, b.String())
+}
+
+// handleReplay queues a dead letter's message as a new delivery. It goes
+// out like any reminder: through the chat's channel, the rate limits and
+// retries, with a Done button.
+func (r *Router) handleReplay(ctx context.Context, chatID int64, arg string) {
+	id, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil {
+		r.sendText(chatID, r.tr(chatID, "replay.usage"))
+		return
+	}
+	d, err := r.repo.ReplayDeadLetter(ctx, id, time.Now())
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		r.sendText(chatID, r.tr(chatID, "replay.not_found", id))
+		return
+	case errors.Is(err, store.ErrConflict):
+		r.sendText(chatID, r.tr(chatID, "replay.already", id))
+		return
+	case err != nil:
+		r.log.Error("ReplayDeadLetter failed", zap.Int64("id", id), zap.Error(err))
+		r.sendText(chatID, r.tr(chatID, "replay.failed", id, err))
+		return
+	}
+	r.notifyDelivery(d.ID)
+	r.sendText(chatID, r.tr(chatID, "replay.done", id))
+}
+
+// jitterMax returns the configured maximum jitter, or 0 if it is off or unreadable.
+func (r *Router) jitterMax(ctx context.Context) time.Duration {
+	v, err := r.repo.GetSetting(ctx, store.SettingJitterMax)
+	if err != nil {
+		r.log.Warn("GetSetting failed", zap.String("key", store.SettingJitterMax), zap.Error(err))
+		return 0
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0
+	}
+	return d
+}
+
+// handleJitter shows or changes the maximum per-user jitter:
+// "/jitter", "/jitter 5m" or "/jitter off".
+func (r *Router) handleJitter(ctx context.Context, chatID int64, arg string) {
+	l := r.lang(chatID)
+	if arg == "" {
+		if cur := r.jitterMax(ctx); cur > 0 {
+			r.sendText(chatID, l.T("jitter.current", l.Duration(cur)))
+		} else {
+			r.sendText(chatID, l.T("jitter.none"))
+		}
+		return
+	}
+
+	var maxJitter time.Duration
+	if arg != "off" {
+		d, err := time.ParseDuration(arg)
+		if err != nil || d < 0 || d > domain.MaxJitter {
+			r.sendText(chatID, l.T("jitter.usage", l.Duration(domain.MaxJitter)))
+			return
+		}
+		maxJitter = d.Truncate(time.Second)
+	}
+
+	if err := r.repo.SetSetting(ctx, store.SettingJitterMax, maxJitter.String()); err != nil {
+		r.log.Error("SetSetting failed", zap.Error(err))
+		r.sendText(chatID, l.T("jitter.failed"))
+		return
+	}
+	n, err := r.repo.ApplyJitter(ctx, maxJitter, time.Now())
+	if err != nil {
+		r.log.Error("ApplyJitter failed", zap.Error(err))
+		r.sendText(chatID, l.T("jitter.apply_failed"))
+		return
+	}
+	r.notifyAllSchedules()
+	r.log.Info("jitter changed", zap.Duration("max", maxJitter), zap.Int64("users", n))
+	if maxJitter == 0 {
+		r.sendText(chatID, l.N("jitter.off", int(n)))
+		return
+	}
+	r.sendText(chatID, l.N("jitter.set", int(n), l.Duration(maxJitter)))
+}
@@ -0,0 +1,143 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"github.com/ykvlv/notification-bot/internal/domain"
+	"github.com/ykvlv/notification-bot/internal/notify"
+	"github.com/ykvlv/notification-bot/internal/store"
+)
+
+var (
+	errBotCannotPost   = errors.New("bot cannot post to the channel")
+	errNotChannelAdmin = errors.New("user is not a channel admin")
+)
+
+// Broadcast is the notify.Channel for domain.ChannelBroadcast: it posts
+// reminders to a Telegram channel, the target being the channel's chat ID.
+// Channel posts carry no Done buttons, since subscribers cannot ack for
+// the user. They are written in the configuring chat's language.
+type Broadcast struct {
+	bot  *tgbotapi.BotAPI
+	repo store.Repo
+}
+
+// NewBroadcast creates a channel poster using bot; repo provides the
+// language of each chat's posts.
+func NewBroadcast(bot *tgbotapi.BotAPI, repo store.Repo) *Broadcast {
+	return &Broadcast{bot: bot, repo: repo}
+}
+
+// Send implements notify.Channel. A bot removed from the channel or
+// stripped of its rights gets 403, which disables the configuring user.
+func (b *Broadcast) Send(ctx context.Context, target string, m notify.Message) error {
+	chatID, err := strconv.ParseInt(target, 10, 64)
+	if err != nil {
+		return fmt.Errorf("bad channel id %q", target)
+	}
+	if _, err := b.bot.Send(tgbotapi.NewMessage(chatID, reminderText(chatLang(ctx, b.repo, m.ChatID), m.Items))); err != nil {
+		return classifySendError(err)
+	}
+	return nil
+}
+
+// handleBroadcast starts choosing a broadcast channel ("/channel broadcast")
+// or sets it directly ("/channel broadcast @name"). It works in private
+// chats only: the reminders keep the private chat's settings.
+func (r *Router) handleBroadcast(ctx context.Context, chatID int64, from *tgbotapi.User, arg string) {
+	if isGroupChat(chatID) {
+		r.sendText(chatID, r.tr(chatID, "broadcast.private_only"))
+		return
+	}
+	if arg == "" {
+		r.setPending(ctx, chatID, userID(from), pendingBroadcast)
+		r.prompt(chatID, from, r.tr(chatID, "broadcast.prompt"))
+		return
+	}
+	r.setBroadcast(ctx, chatID, userID(from), channelRef(arg))
+}
+
+// channelRef reads a channel given as @name, name, a t.me link or a chat ID.
+func channelRef(s string) tgbotapi.ChatConfig {
+	s = strings.TrimSpace(s)
+	if id, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return tgbotapi.ChatConfig{ChatID: id}
+	}
+	for _, p := range []string{"https://t.me/", "http://t.me/", "t.me/", "@"} {
+		s = strings.TrimPrefix(s, p)
+	}
+	return tgbotapi.ChatConfig{SuperGroupUsername: "@" + s}
+}
+
+// handleForwardedPost completes choosing a broadcast channel from a
+// forwarded channel post.
+func (r *Router) handleForwardedPost(ctx context.Context, msg *tgbotapi.Message) {
+	uid := userID(msg.From)
+	r.clearPending(ctx, msg.Chat.ID, uid)
+	r.setBroadcast(ctx, msg.Chat.ID, uid, tgbotapi.ChatConfig{ChatID: msg.ForwardFromChat.ID})
+}
+
+// setBroadcast resolves the channel, checks that the bot may post there
+// and that the user administers it, and stores it as the user's channel.
+func (r *Router) setBroadcast(ctx context.Context, chatID, userID int64, ref tgbotapi.ChatConfig) {
+	ch, err := r.bot.GetChat(tgbotapi.ChatInfoConfig{ChatConfig: ref})
+	if err != nil || !ch.IsChannel() {
+		r.sendText(chatID, r.tr(chatID, "broadcast.not_found"))
+		return
+	}
+	switch err := r.checkBroadcastRights(ch.ID, userID); {
+	case errors.Is(err, errBotCannotPost):
+		r.sendText(chatID, r.tr(chatID, "broadcast.cannot_post", ch.Title))
+		return
+	case errors.Is(err, errNotChannelAdmin):
+		r.sendText(chatID, r.tr(chatID, "broadcast.not_admin", ch.Title))
+		return
+	case err != nil:
+		r.log.Warn("channel rights check failed", zap.Error(err), zap.Int64("channelID", ch.ID))
+		r.sendText(chatID, r.tr(chatID, "broadcast.check_failed"))
+		return
+	}
+
+	err = r.repo.SetChannel(ctx, &domain.Channel{
+		ChatID:    chatID,
+		Kind:      domain.ChannelBroadcast,
+		Target:    strconv.FormatInt(ch.ID, 10),
+		UpdatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		r.log.Error("channel update failed", zap.Error(err))
+		r.sendText(chatID, r.tr(chatID, "channel.failed"))
+		return
+	}
+	r.sendText(chatID, r.tr(chatID, "broadcast.done", ch.Title))
+}
+
+// checkBroadcastRights returns errBotCannotPost unless the bot can post to
+// the channel, and errNotChannelAdmin unless the user administers it.
+func (r *Router) checkBroadcastRights(channelID, userID int64) error {
+	me, err := r.bot.GetChatMember(tgbotapi.GetChatMemberConfig{
+		ChatConfigWithUser: tgbotapi.ChatConfigWithUser{ChatID: channelID, UserID: r.bot.Self.ID},
+	})
+	if err != nil {
+		return err
+	}
+	if !me.IsCreator() && !(me.IsAdministrator() && me.CanPostMessages) {
+		return errBotCannotPost
+	}
+	// Non-admins may be hidden from the bot; any failure means "not an admin".
+	user, err := r.bot.GetChatMember(tgbotapi.GetChatMemberConfig{
+		ChatConfigWithUser: tgbotapi.ChatConfigWithUser{ChatID: channelID, UserID: userID},
+	})
+	if err != nil || !(user.IsCreator() || user.IsAdministrator()) {
+		return errNotChannelAdmin
+	}
+	return nil
+}
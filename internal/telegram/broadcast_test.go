@@ -0,0 +1,72 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"github.com/ykvlv/notification-bot/internal/domain"
+	"github.com/ykvlv/notification-bot/internal/notify"
+	"github.com/ykvlv/notification-bot/internal/scheduler"
+)
+
+func TestChannelRef(t *testing.T) {
+	cases := map[string]tgbotapi.ChatConfig{
+		"@news":               {SuperGroupUsername: "@news"},
+		"news":                {SuperGroupUsername: "@news"},
+		" https://t.me/news ": {SuperGroupUsername: "@news"},
+		"-1001234567890":      {ChatID: -1001234567890},
+	}
+	for in, want := range cases {
+		if got := channelRef(in); got != want {
+			t.Errorf("channelRef(%q) = %+v, want %+v", in, got, want)
+		}
+	}
+}
+
+func TestBroadcast_Send(t *testing.T) {
+	bot, stub := newStubBot(t)
+	stub.results = map[string]string{"sendMessage": `{"message_id":1,"chat":{"id":-100}}`}
+
+	repo := &convRepo{user: &domain.User{ChatID: 42, Language: "en"}}
+	err := NewBroadcast(bot, repo).Send(context.Background(), "-100", notify.Message{
+		ChatID: 42,
+		Items:  []scheduler.Reminder{{DeliveryID: 1, Text: "a"}, {DeliveryID: 2, Text: "b"}},
+	})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	c := stub.last()
+	if c.params["chat_id"] != "-100" || c.params["text"] != "🔔 2 reminders:\n1. a\n2. b" {
+		t.Fatalf("unexpected call %+v", c)
+	}
+	if _, ok := c.params["reply_markup"]; ok {
+		t.Fatal("channel posts must not carry Done buttons")
+	}
+}
+
+func TestCheckBroadcastRights(t *testing.T) {
+	bot, stub := newStubBot(t)
+	r := NewRouter(bot, zap.NewNop(), nil, nil)
+
+	stub.results = map[string]string{"getChatMember": `{"status":"administrator","can_post_messages":true,"user":{"id":1}}`}
+	if err := r.checkBroadcastRights(-100, 7); err != nil {
+		t.Fatalf("admin with post right: %v", err)
+	}
+	if got := stub.last().params["user_id"]; got != "7" {
+		t.Fatalf("user not checked: last user_id = %q", got)
+	}
+
+	stub.results["getChatMember"] = `{"status":"administrator","user":{"id":1}}`
+	if err := r.checkBroadcastRights(-100, 7); !errors.Is(err, errBotCannotPost) {
+		t.Fatalf("admin without post right: got %v", err)
+	}
+
+	stub.results["getChatMember"] = `{"status":"left","user":{"id":1}}`
+	if err := r.checkBroadcastRights(-100, 7); !errors.Is(err, errBotCannotPost) {
+		t.Fatalf("not a member: got %v", err)
+	}
+}
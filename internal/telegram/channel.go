@@ -0,0 +1,83 @@
+package telegram
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"github.com/ykvlv/notification-bot/internal/domain"
+)
+
+// handleChannel shows or changes where the chat's reminders are delivered:
+// "/channel", "/channel telegram", "/channel email a@b.c",
+// "/channel webhook https://…". The file sink is for admins only; email
+// addresses are confirmed first, see handleEmailChannel.
+func (r *Router) handleChannel(ctx context.Context, chatID int64, from *tgbotapi.User, arg string) {
+	if arg == "" {
+		c, err := r.repo.GetChannel(ctx, chatID)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			r.sendText(chatID, r.tr(chatID, "channel.here")+"\n\n"+r.tr(chatID, "channel.usage"))
+		case err != nil:
+			r.log.Error("GetChannel failed", zap.Error(err))
+			r.sendText(chatID, r.tr(chatID, "channel.load_failed"))
+		default:
+			r.sendText(chatID, r.tr(chatID, "channel.current", describeChannel(c))+"\n\n"+r.tr(chatID, "channel.usage"))
+		}
+		return
+	}
+
+	kind, target, _ := strings.Cut(arg, " ")
+	kind = strings.ToLower(kind)
+	if kind == domain.ChannelBroadcast {
+		r.handleBroadcast(ctx, chatID, from, strings.TrimSpace(target))
+		return
+	}
+	if kind == domain.ChannelFile && !r.isAdmin(from) {
+		r.sendText(chatID, r.tr(chatID, "channel.usage"))
+		return
+	}
+	target, err := domain.ValidateChannelTarget(kind, strings.TrimSpace(target))
+	if err != nil {
+		r.sendText(chatID, r.tr(chatID, "channel.usage"))
+		return
+	}
+	if kind == domain.ChannelEmail {
+		r.handleEmailChannel(ctx, chatID, from, target)
+		return
+	}
+	r.setChannel(ctx, chatID, kind, target)
+}
+
+// setChannel stores the chat's channel and reports the change.
+func (r *Router) setChannel(ctx context.Context, chatID int64, kind, target string) {
+	var err error
+	if kind == domain.ChannelTelegram {
+		err = r.repo.DeleteChannel(ctx, chatID)
+	} else {
+		err = r.repo.SetChannel(ctx, &domain.Channel{
+			ChatID:    chatID,
+			Kind:      kind,
+			Target:    target,
+			UpdatedAt: time.Now().UTC(),
+		})
+	}
+	if err != nil {
+		r.log.Error("channel update failed", zap.Error(err))
+		r.sendText(chatID, r.tr(chatID, "channel.failed"))
+		return
+	}
+	r.sendText(chatID, r.tr(chatID, "channel.updated", describeChannel(&domain.Channel{Kind: kind, Target: target})))
+}
+
+func describeChannel(c *domain.Channel) string {
+	if c.Target == "" {
+		return c.Kind
+	}
+	return c.Kind + " " + c.Target
+}
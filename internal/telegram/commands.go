@@ -0,0 +1,199 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/ykvlv/notification-bot/internal/i18n"
+)
+
+// A command is one entry of the command registry: HandleUpdate dispatches
+// through it and RegisterCommands publishes it to Telegram's command menu.
+type command struct {
+	Name string // its menu description is the catalog message "cmd.<Name>"
+	// Settings commands change the chat's settings: in groups only chat
+	// admins may run them, and only admins see them in the menu.
+	Settings bool
+	// Private commands are listed in private chats only. They still run
+	// in groups.
+	Private bool
+	// Admin commands are for ADMIN_IDS only and never listed; to anyone
+	// else they are unknown.
+	Admin bool
+	Run   func(r *Router, ctx context.Context, msg *tgbotapi.Message, args string)
+}
+
+// commands is the command registry, in menu order.
+var commands = []command{
+	{
+		Name:     "start",
+		Settings: true,
+		Run: func(r *Router, ctx context.Context, msg *tgbotapi.Message, args string) {
+			r.handleStart(ctx, msg.Chat.ID, msg.From, args)
+		},
+	},
+	{
+		Name: "status",
+		Run: func(r *Router, ctx context.Context, msg *tgbotapi.Message, _ string) {
+			r.handleStatus(ctx, msg.Chat.ID)
+		},
+	},
+	{
+		Name:     "settings",
+		Settings: true,
+		Run: func(r *Router, ctx context.Context, msg *tgbotapi.Message, _ string) {
+			r.handleSettings(ctx, msg.Chat.ID, msg.From)
+		},
+	},
+	{
+		Name:     "pause",
+		Settings: true,
+		Run: func(r *Router, ctx context.Context, msg *tgbotapi.Message, _ string) {
+			r.handlePause(ctx, msg.Chat.ID)
+		},
+	},
+	{
+		Name:     "resume",
+		Settings: true,
+		Run: func(r *Router, ctx context.Context, msg *tgbotapi.Message, _ string) {
+			r.handleResume(ctx, msg.Chat.ID)
+		},
+	},
+	{
+		Name: "history",
+		Run: func(r *Router, ctx context.Context, msg *tgbotapi.Message, _ string) {
+			r.handleHistory(ctx, msg.Chat.ID)
+		},
+	},
+	{
+		Name: "examples",
+		Run: func(r *Router, ctx context.Context, msg *tgbotapi.Message, _ string) {
+			r.handleExamples(ctx, msg.Chat.ID)
+		},
+	},
+	{
+		Name: "share",
+		Run: func(r *Router, ctx context.Context, msg *tgbotapi.Message, _ string) {
+			r.handleShare(ctx, msg.Chat.ID)
+		},
+	},
+	{
+		Name: "cancel",
+		Run: func(r *Router, ctx context.Context, msg *tgbotapi.Message, _ string) {
+			r.handleCancel(ctx, msg.Chat.ID, msg.From)
+		},
+	},
+	{
+		Name:     "language",
+		Settings: true,
+		Run: func(r *Router, ctx context.Context, msg *tgbotapi.Message, args string) {
+			r.handleLanguage(ctx, msg.Chat.ID, args)
+		},
+	},
+	{
+		Name:     "channel",
+		Settings: true,
+		Private:  true,
+		Run: func(r *Router, ctx context.Context, msg *tgbotapi.Message, args string) {
+			r.handleChannel(ctx, msg.Chat.ID, msg.From, args)
+		},
+	},
+	{
+		Name:     "webhooks",
+		Settings: true,
+		Private:  true,
+		Run: func(r *Router, ctx context.Context, msg *tgbotapi.Message, args string) {
+			r.handleWebhooks(ctx, msg.Chat.ID, args)
+		},
+	},
+
+	// Admin commands
+	{
+		Name:  "deadletters",
+		Admin: true,
+		Run: func(r *Router, ctx context.Context, msg *tgbotapi.Message, _ string) {
+			r.handleDeadLetters(ctx, msg.Chat.ID)
+		},
+	},
+	{
+		Name:  "replay",
+		Admin: true,
+		Run: func(r *Router, ctx context.Context, msg *tgbotapi.Message, args string) {
+			r.handleReplay(ctx, msg.Chat.ID, args)
+		},
+	},
+	{
+		Name:  "jitter",
+		Admin: true,
+		Run: func(r *Router, ctx context.Context, msg *tgbotapi.Message, args string) {
+			r.handleJitter(ctx, msg.Chat.ID, args)
+		},
+	},
+}
+
+// commandByName returns the registered command, or nil.
+func commandByName(name string) *command {
+	for i := range commands {
+		if commands[i].Name == name {
+			return &commands[i]
+		}
+	}
+	return nil
+}
+
+// isSettingsCommand reports whether cmd changes the chat's settings and is
+// therefore reserved for chat admins in groups.
+func isSettingsCommand(cmd string) bool {
+	c := commandByName(cmd)
+	return c != nil && c.Settings
+}
+
+// commandScope is a command menu audience.
+type commandScope struct {
+	scope tgbotapi.BotCommandScope
+	lists func(c command) bool
+}
+
+var commandScopes = []commandScope{
+	{tgbotapi.NewBotCommandScopeAllPrivateChats(), func(c command) bool { return true }},
+	{tgbotapi.NewBotCommandScopeAllGroupChats(), func(c command) bool { return !c.Private && !c.Settings }},
+	{tgbotapi.NewBotCommandScopeAllChatAdministrators(), func(c command) bool { return !c.Private }},
+}
+
+// menuCommands lists the registry for one scope and language.
+func menuCommands(lists func(command) bool, l i18n.Lang) []tgbotapi.BotCommand {
+	var out []tgbotapi.BotCommand
+	for _, c := range commands {
+		if c.Admin || !lists(c) {
+			continue
+		}
+		out = append(out, tgbotapi.BotCommand{Command: c.Name, Description: l.T("cmd." + c.Name)})
+	}
+	return out
+}
+
+// RegisterCommands publishes the command registry with setMyCommands: per
+// scope, one list for each catalog language, the first being the default
+// for apps in other languages.
+func RegisterCommands(bot *tgbotapi.BotAPI) error {
+	for _, s := range commandScopes {
+		scope := s.scope
+		for i, l := range i18n.Langs {
+			code := string(l)
+			if i == 0 {
+				code = ""
+			}
+			_, err := bot.Request(tgbotapi.SetMyCommandsConfig{
+				Commands:     menuCommands(s.lists, l),
+				Scope:        &scope,
+				LanguageCode: code,
+			})
+			if err != nil {
+				return fmt.Errorf("setMyCommands %s %q: %w", scope.Type, code, err)
+			}
+		}
+	}
+	return nil
+}
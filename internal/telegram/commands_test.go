@@ -0,0 +1,77 @@
+package telegram
+
+import (
+	"encoding/json"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/ykvlv/notification-bot/internal/i18n"
+)
+
+func TestRegisterCommands(t *testing.T) {
+	bot, stub := newStubBot(t)
+	if err := RegisterCommands(bot); err != nil {
+		t.Fatal(err)
+	}
+
+	menus := map[string][]tgbotapi.BotCommand{} // "scope/lang" -> commands
+	for _, c := range stub.calls {
+		if c.method != "setMyCommands" {
+			continue
+		}
+		var scope tgbotapi.BotCommandScope
+		var list []tgbotapi.BotCommand
+		if err := json.Unmarshal([]byte(c.params["scope"]), &scope); err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal([]byte(c.params["commands"]), &list); err != nil {
+			t.Fatal(err)
+		}
+		menus[scope.Type+"/"+c.params["language_code"]] = list
+	}
+	if len(menus) != 6 {
+		t.Fatalf("got %d menus, want 3 scopes x 2 languages: %v", len(menus), menus)
+	}
+
+	names := func(key string) map[string]string {
+		m := map[string]string{}
+		for _, c := range menus[key] {
+			m[c.Command] = c.Description
+		}
+		return m
+	}
+	private, members, admins := names("all_private_chats/"), names("all_group_chats/"), names("all_chat_administrators/")
+	for _, cmd := range []string{"start", "status", "settings", "pause", "resume", "examples"} {
+		if private[cmd] == "" || admins[cmd] == "" {
+			t.Errorf("/%s missing from the private or admin menu", cmd)
+		}
+	}
+	if members["settings"] != "" || members["status"] == "" {
+		t.Errorf("group members menu: %v", members)
+	}
+	if private["replay"] != "" || admins["jitter"] != "" {
+		t.Error("admin commands published")
+	}
+	if ru := names("all_private_chats/ru"); ru["status"] == private["status"] {
+		t.Errorf("no Russian description: %q", ru["status"])
+	}
+}
+
+func TestCommandRegistry(t *testing.T) {
+	seen := map[string]bool{}
+	for _, c := range commands {
+		if seen[c.Name] {
+			t.Errorf("/%s registered twice", c.Name)
+		}
+		seen[c.Name] = true
+		for _, l := range i18n.Langs {
+			if _, ok := l.Lookup("cmd." + c.Name); !c.Admin && !ok {
+				t.Errorf("/%s has no %s description", c.Name, l)
+			}
+		}
+	}
+	if !isSettingsCommand("pause") || isSettingsCommand("status") || isSettingsCommand("nope") {
+		t.Error("isSettingsCommand disagrees with the registry")
+	}
+}
@@ -0,0 +1,58 @@
+package telegram
+
+import (
+	"context"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// pendingIs reports whether a member's unexpired pending state is s.
+func (r *Router) pendingIs(ctx context.Context, chatID, userID int64, s string) bool {
+	state, expired := r.getPending(ctx, chatID, userID)
+	return !expired && state == s
+}
+
+// handleCancel drops the sender's pending input: "/cancel".
+func (r *Router) handleCancel(ctx context.Context, chatID int64, from *tgbotapi.User) {
+	uid := userID(from)
+	if state, expired := r.getPending(ctx, chatID, uid); state == "" || expired {
+		r.clearPending(ctx, chatID, uid) // an expired one, if any
+		r.sendText(chatID, r.tr(chatID, "cancel.nothing"))
+		return
+	} else if st, ok := decodeFormState(state); ok {
+		r.retireMenu(chatID, st.Menu)
+	}
+	r.clearPending(ctx, chatID, uid)
+	r.sendText(chatID, r.tr(chatID, "cancel.done"))
+}
+
+// handleCancelCallback is the Cancel button of prompts and menus: it drops
+// the pending input and turns the message into "Cancelled.". Only the
+// member whose input the message asks for can cancel it.
+func (r *Router) handleCancelCallback(ctx context.Context, cb *tgbotapi.CallbackQuery) {
+	chatID := cb.Message.Chat.ID
+	if !r.cancelsOwn(ctx, chatID, cb.From, cb.Message.MessageID) {
+		_ = r.answerCallback(cb.ID, r.tr(chatID, "menu.stale"))
+		return
+	}
+	r.clearPending(ctx, chatID, userID(cb.From))
+	_ = r.answerCallback(cb.ID, r.tr(chatID, "cancel.done"))
+	if err := r.edit(chatID, cb.Message.MessageID, r.tr(chatID, "cancel.done"), nil); err != nil {
+		r.retireMenu(chatID, cb.Message.MessageID)
+	}
+}
+
+// cancelsOwn reports whether a Cancel button on messageID is the sender's:
+// it must be on their settings menu or link confirmation if they have one
+// open. Other prompts carry the button only in private chats, where nobody
+// else can tap it.
+func (r *Router) cancelsOwn(ctx context.Context, chatID int64, from *tgbotapi.User, messageID int) bool {
+	state, _ := r.getPending(ctx, chatID, userID(from))
+	if st, ok := decodeFormState(state); ok && st.Menu != 0 {
+		return st.Menu == messageID
+	}
+	if id, _, ok := parseStartLinkState(state); ok {
+		return id == messageID
+	}
+	return !isGroupChat(chatID)
+}
@@ -0,0 +1,410 @@
+package telegram
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"github.com/ykvlv/notification-bot/internal/domain"
+	"github.com/ykvlv/notification-bot/internal/i18n"
+)
+
+// Deep links open the bot with "/start <payload>": t.me/<bot>?start=<payload>.
+// Telegram allows up to 64 characters from [A-Za-z0-9_-]. A payload is a
+// list of "_"-separated tokens; the first names the format and its version,
+// so a format can change without breaking links already shared:
+//
+//	t1_<template>[_<interval>][_<hours>][_<zone>]  e.g. t1_water_1h_09-21_EuropeBerlin
+//	s1_<code>                                      a stored domain.Share
+//
+// A template link spells its settings out: the interval as in /settings
+// ("1h30m"), active hours as "09-21" or "0930-2100" and the time zone
+// without "/" and "_" ("AmericaNewYork"). Omitted tokens take the
+// template's values; without a zone the chat keeps its own. Settings that
+// do not fit, such as a custom reminder text, are shared by code. Links
+// shared before versioning start with "tpl" and read like "t1".
+const (
+	maxStartPayload = 64
+	linkTemplate    = "t1"  // readable template link, version 1
+	linkTemplateOld = "tpl" // the same, from before links were versioned
+	linkShare       = "s1"  // share code, version 1
+	shareCodeLen    = 12
+)
+
+// pendingStartLink prefixes the pending state of a member asked to confirm
+// a link: "start_link:<message ID>:<payload>", the message being the
+// confirmation with its buttons.
+const pendingStartLink = "start_link:"
+
+// startLinkState is the pending state for a confirmation sent as messageID.
+func startLinkState(messageID int, payload string) string {
+	return pendingStartLink + strconv.Itoa(messageID) + ":" + payload
+}
+
+// parseStartLinkState reverses startLinkState.
+func parseStartLinkState(state string) (messageID int, payload string, ok bool) {
+	rest, ok := strings.CutPrefix(state, pendingStartLink)
+	if !ok {
+		return 0, "", false
+	}
+	id, payload, ok := strings.Cut(rest, ":")
+	if !ok {
+		return 0, "", false
+	}
+	messageID, err := strconv.Atoi(id)
+	return messageID, payload, err == nil
+}
+
+var errBadLink = errors.New("malformed or unsupported start link")
+
+var (
+	payloadRe  = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+	intervalRe = regexp.MustCompile(`^(\d{1,2}h)?(\d{1,4}m)?$`)
+	hoursRe    = regexp.MustCompile(`^(\d\d)(\d\d)?-(\d\d)(\d\d)?$`)
+	zoneRe     = regexp.MustCompile(`^[A-Z][A-Za-z0-9]*$`)
+	wordRe     = regexp.MustCompile(`[A-Z]+[a-z]*[0-9]*`)
+)
+
+// linkSettings is what a deep link sets up.
+type linkSettings struct {
+	Template string // reminderTemplate ID whose text is used, or ""
+	Message  string // the reminder text when Template is ""
+	Interval time.Duration
+	FromM    int
+	ToM      int
+	TZ       string // "" keeps the chat's time zone
+}
+
+// text is the reminder text in language l.
+func (s *linkSettings) text(l i18n.Lang) string {
+	if s.Template != "" {
+		return l.T("tpl." + s.Template + ".message")
+	}
+	return s.Message
+}
+
+// templatePayload encodes s as a template link, if it is one and fits.
+func (s *linkSettings) templatePayload() (string, bool) {
+	t := templateByID(s.Template)
+	if t == nil {
+		return "", false
+	}
+	tokens := []string{linkTemplate, t.ID}
+	if s.Interval != t.Interval {
+		tokens = append(tokens, formatLinkInterval(s.Interval))
+	}
+	if s.FromM != t.FromM || s.ToM != t.ToM {
+		tokens = append(tokens, formatLinkHours(s.FromM, s.ToM))
+	}
+	if s.TZ != "" {
+		zone, ok := encodeZone(s.TZ)
+		if !ok {
+			return "", false
+		}
+		tokens = append(tokens, zone)
+	}
+	p := strings.Join(tokens, "_")
+	return p, len(p) <= maxStartPayload
+}
+
+// parseStartPayload checks a payload's syntax. Template links are decoded
+// in full; for share codes only the code is returned.
+func parseStartPayload(payload string) (s *linkSettings, code string, err error) {
+	if len(payload) > maxStartPayload || !payloadRe.MatchString(payload) {
+		return nil, "", errBadLink
+	}
+	tokens := strings.Split(payload, "_")
+	switch tokens[0] {
+	case linkTemplate, linkTemplateOld:
+		s, err = parseTemplateLink(tokens[1:])
+		return s, "", err
+	case linkShare:
+		code = strings.TrimPrefix(payload, linkShare+"_")
+		if len(code) != shareCodeLen {
+			return nil, "", errBadLink
+		}
+		return nil, code, nil
+	default:
+		return nil, "", errBadLink
+	}
+}
+
+// parseTemplateLink decodes the tokens after "t1". Tokens are told apart
+// by their shape, and each may appear once.
+func parseTemplateLink(tokens []string) (*linkSettings, error) {
+	if len(tokens) == 0 {
+		return nil, errBadLink
+	}
+	t := templateByID(tokens[0])
+	if t == nil {
+		return nil, fmt.Errorf("%w: unknown template %q", errBadLink, tokens[0])
+	}
+	s := &linkSettings{Template: t.ID, Interval: t.Interval, FromM: t.FromM, ToM: t.ToM}
+	seen := map[string]bool{}
+	for _, tok := range tokens[1:] {
+		var kind string
+		var err error
+		switch {
+		case tok != "" && intervalRe.MatchString(tok):
+			kind = "interval"
+			s.Interval, err = domain.ParseDurationHuman(tok)
+		case hoursRe.MatchString(tok):
+			kind = "hours"
+			s.FromM, s.ToM, err = parseLinkHours(tok)
+		case zoneRe.MatchString(tok):
+			kind = "zone"
+			s.TZ, err = decodeZone(tok)
+		default:
+			return nil, fmt.Errorf("%w: token %q", errBadLink, tok)
+		}
+		if err != nil || seen[kind] {
+			return nil, fmt.Errorf("%w: %s %q", errBadLink, kind, tok)
+		}
+		seen[kind] = true
+	}
+	return s, nil
+}
+
+// formatLinkInterval writes d as hours and minutes: "1h", "1h30m", "20m".
+func formatLinkInterval(d time.Duration) string {
+	h, m := int(d/time.Hour), int(d%time.Hour/time.Minute)
+	switch {
+	case m == 0:
+		return strconv.Itoa(h) + "h"
+	case h == 0:
+		return strconv.Itoa(m) + "m"
+	default:
+		return fmt.Sprintf("%dh%dm", h, m)
+	}
+}
+
+// formatLinkHours writes active hours as "09-21", or "0930-2100" when
+// either end is not on the hour.
+func formatLinkHours(fromM, toM int) string {
+	if fromM%60 == 0 && toM%60 == 0 {
+		return fmt.Sprintf("%02d-%02d", fromM/60, toM/60)
+	}
+	return fmt.Sprintf("%02d%02d-%02d%02d", fromM/60, fromM%60, toM/60, toM%60)
+}
+
+func parseLinkHours(tok string) (fromM, toM int, err error) {
+	m := hoursRe.FindStringSubmatch(tok)
+	for _, i := range []int{2, 4} {
+		if m[i] == "" {
+			m[i] = "00"
+		}
+	}
+	return domain.ParseActiveWindow(m[1] + ":" + m[2] + "-" + m[3] + ":" + m[4])
+}
+
+// encodeZone drops the separators of an IANA zone name. Names that would
+// not decode back, such as "Etc/GMT+3", are not encodable.
+func encodeZone(tz string) (string, bool) {
+	zone := strings.NewReplacer("/", "", "_", "").Replace(tz)
+	if !zoneRe.MatchString(zone) {
+		return "", false
+	}
+	back, err := decodeZone(zone)
+	return zone, err == nil && back == tz
+}
+
+// decodeZone restores the separators of an encoded zone name: it splits
+// it into capitalized words and tries "/" or "_" between them until a
+// zone loads ("AmericaNewYork" -> "America/New_York").
+func decodeZone(zone string) (string, error) {
+	words := wordRe.FindAllString(zone, -1)
+	if strings.Join(words, "") != zone || len(words) > 6 {
+		return "", errBadLink
+	}
+	for mask := 0; mask < 1<<(len(words)-1); mask++ {
+		var b strings.Builder
+		b.WriteString(words[0])
+		for i, w := range words[1:] {
+			if mask&(1<<i) == 0 {
+				b.WriteByte('/')
+			} else {
+				b.WriteByte('_')
+			}
+			b.WriteString(w)
+		}
+		if tz, err := domain.ValidateTZ(b.String()); err == nil && tz != "Local" {
+			return tz, nil
+		}
+	}
+	return "", errBadLink
+}
+
+// shareCode derives the code of a share from its settings, so sharing the
+// same settings twice gives the same link.
+func shareCode(s *domain.Share) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d|%d|%d|%s|%s",
+		s.IntervalSec, s.ActiveFromM, s.ActiveToM, s.TZ, s.Message)))
+	return base64.RawURLEncoding.EncodeToString(sum[:shareCodeLen*3/4])
+}
+
+// startURL is the deep link that opens the bot with a payload.
+func (r *Router) startURL(payload string) string {
+	return "https://t.me/" + r.bot.Self.UserName + "?start=" + payload
+}
+
+// resolveStartLink decodes a payload, looking share codes up in the store.
+func (r *Router) resolveStartLink(ctx context.Context, payload string) (*linkSettings, error) {
+	s, code, err := parseStartPayload(payload)
+	if err != nil || s != nil {
+		return s, err
+	}
+	sh, err := r.repo.GetShare(ctx, code)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: no share %q", errBadLink, code)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &linkSettings{
+		Message:  sh.Message,
+		Interval: time.Duration(sh.IntervalSec) * time.Second,
+		FromM:    sh.ActiveFromM,
+		ToM:      sh.ActiveToM,
+		TZ:       sh.TZ,
+	}, nil
+}
+
+// linkSummary lists what a link sets up. A link without a zone keeps tz.
+func linkSummary(l i18n.Lang, s *linkSettings, tz string) string {
+	if s.TZ != "" {
+		tz = s.TZ
+	}
+	return l.T("link.summary", s.text(l), l.Duration(s.Interval),
+		domain.FormatMinutes(s.FromM), domain.FormatMinutes(s.ToM), tz)
+}
+
+// handleStartLink asks to confirm the settings of a deep link; the answer
+// is handled by handleStartLinkCallback.
+func (r *Router) handleStartLink(ctx context.Context, chatID int64, from *tgbotapi.User, payload string) {
+	s, err := r.resolveStartLink(ctx, payload)
+	if err != nil {
+		r.log.Info("bad start link", zap.Error(err), zap.String("payload", payload))
+		r.sendText(chatID, r.tr(chatID, "link.bad"))
+		return
+	}
+	tz := defaultTZ
+	if u, err := r.repo.GetUser(ctx, chatID); err == nil {
+		tz = u.TZ
+	}
+	l := r.lang(chatID)
+	msg := tgbotapi.NewMessage(chatID, l.T("link.confirm", linkSummary(l, s, tz)))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(l.T("btn.link_confirm"), "start_link"),
+		tgbotapi.NewInlineKeyboardButtonData(l.T("btn.cancel"), "cancel"),
+	))
+	sent, err := r.send(msg)
+	if err != nil {
+		return
+	}
+	r.setPending(ctx, chatID, userID(from), startLinkState(sent.MessageID, payload))
+}
+
+// handleStartLinkCallback applies the deep link the member confirmed.
+func (r *Router) handleStartLinkCallback(ctx context.Context, cb *tgbotapi.CallbackQuery) {
+	chatID, uid := cb.Message.Chat.ID, userID(cb.From)
+	state, expired := r.getPending(ctx, chatID, uid)
+	messageID, payload, ok := parseStartLinkState(state)
+	if !ok || expired || messageID != cb.Message.MessageID {
+		_ = r.answerCallback(cb.ID, r.tr(chatID, "link.stale"))
+		return
+	}
+	_ = r.answerCallback(cb.ID, "")
+	r.clearPending(ctx, chatID, uid)
+	r.retireMenu(chatID, cb.Message.MessageID)
+
+	s, err := r.resolveStartLink(ctx, payload)
+	if err == nil {
+		err = r.applyStartLink(ctx, chatID, s)
+	}
+	if err != nil {
+		r.log.Error("start link failed", zap.Error(err), zap.String("payload", payload))
+		r.sendText(chatID, r.tr(chatID, "link.failed"))
+		return
+	}
+	msg := tgbotapi.NewMessage(chatID, r.tr(chatID, "link.done"))
+	msg.ReplyMarkup = mainMenuKeyboard(true)
+	_, _ = r.send(msg)
+}
+
+// applyStartLink saves a link's settings, creating the chat if needed, and
+// turns reminders on.
+func (r *Router) applyStartLink(ctx context.Context, chatID int64, s *linkSettings) error {
+	u, err := r.ensureUser(ctx, chatID)
+	if err != nil {
+		return err
+	}
+	u.IntervalSec = int(s.Interval.Seconds())
+	u.ActiveFromM, u.ActiveToM = s.FromM, s.ToM
+	if s.TZ != "" {
+		u.TZ = s.TZ
+	}
+	u.Message = s.text(r.lang(chatID))
+	u.Enabled, u.DisabledReason = true, ""
+	r.configuredHere(u)
+	next := domain.NextFire(time.Now().UTC(), u)
+	u.NextFireAt = &next
+	if err := r.repo.UpsertUser(ctx, u); err != nil {
+		return err
+	}
+	r.notifySchedule(chatID)
+	return nil
+}
+
+// handleShare replies with a deep link that sets up the chat's current
+// settings: a readable template link when the text is a template's, else
+// a share code.
+func (r *Router) handleShare(ctx context.Context, chatID int64) {
+	u, err := r.repo.GetUser(ctx, chatID)
+	if errors.Is(err, sql.ErrNoRows) {
+		r.sendText(chatID, r.tr(chatID, "share.none"))
+		return
+	}
+	if err != nil {
+		r.log.Error("GetUser failed", zap.Error(err))
+		r.sendText(chatID, r.tr(chatID, "share.failed"))
+		return
+	}
+	s := &linkSettings{
+		Template: templateForMessage(u.Message),
+		Message:  u.Message,
+		Interval: time.Duration(u.IntervalSec) * time.Second,
+		FromM:    u.ActiveFromM,
+		ToM:      u.ActiveToM,
+		TZ:       u.TZ,
+	}
+	payload, ok := s.templatePayload()
+	if !ok {
+		s.Template = ""
+		sh := &domain.Share{
+			IntervalSec: u.IntervalSec,
+			ActiveFromM: u.ActiveFromM,
+			ActiveToM:   u.ActiveToM,
+			TZ:          u.TZ,
+			Message:     u.Message,
+		}
+		sh.Code = shareCode(sh)
+		if err := r.repo.AddShare(ctx, sh); err != nil {
+			r.log.Error("AddShare failed", zap.Error(err))
+			r.sendText(chatID, r.tr(chatID, "share.failed"))
+			return
+		}
+		payload = linkShare + "_" + sh.Code
+	}
+	l := r.lang(chatID)
+	r.sendText(chatID, l.T("share.text", r.startURL(payload), linkSummary(l, s, u.TZ)))
+}
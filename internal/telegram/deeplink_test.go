@@ -0,0 +1,136 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"github.com/ykvlv/notification-bot/internal/domain"
+)
+
+func TestParseStartPayload(t *testing.T) {
+	s, _, err := parseStartPayload("t1_water_1h30m_0930-21_AmericaNewYork")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	want := linkSettings{Template: "water", Interval: 90 * time.Minute, FromM: 9*60 + 30, ToM: 21 * 60, TZ: "America/New_York"}
+	if *s != want {
+		t.Fatalf("got %+v, want %+v", *s, want)
+	}
+
+	// Omitted tokens take the template's values.
+	s, _, err = parseStartPayload("t1_eyes")
+	if err != nil || s.Interval != 20*time.Minute || s.FromM != 9*60 || s.TZ != "" {
+		t.Fatalf("defaults: %+v, %v", s, err)
+	}
+
+	// Links shared before versioning still work.
+	if old, _, err := parseStartPayload("tpl_water_1h30m_0930-21_AmericaNewYork"); err != nil || *old != want {
+		t.Fatalf("unversioned link: %+v, %v", old, err)
+	}
+
+	if _, code, err := parseStartPayload("s1_AbCdEf-_1234"); err != nil || code != "AbCdEf-_1234" {
+		t.Fatalf("share code: %q, %v", code, err)
+	}
+
+	for _, p := range []string{
+		"",
+		"t1",
+		"t1_coffee",                           // unknown template
+		"t1_water_5m",                         // interval too short
+		"t1_water_1h_2h",                      // interval twice
+		"t1_water_25-21",                      // bad hours
+		"t1_water_MarsOlympus",                // unknown zone
+		"t1_water_1h!",                        // bad characters
+		"s1_short",                            // bad code
+		"s2_AbCdEf-_1234",                     // unknown version
+		"t1_water_" + strings.Repeat("1", 60), // too long
+	} {
+		if _, _, err := parseStartPayload(p); !errors.Is(err, errBadLink) {
+			t.Errorf("%q: want errBadLink, got %v", p, err)
+		}
+	}
+}
+
+func TestTemplatePayloadRoundTrip(t *testing.T) {
+	cases := []struct {
+		s    linkSettings
+		want string
+	}{
+		{linkSettings{Template: "water", Interval: time.Hour, FromM: 9 * 60, ToM: 21 * 60}, "t1_water"},
+		{linkSettings{Template: "water", Interval: 2 * time.Hour, FromM: 8 * 60, ToM: 22*60 + 30, TZ: "Europe/Berlin"},
+			"t1_water_2h_0800-2230_EuropeBerlin"},
+		{linkSettings{Template: "walk", Interval: 3 * time.Hour, FromM: 10 * 60, ToM: 20 * 60, TZ: "UTC"}, "t1_walk_UTC"},
+	}
+	for _, c := range cases {
+		p, ok := c.s.templatePayload()
+		if !ok || p != c.want {
+			t.Errorf("%+v: got %q, %v; want %q", c.s, p, ok, c.want)
+			continue
+		}
+		back, _, err := parseStartPayload(p)
+		if err != nil || *back != c.s {
+			t.Errorf("%q decodes to %+v, %v", p, back, err)
+		}
+	}
+
+	// Zones that do not survive encoding need a share code.
+	s := linkSettings{Template: "water", Interval: time.Hour, FromM: 9 * 60, ToM: 21 * 60, TZ: "Etc/GMT+3"}
+	if p, ok := s.templatePayload(); ok {
+		t.Fatalf("Etc/GMT+3 encoded as %q", p)
+	}
+}
+
+func TestShareCode(t *testing.T) {
+	a := &domain.Share{IntervalSec: 3600, ActiveFromM: 540, ActiveToM: 1260, TZ: "UTC", Message: "hi"}
+	b := *a
+	if shareCode(a) != shareCode(&b) || len(shareCode(a)) != shareCodeLen {
+		t.Fatalf("code %q is not stable or has the wrong length", shareCode(a))
+	}
+	b.Message = "hello"
+	if shareCode(a) == shareCode(&b) {
+		t.Fatal("different settings share a code")
+	}
+	if _, code, err := parseStartPayload(linkShare + "_" + shareCode(a)); err != nil || code != shareCode(a) {
+		t.Fatalf("code does not parse: %v", err)
+	}
+}
+
+func TestStartLinkConfirm(t *testing.T) {
+	bot, stub := newStubBot(t)
+	stub.results = map[string]string{"sendMessage": `{"message_id":5,"chat":{"id":7}}`}
+	repo := &convRepo{convs: map[pendingKey]domain.Conversation{}}
+	r := NewRouter(bot, zap.NewNop(), repo, nil)
+	ctx := context.Background()
+	from := &tgbotapi.User{ID: 7, LanguageCode: "en"}
+	r.setLang(ctx, 7, from)
+
+	r.handleStart(ctx, 7, from, "t1_water_EuropeBerlin")
+	c := stub.last()
+	if c.method != "sendMessage" || !strings.Contains(c.params["text"], "Europe/Berlin") ||
+		!strings.Contains(c.params["reply_markup"], `"start_link"`) {
+		t.Fatalf("unexpected confirmation %+v", c)
+	}
+	if state, _ := r.getPending(ctx, 7, 7); state != startLinkState(5, "t1_water_EuropeBerlin") {
+		t.Fatalf("pending %q", state)
+	}
+
+	// Someone else's button press has nothing to confirm.
+	r.handleStartLinkCallback(ctx, &tgbotapi.CallbackQuery{
+		ID: "cb", From: &tgbotapi.User{ID: 8},
+		Message: &tgbotapi.Message{MessageID: 5, Chat: &tgbotapi.Chat{ID: 7}},
+	})
+	if c := stub.last(); c.method != "answerCallbackQuery" || c.params["text"] != r.tr(7, "link.stale") {
+		t.Fatalf("unexpected answer %+v", c)
+	}
+
+	r.handleStart(ctx, 7, from, "t1_coffee")
+	if c := stub.last(); c.params["text"] != r.tr(7, "link.bad") {
+		t.Fatalf("bad link answered with %+v", c)
+	}
+}
@@ -0,0 +1,119 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"github.com/ykvlv/notification-bot/internal/domain"
+	"github.com/ykvlv/notification-bot/internal/i18n"
+	"github.com/ykvlv/notification-bot/internal/scheduler"
+	"github.com/ykvlv/notification-bot/internal/store"
+)
+
+// SendReminders sends one or more due reminders to a chat as a single
+// message with a Done button per reminder, in the topic they were set up in. This makes Router satisfy
+// scheduler.Sender; API errors are classified so the scheduler can disable,
+// back off or migrate instead of retrying.
+func (r *Router) SendReminders(chatID int64, items []scheduler.Reminder) (int, error) {
+	l := chatLang(context.Background(), r.repo, chatID)
+	msg := tgbotapi.NewMessage(chatID, reminderText(l, items))
+	msg.ReplyMarkup = ackKeyboard(l, items)
+	sent, err := sendInTopic(r.bot, msg, r.reminderTopic(chatID))
+	if err != nil {
+		return 0, classifySendError(err)
+	}
+	return sent.MessageID, nil
+}
+
+// reminderText renders a single reminder as is and several as a numbered digest.
+func reminderText(l i18n.Lang, items []scheduler.Reminder) string {
+	if len(items) == 1 {
+		return items[0].Text
+	}
+	var b strings.Builder
+	b.WriteString(l.N("digest.title", len(items)))
+	for i, it := range items {
+		fmt.Fprintf(&b, "\n%d. %s", i+1, it.Text)
+	}
+	return b.String()
+}
+
+// handleAckCallback marks one reminder as done and removes its button from
+// the message, leaving the other items of a digest untouched.
+func (r *Router) handleAckCallback(ctx context.Context, cb *tgbotapi.CallbackQuery) {
+	id, err := strconv.ParseInt(strings.TrimPrefix(cb.Data, "ack:"), 10, 64)
+	if err != nil {
+		_ = r.answerCallback(cb.ID, "")
+		return
+	}
+	chatID := cb.Message.Chat.ID
+	err = r.repo.AckDelivery(ctx, id, chatID, time.Now().UTC())
+	switch {
+	case errors.Is(err, store.ErrConflict):
+		_ = r.answerCallback(cb.ID, r.tr(chatID, "ack.already"))
+	case err != nil:
+		r.log.Error("AckDelivery failed", zap.Error(err), zap.Int64("deliveryID", id))
+		_ = r.answerCallback(cb.ID, r.tr(chatID, "ack.failed"))
+		return
+	default:
+		_ = r.answerCallback(cb.ID, r.tr(chatID, "ack.done"))
+	}
+
+	if cb.Message.ReplyMarkup == nil {
+		return
+	}
+	kb := withoutButton(*cb.Message.ReplyMarkup, cb.Data)
+	_, _ = r.bot.Request(tgbotapi.NewEditMessageReplyMarkup(chatID, cb.Message.MessageID, kb))
+}
+
+// withoutButton returns kb with every button carrying data removed; empty
+// rows are dropped.
+func withoutButton(kb tgbotapi.InlineKeyboardMarkup, data string) tgbotapi.InlineKeyboardMarkup {
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(kb.InlineKeyboard))
+	for _, row := range kb.InlineKeyboard {
+		var kept []tgbotapi.InlineKeyboardButton
+		for _, btn := range row {
+			if btn.CallbackData == nil || *btn.CallbackData != data {
+				kept = append(kept, btn)
+			}
+		}
+		if len(kept) > 0 {
+			rows = append(rows, kept)
+		}
+	}
+	return tgbotapi.InlineKeyboardMarkup{InlineKeyboard: rows}
+}
+
+// --- Digest flow ---
+
+// maxDigestWindow bounds how long a reminder can be held back for a digest.
+const maxDigestWindow = 5 * time.Minute
+
+// parseDigestWindow reads "off" or a window up to maxDigestWindow.
+func parseDigestWindow(s string) (string, error) {
+	if s == "off" {
+		return "0s", nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 || d > maxDigestWindow {
+		return "", domain.ErrInvalidDuration
+	}
+	return d.String(), nil
+}
+
+func (r *Router) updateDigest(ctx context.Context, chatID int64, d time.Duration) error {
+	u, err := r.ensureUser(ctx, chatID)
+	if err != nil {
+		return err
+	}
+	u.DigestSec = int(d.Seconds())
+	r.configuredHere(u)
+	return r.repo.UpsertUser(ctx, u)
+}
@@ -0,0 +1,110 @@
+package telegram
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"github.com/ykvlv/notification-bot/internal/domain"
+)
+
+// pendingEmailCode prefixes the state of an address awaiting its
+// confirmation code: "await_email_code:<wrong tries>:<code>:<address>".
+const pendingEmailCode = "await_email_code:"
+
+// emailCodeTries is how many wrong codes end a confirmation.
+const emailCodeTries = 3
+
+// Mailer sends a plain-text email. notify.SMTP implements it.
+type Mailer interface {
+	Mail(ctx context.Context, to, subject, body string) error
+}
+
+// SetMailer enables the email channel: addresses are confirmed with a code
+// mailed through m. Without a mailer "/channel email" is refused.
+// Must be called before the first update is handled.
+func (r *Router) SetMailer(m Mailer) {
+	r.mailer = m
+}
+
+// handleEmailChannel mails a confirmation code to address and waits for the
+// member to send it back. The channel changes only then, so nobody can point
+// reminders at a mailbox they do not read.
+func (r *Router) handleEmailChannel(ctx context.Context, chatID int64, from *tgbotapi.User, address string) {
+	if r.mailer == nil {
+		r.sendText(chatID, r.tr(chatID, "email.unavailable"))
+		return
+	}
+	code, err := newEmailCode()
+	if err != nil {
+		r.log.Error("confirmation code failed", zap.Error(err))
+		r.sendText(chatID, r.tr(chatID, "channel.failed"))
+		return
+	}
+	l := r.lang(chatID)
+	if err := r.mailer.Mail(ctx, address, l.T("email.code_subject"), l.T("email.code_body", code)); err != nil {
+		r.log.Warn("confirmation mail failed", zap.Int64("chatID", chatID), zap.Error(err))
+		r.sendText(chatID, r.tr(chatID, "email.send_failed", address))
+		return
+	}
+	r.setPending(ctx, chatID, userID(from), emailCodeState(0, code, address))
+	r.prompt(chatID, from, r.tr(chatID, "email.code_prompt", address))
+}
+
+// handleEmailCode checks a code sent in reply to the confirmation prompt
+// and switches the chat to the address once it matches. The confirmation
+// ends after emailCodeTries wrong codes.
+func (r *Router) handleEmailCode(ctx context.Context, chatID int64, from *tgbotapi.User, state, text string) {
+	uid := userID(from)
+	tries, code, address, ok := parseEmailCodeState(state)
+	if !ok {
+		r.clearPending(ctx, chatID, uid)
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(strings.TrimSpace(text)), []byte(code)) != 1 {
+		if tries+1 >= emailCodeTries {
+			r.clearPending(ctx, chatID, uid)
+			r.sendText(chatID, r.tr(chatID, "email.code_exhausted"))
+			return
+		}
+		r.setPending(ctx, chatID, uid, emailCodeState(tries+1, code, address))
+		r.sendText(chatID, r.tr(chatID, "email.code_wrong"))
+		return
+	}
+	r.clearPending(ctx, chatID, uid)
+	r.setChannel(ctx, chatID, domain.ChannelEmail, address)
+}
+
+// newEmailCode returns a random six-digit code.
+func newEmailCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1_000_000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+func emailCodeState(tries int, code, address string) string {
+	return pendingEmailCode + strconv.Itoa(tries) + ":" + code + ":" + address
+}
+
+func parseEmailCodeState(s string) (tries int, code, address string, ok bool) {
+	rest, ok := strings.CutPrefix(s, pendingEmailCode)
+	if !ok {
+		return 0, "", "", false
+	}
+	n, rest, _ := strings.Cut(rest, ":")
+	code, address, ok = strings.Cut(rest, ":")
+	tries, err := strconv.Atoi(n)
+	if !ok || err != nil || code == "" || address == "" {
+		return 0, "", "", false
+	}
+	return tries, code, address, true
+}
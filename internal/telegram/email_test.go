@@ -0,0 +1,91 @@
+package telegram
+
+import (
+	"context"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"github.com/ykvlv/notification-bot/internal/domain"
+	"github.com/ykvlv/notification-bot/internal/i18n"
+)
+
+// channelRepo is a convRepo that also records SetChannel.
+type channelRepo struct {
+	*convRepo
+	set []domain.Channel
+}
+
+func (c *channelRepo) SetChannel(_ context.Context, ch *domain.Channel) error {
+	c.set = append(c.set, *ch)
+	return nil
+}
+
+// mailbox is a Mailer keeping the mail sent.
+type mailbox struct {
+	to, subject, body []string
+}
+
+func (m *mailbox) Mail(_ context.Context, to, subject, body string) error {
+	m.to, m.subject, m.body = append(m.to, to), append(m.subject, subject), append(m.body, body)
+	return nil
+}
+
+func TestEmailChannel_RequiresCode(t *testing.T) {
+	bot, stub := newStubBot(t)
+	stub.results = map[string]string{"sendMessage": `{"message_id":1,"chat":{"id":7}}`}
+	repo := &channelRepo{convRepo: &convRepo{
+		convs: map[pendingKey]domain.Conversation{},
+		user:  &domain.User{ChatID: 7, Language: "en"},
+	}}
+	r := NewRouter(bot, zap.NewNop(), repo, nil)
+	ctx := context.Background()
+	user := &tgbotapi.User{ID: 7, LanguageCode: "en"}
+	say := func(text string) string {
+		r.HandleUpdate(ctx, Update{Update: tgbotapi.Update{Message: &tgbotapi.Message{
+			MessageID: 2, From: user, Chat: &tgbotapi.Chat{ID: 7, Type: "private"}, Text: text,
+		}}})
+		return stub.last().params["text"]
+	}
+
+	if got := say("/channel email a@example.com"); got != i18n.En.T("email.unavailable") {
+		t.Fatalf("without a mailer: %q", got)
+	}
+
+	mail := &mailbox{}
+	r.SetMailer(mail)
+	say("/channel email a@example.com")
+	if len(mail.to) != 1 || mail.to[0] != "a@example.com" || mail.subject[0] != i18n.En.T("email.code_subject") {
+		t.Fatalf("confirmation mail: %+v", mail)
+	}
+	_, code, _, _ := parseEmailCodeState(repo.convs[pendingKey{7, 7}].State)
+	if code == "" || mail.body[0] != i18n.En.T("email.code_body", code) {
+		t.Fatalf("code %q not in mail %q", code, mail.body[0])
+	}
+	if len(repo.set) != 0 {
+		t.Fatal("channel set before confirmation")
+	}
+
+	if got := say("000000x"); got != i18n.En.T("email.code_wrong") {
+		t.Fatalf("wrong code: %q", got)
+	}
+	say(code)
+	if len(repo.set) != 1 || repo.set[0].Kind != domain.ChannelEmail || repo.set[0].Target != "a@example.com" {
+		t.Fatalf("channel after confirmation: %+v", repo.set)
+	}
+
+	// Wrong codes end the confirmation.
+	say("/channel email b@example.com")
+	_, code, _, _ = parseEmailCodeState(repo.convs[pendingKey{7, 7}].State)
+	for range emailCodeTries {
+		say("x")
+	}
+	if got := stub.last().params["text"]; got != i18n.En.T("email.code_exhausted") {
+		t.Fatalf("after %d wrong codes: %q", emailCodeTries, got)
+	}
+	say(code)
+	if len(repo.set) != 1 {
+		t.Fatalf("channel set after the confirmation ended: %+v", repo.set)
+	}
+}
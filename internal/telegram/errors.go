@@ -0,0 +1,36 @@
+package telegram
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/ykvlv/notification-bot/internal/scheduler"
+)
+
+// classifySendError maps Bot API failures onto the scheduler's error types:
+//   - 403 (bot blocked, user deactivated, bot kicked) → BlockedError
+//   - 400 "chat not found" → BlockedError
+//   - 429 with retry_after → RetryAfterError
+//   - migrate_to_chat_id set → ChatMigratedError
+//
+// Anything else (network errors, 5xx) is returned unchanged and retried.
+func classifySendError(err error) error {
+	var apiErr *tgbotapi.Error
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+	switch {
+	case apiErr.MigrateToChatID != 0:
+		return &scheduler.ChatMigratedError{NewChatID: apiErr.MigrateToChatID}
+	case apiErr.Code == 429 && apiErr.RetryAfter > 0:
+		return &scheduler.RetryAfterError{After: time.Duration(apiErr.RetryAfter) * time.Second}
+	case apiErr.Code == 403:
+		return &scheduler.BlockedError{Reason: apiErr.Message}
+	case apiErr.Code == 400 && strings.Contains(strings.ToLower(apiErr.Message), "chat not found"):
+		return &scheduler.BlockedError{Reason: apiErr.Message}
+	}
+	return err
+}
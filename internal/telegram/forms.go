@@ -0,0 +1,183 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ykvlv/notification-bot/internal/domain"
+	"github.com/ykvlv/notification-bot/internal/i18n"
+)
+
+// maxMessageLen bounds the reminder text.
+const maxMessageLen = 512
+
+var errMessageLength = errors.New("message must be 1-512 characters")
+
+// settingsForms are the flows behind the settings menu; see form. Texts
+// are i18n catalog keys.
+var settingsForms = []*form{
+	{
+		ID: "interval",
+		Steps: []step{{
+			Key:    "interval",
+			Prompt: "interval.prompt",
+			Presets: [][]preset{
+				{{"30m", "30m"}, {"1h", "1h"}, {"2h", "2h"}, {"3h", "3h"}},
+				{{"4h", "4h"}, {"6h", "6h"}, {"8h", "8h"}},
+				{{"12h", "12h"}, {"24h", "24h"}},
+			},
+			Custom: "interval.custom",
+			Parse: func(s string) (string, error) {
+				d, err := domain.ParseDurationHuman(s)
+				return d.String(), err
+			},
+			Invalid: durationErrorText,
+			Current: func(u *domain.User) string {
+				return (time.Duration(u.IntervalSec) * time.Second).String()
+			},
+			Format: formatDuration,
+		}},
+		Apply: func(ctx context.Context, r *Router, chatID int64, a url.Values) (string, error) {
+			d, _ := time.ParseDuration(a.Get("interval"))
+			return r.tr(chatID, "interval.updated", r.lang(chatID).Duration(d)), r.updateInterval(ctx, chatID, d)
+		},
+		Failed: "interval.failed",
+	},
+	{
+		ID: "hours",
+		Steps: []step{{
+			Key:    "hours",
+			Prompt: "hours.prompt",
+			Presets: [][]preset{
+				{{"08:00–22:00", "08:00-22:00"}, {"09:00–21:00", "09:00-21:00"}},
+				{{"22:00–02:00", "22:00-02:00"}},
+			},
+			Custom: "hours.custom",
+			Parse: func(s string) (string, error) {
+				fromM, toM, err := domain.ParseActiveWindow(s)
+				return domain.FormatMinutes(fromM) + "-" + domain.FormatMinutes(toM), err
+			},
+			Invalid: func(error) string { return "hours.invalid" },
+			Current: func(u *domain.User) string {
+				return domain.FormatMinutes(u.ActiveFromM) + "-" + domain.FormatMinutes(u.ActiveToM)
+			},
+		}},
+		Apply: func(ctx context.Context, r *Router, chatID int64, a url.Values) (string, error) {
+			fromM, toM, _ := domain.ParseActiveWindow(a.Get("hours"))
+			reply := r.tr(chatID, "hours.updated", domain.FormatMinutes(fromM), domain.FormatMinutes(toM))
+			return reply, r.updateHours(ctx, chatID, fromM, toM)
+		},
+		Failed: "hours.failed",
+	},
+	{
+		ID: "tz",
+		Steps: []step{{
+			Key:    "tz",
+			Prompt: "tz.prompt",
+			Presets: [][]preset{
+				{{"Europe/Moscow", "Europe/Moscow"}, {"Europe/Tallinn", "Europe/Tallinn"}},
+				{{"Asia/Almaty", "Asia/Almaty"}, {"UTC", "UTC"}},
+			},
+			Custom:  "tz.custom",
+			Parse:   domain.ValidateTZ,
+			Invalid: func(error) string { return "tz.invalid" },
+			Current: func(u *domain.User) string { return u.TZ },
+		}},
+		Apply: func(ctx context.Context, r *Router, chatID int64, a url.Values) (string, error) {
+			tz := a.Get("tz")
+			return r.tr(chatID, "tz.updated", tz), r.updateTZ(ctx, chatID, tz)
+		},
+		Failed: "tz.failed",
+	},
+	{
+		ID: "message",
+		Steps: []step{{
+			Key:    "message",
+			Prompt: "message.prompt",
+			Parse: func(s string) (string, error) {
+				if s == "" || utf8.RuneCountInString(s) > maxMessageLen {
+					return "", errMessageLength
+				}
+				return s, nil
+			},
+			Invalid: func(error) string { return "message.invalid" },
+			Current: func(u *domain.User) string { return u.Message },
+		}},
+		Confirm: true,
+		Summary: func(l i18n.Lang, a url.Values) string {
+			return l.T("message.summary", a.Get("message"))
+		},
+		Apply: func(ctx context.Context, r *Router, chatID int64, a url.Values) (string, error) {
+			return r.tr(chatID, "message.updated"), r.updateMessage(ctx, chatID, a.Get("message"))
+		},
+		Failed: "message.failed",
+	},
+	{
+		ID: "digest",
+		Steps: []step{{
+			Key:    "digest",
+			Prompt: "digest.prompt",
+			Presets: [][]preset{
+				{{"preset.off", "off"}, {"30s", "30s"}, {"1m", "1m"}, {"5m", "5m"}},
+			},
+			Parse:   parseDigestWindow,
+			Invalid: func(error) string { return "digest.invalid" },
+			Current: func(u *domain.User) string { return u.DigestWindow().String() },
+			Format:  formatDuration,
+		}},
+		Apply: func(ctx context.Context, r *Router, chatID int64, a url.Values) (string, error) {
+			d, _ := time.ParseDuration(a.Get("digest"))
+			reply := r.tr(chatID, "digest.updated", r.lang(chatID).Duration(d))
+			if d == 0 {
+				reply = r.tr(chatID, "digest.off")
+			}
+			return reply, r.updateDigest(ctx, chatID, d)
+		},
+		Failed: "digest.failed",
+	},
+}
+
+// settingsFormIDs maps the settings menu buttons to their forms.
+var settingsFormIDs = map[string]string{
+	"set_interval": "interval",
+	"set_hours":    "hours",
+	"set_tz":       "tz",
+	"set_msg":      "message",
+	"set_digest":   "digest",
+}
+
+// formByID returns the settings form with the given ID, or nil.
+func formByID(id string) *form {
+	for _, f := range settingsForms {
+		if f.ID == id {
+			return f
+		}
+	}
+	return nil
+}
+
+// durationErrorText explains an interval parse error.
+func durationErrorText(err error) string {
+	switch {
+	case errors.Is(err, domain.ErrTooSmall):
+		return "duration.short"
+	case errors.Is(err, domain.ErrTooLarge):
+		return "duration.long"
+	case errors.Is(err, domain.ErrEmptyDuration), errors.Is(err, domain.ErrInvalidDuration):
+		return "duration.invalid"
+	default:
+		return "duration.failed"
+	}
+}
+
+// formatDuration spells out a canonical duration value.
+func formatDuration(l i18n.Lang, value string) string {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return value
+	}
+	return l.Duration(d)
+}
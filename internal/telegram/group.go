@@ -0,0 +1,131 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// adminCacheTTL is how long a getChatMember answer is trusted.
+const adminCacheTTL = time.Minute
+
+// pendingKey identifies one member's conversation in a chat. In private
+// chats chatID and userID are the same person.
+type pendingKey struct {
+	chatID int64
+	userID int64
+}
+
+type adminEntry struct {
+	ok bool
+	at time.Time
+}
+
+// isGroupChat reports whether chatID belongs to a group or supergroup;
+// Telegram uses negative IDs for those and positive ones for users.
+func isGroupChat(chatID int64) bool {
+	return chatID < 0
+}
+
+// userID returns the sender's ID, or 0 for messages without one.
+func userID(from *tgbotapi.User) int64 {
+	if from == nil {
+		return 0
+	}
+	return from.ID
+}
+
+// parseCommand splits "/cmd@bot args" into its lower-cased name and
+// arguments. ok is false when text is not a command or is addressed to
+// another bot.
+func parseCommand(text, botUsername string) (cmd, args string, ok bool) {
+	if !strings.HasPrefix(text, "/") {
+		return "", "", false
+	}
+	head, args, _ := strings.Cut(text, " ")
+	head, addressee, hasAt := strings.Cut(head[1:], "@")
+	if hasAt && !strings.EqualFold(addressee, botUsername) {
+		return "", "", false
+	}
+	if head == "" {
+		return "", "", false
+	}
+	return strings.ToLower(head), strings.TrimSpace(args), true
+}
+
+// isSettingsCallback is isSettingsCommand for inline button data.
+func isSettingsCallback(data string) bool {
+	return settingsFormIDs[data] != "" || strings.HasPrefix(data, "f:") || strings.HasPrefix(data, "lang:") ||
+		data == "start_link" || data == "cancel"
+}
+
+// canConfigure reports whether the sender may change the chat's settings:
+// anyone in a private chat, otherwise chat admins and the creator. Posts
+// by an anonymous admin arrive with the group itself as sender chat.
+func (r *Router) canConfigure(ctx context.Context, chat *tgbotapi.Chat, from *tgbotapi.User, senderChat *tgbotapi.Chat) bool {
+	if chat == nil || chat.IsPrivate() {
+		return true
+	}
+	if senderChat != nil && senderChat.ID == chat.ID {
+		return true
+	}
+	if from == nil {
+		return false
+	}
+
+	key := pendingKey{chatID: chat.ID, userID: from.ID}
+	r.mu.RLock()
+	e, hit := r.adminCache[key]
+	r.mu.RUnlock()
+	if hit && time.Since(e.at) < adminCacheTTL {
+		return e.ok
+	}
+
+	member, err := r.bot.GetChatMember(tgbotapi.GetChatMemberConfig{
+		ChatConfigWithUser: tgbotapi.ChatConfigWithUser{ChatID: chat.ID, UserID: from.ID},
+	})
+	if err != nil {
+		r.log.Warn("getChatMember failed", zap.Error(err),
+			zap.Int64("chatID", chat.ID), zap.Int64("userID", from.ID))
+		return false
+	}
+	ok := member.IsCreator() || member.IsAdministrator()
+
+	r.mu.Lock()
+	r.adminCache[key] = adminEntry{ok: ok, at: time.Now()}
+	r.mu.Unlock()
+	return ok
+}
+
+// prompt asks a member for free-form input, with a Cancel button. In
+// groups the question mentions the member and forces a selective reply
+// instead, so that only they get the reply box and the answer reaches the
+// bot even in privacy mode; /cancel replaces the button there.
+func (r *Router) prompt(chatID int64, from *tgbotapi.User, text string) {
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ReplyMarkup = cancelKeyboard(r.lang(chatID))
+	if isGroupChat(chatID) && from != nil {
+		name := from.FirstName
+		if name == "" {
+			name = from.UserName
+		}
+		msg.Text = name + ", " + text
+		// In privacy mode the bot only sees replies to itself.
+		if !r.bot.Self.CanReadAllGroupMessages {
+			msg.Text += r.tr(chatID, "group.reply_hint")
+		}
+		msg.Text += r.tr(chatID, "cancel.hint")
+		msg.Entities = []tgbotapi.MessageEntity{{
+			Type:   "text_mention",
+			Offset: 0,
+			Length: len(utf16.Encode([]rune(name))),
+			User:   from,
+		}}
+		msg.ReplyMarkup = tgbotapi.ForceReply{ForceReply: true, Selective: true}
+	}
+	_, _ = r.send(msg)
+}
@@ -0,0 +1,189 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"github.com/ykvlv/notification-bot/internal/domain"
+)
+
+func TestParseCommand(t *testing.T) {
+	cases := []struct {
+		text      string
+		cmd, args string
+		ok        bool
+	}{
+		{"/status", "status", "", true},
+		{"/status@test_bot", "status", "", true},
+		{"/Status@Test_Bot", "status", "", true},
+		{"/replay@test_bot  42 ", "replay", "42", true},
+		{"/channel email a@b.c", "channel", "email a@b.c", true},
+		{"/status@other_bot", "", "", false},
+		{"/", "", "", false},
+		{"hello", "", "", false},
+	}
+	for _, c := range cases {
+		cmd, args, ok := parseCommand(c.text, "test_bot")
+		if cmd != c.cmd || args != c.args || ok != c.ok {
+			t.Errorf("parseCommand(%q) = %q, %q, %v; want %q, %q, %v",
+				c.text, cmd, args, ok, c.cmd, c.args, c.ok)
+		}
+	}
+}
+
+func TestCanConfigure(t *testing.T) {
+	bot, stub := newStubBot(t)
+	r := NewRouter(bot, zap.NewNop(), nil, nil)
+	ctx := context.Background()
+	group := &tgbotapi.Chat{ID: -100, Type: "supergroup"}
+	user := &tgbotapi.User{ID: 7}
+
+	if !r.canConfigure(ctx, &tgbotapi.Chat{ID: 7, Type: "private"}, user, nil) {
+		t.Error("private chat: want allowed")
+	}
+	if !r.canConfigure(ctx, group, nil, group) {
+		t.Error("anonymous admin: want allowed")
+	}
+
+	stub.results = map[string]string{"getChatMember": `{"status":"member","user":{"id":7}}`}
+	if r.canConfigure(ctx, group, user, nil) {
+		t.Error("member: want denied")
+	}
+	if got := stub.last().params["user_id"]; got != "7" {
+		t.Errorf("getChatMember user_id = %q, want 7", got)
+	}
+
+	// The answer is cached: promotion is seen only after adminCacheTTL.
+	stub.results["getChatMember"] = `{"status":"administrator","user":{"id":7}}`
+	if r.canConfigure(ctx, group, user, nil) {
+		t.Error("cached member: want denied")
+	}
+	admin := &tgbotapi.User{ID: 8}
+	if !r.canConfigure(ctx, group, admin, nil) {
+		t.Error("administrator: want allowed")
+	}
+}
+
+func TestPrompt(t *testing.T) {
+	bot, stub := newStubBot(t)
+	r := NewRouter(bot, zap.NewNop(), nil, nil)
+	user := &tgbotapi.User{ID: 7, FirstName: "Ann"}
+
+	r.prompt(7, user, "Enter timezone:")
+	c := stub.last()
+	if c.params["text"] != "Enter timezone:" || !strings.Contains(c.params["reply_markup"], `"callback_data":"cancel"`) {
+		t.Fatalf("private prompt: %+v", c)
+	}
+
+	r.prompt(-100, user, "Enter timezone:")
+	c = stub.last()
+	if !strings.HasPrefix(c.params["text"], "Ann, Enter timezone:") || !strings.HasSuffix(c.params["text"], r.tr(-100, "cancel.hint")) ||
+		c.params["reply_markup"] != `{"force_reply":true,"selective":true}` {
+		t.Fatalf("group prompt: %+v", c)
+	}
+}
+
+func TestWebhookSecretNotShownInGroups(t *testing.T) {
+	bot, stub := newStubBot(t)
+	stub.results = map[string]string{"sendMessage": `{"message_id":1,"chat":{"id":-100}}`}
+	// A nil repo: touching the webhook would panic.
+	r := NewRouter(bot, zap.NewNop(), nil, nil)
+
+	for _, arg := range []string{"set https://example.com/hook", "rotate"} {
+		r.handleWebhooks(context.Background(), -100, arg)
+		if c := stub.last(); c.method != "sendMessage" || c.params["text"] != r.tr(-100, "webhooks.private_only") {
+			t.Fatalf("%q in a group answered %+v", arg, c)
+		}
+	}
+}
+
+func TestCancelOnlyOwnMenu(t *testing.T) {
+	bot, stub := newStubBot(t)
+	stub.results = map[string]string{
+		"sendMessage":   `{"message_id":1,"chat":{"id":-100}}`,
+		"getChatMember": `{"status":"administrator","user":{"id":7}}`,
+	}
+	repo := &convRepo{convs: map[pendingKey]domain.Conversation{}, user: &domain.User{ChatID: -100, TZ: "UTC"}}
+	r := NewRouter(bot, zap.NewNop(), repo, nil)
+	ctx := context.Background()
+	chat := &tgbotapi.Chat{ID: -100, Type: "supergroup"}
+	owner, other := &tgbotapi.User{ID: 7}, &tgbotapi.User{ID: 8}
+	tap := func(from *tgbotapi.User) {
+		r.HandleUpdate(ctx, Update{Update: tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+			ID: "q", From: from, Data: "cancel", Message: &tgbotapi.Message{MessageID: 1, Chat: chat},
+		}}})
+	}
+
+	r.handleSettings(ctx, -100, owner)
+	if _, ok := r.menuState(ctx, -100, owner, 1); !ok {
+		t.Fatal("menu not opened")
+	}
+
+	tap(other)
+	if c := stub.last(); c.method != "answerCallbackQuery" || c.params["text"] != r.tr(-100, "menu.stale") {
+		t.Fatalf("another member's cancel answered %+v", c)
+	}
+	if _, ok := r.menuState(ctx, -100, owner, 1); !ok {
+		t.Fatal("another member cancelled the owner's menu")
+	}
+
+	tap(owner)
+	if c := stub.last(); c.method != "editMessageText" || c.params["text"] != r.tr(-100, "cancel.done") {
+		t.Fatalf("owner's cancel answered %+v", c)
+	}
+	if state, _ := r.getPending(ctx, -100, 7); state != "" {
+		t.Fatalf("pending %q after cancel", state)
+	}
+}
+
+func TestStatusDoesNotSetUpGroup(t *testing.T) {
+	bot, stub := newStubBot(t)
+	stub.results = map[string]string{
+		"sendMessage":   `{"message_id":1,"chat":{"id":-100}}`,
+		"getChatMember": `{"status":"member","user":{"id":8}}`,
+	}
+	// No user and no UpsertUser: creating the chat would panic.
+	repo := &convRepo{convs: map[pendingKey]domain.Conversation{}}
+	r := NewRouter(bot, zap.NewNop(), repo, nil)
+
+	r.HandleUpdate(context.Background(), Update{Update: tgbotapi.Update{Message: &tgbotapi.Message{
+		MessageID: 5, Text: "/status", From: &tgbotapi.User{ID: 8},
+		Chat:     &tgbotapi.Chat{ID: -100, Type: "supergroup"},
+		Entities: []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: 7}},
+	}}})
+	if c := stub.last(); c.method != "sendMessage" || c.params["text"] != r.tr(-100, "status.none") {
+		t.Fatalf("/status in a new group answered %+v", c)
+	}
+}
+
+func TestCancelStartLinkInGroup(t *testing.T) {
+	bot, stub := newStubBot(t)
+	stub.results = map[string]string{"sendMessage": `{"message_id":5,"chat":{"id":-100}}`}
+	repo := &convRepo{convs: map[pendingKey]domain.Conversation{}}
+	r := NewRouter(bot, zap.NewNop(), repo, nil)
+	ctx := context.Background()
+	chat := &tgbotapi.Chat{ID: -100, Type: "supergroup"}
+	owner, other := &tgbotapi.User{ID: 7}, &tgbotapi.User{ID: 8}
+	tap := func(from *tgbotapi.User) {
+		r.handleCancelCallback(ctx, &tgbotapi.CallbackQuery{
+			ID: "q", From: from, Data: "cancel", Message: &tgbotapi.Message{MessageID: 5, Chat: chat},
+		})
+	}
+
+	r.handleStart(ctx, -100, owner, "t1_water")
+	tap(other)
+	if c := stub.last(); c.method != "answerCallbackQuery" || c.params["text"] != r.tr(-100, "menu.stale") {
+		t.Fatalf("another member's cancel answered %+v", c)
+	}
+	tap(owner)
+	if c := stub.last(); c.method != "editMessageText" || c.params["text"] != r.tr(-100, "cancel.done") {
+		t.Fatalf("owner's cancel answered %+v", c)
+	}
+	if state, _ := r.getPending(ctx, -100, 7); state != "" {
+		t.Fatalf("pending %q after cancel", state)
+	}
+}
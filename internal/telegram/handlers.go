@@ -2,8 +2,8 @@ package telegram
 
 import (
 	"context"
+	"database/sql"
 	"errors"
-	"fmt"
 	"github.com/ykvlv/notification-bot/assets"
 	"io"
 	"path/filepath"
@@ -41,6 +41,9 @@ func (r *Router) ensureUser(ctx context.Context, chatID int64) (*domain.User, er
 		ActiveToM:   defaultToM,
 		Message:     defaultMessage,
 		CreatedAt:   now,
+		JitterSec:   domain.JitterFor(chatID, r.jitterMax(ctx)),
+		ThreadID:    r.topic(chatID),
+		Language:    string(r.lang(chatID)),
 	}
 	// Compute initial next_fire_at right away
 	next := domain.NextFire(now, u)
@@ -49,13 +52,14 @@ func (r *Router) ensureUser(ctx context.Context, chatID int64) (*domain.User, er
 	if err := r.repo.UpsertUser(ctx, u); err != nil {
 		return nil, err
 	}
+	r.notifySchedule(chatID)
 	return u, nil
 }
 
 // --- Generic helpers ---
 
 func (r *Router) sendText(chatID int64, text string) {
-	_, _ = r.bot.Send(tgbotapi.NewMessage(chatID, text))
+	_, _ = r.send(tgbotapi.NewMessage(chatID, text))
 }
 
 func (r *Router) answerCallback(id, text string) error {
@@ -63,195 +67,184 @@ func (r *Router) answerCallback(id, text string) error {
 	return err
 }
 
-func (r *Router) sendDurationError(chatID int64, err error) {
-	switch {
-	case errors.Is(err, domain.ErrTooSmall):
-		r.sendText(chatID, "Interval is too short. Minimum is 10m.")
-	case errors.Is(err, domain.ErrTooLarge):
-		r.sendText(chatID, "Interval is too long. Maximum is 72h.")
-	case errors.Is(err, domain.ErrEmptyDuration), errors.Is(err, domain.ErrInvalidDuration):
-		r.sendText(chatID, "Invalid interval. Examples: 30m, 1h, 1h30m.")
-	default:
-		r.sendText(chatID, "Failed to parse interval.")
+// edit replaces the text and inline keyboard of a bot message; a nil kb
+// removes the keyboard. Redrawing an unchanged screen is not an error.
+func (r *Router) edit(chatID int64, messageID int, text string, kb *tgbotapi.InlineKeyboardMarkup) error {
+	e := tgbotapi.NewEditMessageText(chatID, messageID, text)
+	e.ReplyMarkup = kb
+	_, err := r.bot.Request(e)
+	if isNotModified(err) {
+		return nil
+	}
+	return err
+}
+
+// retireMenu removes the buttons of a menu that has been replaced; 0 is
+// no menu. The message may be gone already, so errors are ignored.
+func (r *Router) retireMenu(chatID int64, messageID int) {
+	if messageID == 0 {
+		return
 	}
+	_, _ = r.bot.Request(tgbotapi.NewEditMessageReplyMarkup(chatID, messageID,
+		tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}}))
+}
+
+// isNotModified reports the Bot API's answer to an edit that changes
+// nothing.
+func isNotModified(err error) bool {
+	var apiErr *tgbotapi.Error
+	return errors.As(err, &apiErr) && strings.Contains(apiErr.Message, "message is not modified")
 }
 
 // --- Core commands ---
 
-func (r *Router) handleStart(ctx context.Context, chatID int64) {
+// handleStart creates the chat with default settings and shows the menu.
+// A deep link payload ("/start t1_water") sets up its settings instead,
+// after a confirmation; see deeplink.go.
+func (r *Router) handleStart(ctx context.Context, chatID int64, from *tgbotapi.User, payload string) {
+	if payload != "" {
+		r.handleStartLink(ctx, chatID, from, payload)
+		return
+	}
 	u, err := r.ensureUser(ctx, chatID)
 	if err != nil {
 		r.log.Error("ensureUser failed", zap.Error(err))
-		r.sendText(chatID, "Profile initialization error. Please try again later.")
+		r.sendText(chatID, r.tr(chatID, "start.failed"))
 		return
 	}
-	msg := tgbotapi.NewMessage(chatID, startText)
+	// /start in another forum topic moves the reminders there.
+	if thread := r.topic(chatID); u.ThreadID != thread {
+		r.configuredHere(u)
+		if err := r.repo.UpsertUser(ctx, u); err != nil {
+			r.log.Error("save topic failed", zap.Error(err))
+		}
+	}
+	msg := tgbotapi.NewMessage(chatID, r.tr(chatID, "start.text"))
 	msg.ReplyMarkup = mainMenuKeyboard(u.Enabled)
-	_, _ = r.bot.Send(msg)
+	_, _ = r.send(msg)
 }
 
+// handleStatus shows the chat's settings. Anyone in a group may ask, so it
+// only reads them: a chat without settings is told to run /start.
 func (r *Router) handleStatus(ctx context.Context, chatID int64) {
-	u, err := r.ensureUser(ctx, chatID)
+	u, err := r.repo.GetUser(ctx, chatID)
+	if errors.Is(err, sql.ErrNoRows) {
+		r.sendText(chatID, r.tr(chatID, "status.none"))
+		return
+	}
 	if err != nil {
-		r.log.Error("ensureUser failed", zap.Error(err))
-		r.sendText(chatID, "Error reading your settings.")
+		r.log.Error("GetUser failed", zap.Error(err))
+		r.sendText(chatID, r.tr(chatID, "status.failed"))
 		return
 	}
 
-	interval := time.Duration(u.IntervalSec) * time.Second
-	activeFrom := domain.FormatMinutes(u.ActiveFromM)
-	activeTo := domain.FormatMinutes(u.ActiveToM)
-	enabledText := "✅ Enabled"
+	l := r.lang(chatID)
+	enabledText := l.T("status.enabled")
 	if !u.Enabled {
-		enabledText = "⏸ Paused"
+		enabledText = l.T("status.paused")
 	}
 	next := "—"
-	if u.NextFireAt != nil {
-		if s, err := domain.LocalizeTime(*u.NextFireAt, u.TZ); err == nil {
-			next = s
+	if loc, err := time.LoadLocation(u.TZ); err == nil && u.NextFireAt != nil {
+		// With jitter, slots are not on whole minutes: show the exact second.
+		layout := "15:04"
+		if u.JitterSec > 0 {
+			layout = "15:04:05"
 		}
+		at := u.NextFireAt.In(loc)
+		next = l.T("status.next", l.Day(at, time.Now().In(loc)), at.Format(layout))
 	}
 
-	body := fmt.Sprintf("%s\n\n"+statusFmt,
-		statusTitle,
-		interval.String(),
-		activeFrom, activeTo,
+	body := l.T("status.title") + "\n\n" + l.T("status.body",
+		l.Duration(time.Duration(u.IntervalSec)*time.Second),
+		domain.FormatMinutes(u.ActiveFromM), domain.FormatMinutes(u.ActiveToM),
 		u.TZ,
 		enabledText,
 		next,
 		u.Message,
 	)
+	if u.JitterSec > 0 {
+		body += l.T("status.jitter", l.Duration(time.Duration(u.JitterSec)*time.Second))
+	}
+	if u.DigestSec > 0 {
+		body += l.T("status.digest", l.Duration(u.DigestWindow()))
+	}
 
 	msg := tgbotapi.NewMessage(chatID, body)
 	msg.ReplyMarkup = mainMenuKeyboard(u.Enabled)
-	_, _ = r.bot.Send(msg)
+	_, _ = r.send(msg)
 }
 
-func (r *Router) handleSettings(ctx context.Context, chatID int64) {
-	if _, err := r.ensureUser(ctx, chatID); err != nil {
-		r.log.Error("ensureUser failed", zap.Error(err))
-		r.sendText(chatID, "Error opening settings.")
-		return
-	}
-	msg := tgbotapi.NewMessage(chatID, "What do you want to configure?")
-	msg.ReplyMarkup = settingsInlineKeyboard()
-	// Also refresh main keyboard under message:
-	msg.ReplyMarkup = settingsInlineKeyboard()
-	_, _ = r.bot.Send(msg)
-
-	// Optionally, send a separate message with the main menu keyboard refreshed:
-	// reply := tgbotapi.NewMessage(chatID, "Menu updated.")
-	// reply.ReplyMarkup = mainMenuKeyboard(u.Enabled)
-	// _, _ = r.bot.Send(reply)
+// handleSettings sends a fresh settings menu; the previous one, if any,
+// loses its buttons.
+func (r *Router) handleSettings(ctx context.Context, chatID int64, from *tgbotapi.User) {
+	state, _ := r.getPending(ctx, chatID, userID(from))
+	prev, _ := decodeFormState(state)
+	r.showSettingsMenu(ctx, chatID, from, prev.Menu, "", false)
 }
 
-// --- Interval flow ---
-
-func (r *Router) askIntervalPresets(ctx context.Context, chatID int64, cbID string) {
-	_ = r.answerCallback(cbID, "")
-	msg := tgbotapi.NewMessage(chatID, "Choose an interval (or Custom to enter your own):")
-	msg.ReplyMarkup = intervalPresetsKeyboard()
-	_, _ = r.bot.Send(msg)
-}
-
-func (r *Router) handleIntervalCallback(ctx context.Context, chatID int64, data string, cbID string) {
-	_ = r.answerCallback(cbID, "")
-	if data == "interval:custom" {
-		r.sendText(chatID, "Enter interval, e.g.: 30m, 1h, 1h30m, 90m")
-		r.setPending(chatID, pendingInterval)
-		return
-	}
-	val := strings.TrimPrefix(data, "interval:")
-	dur, err := domain.ParseDurationHuman(val)
+// showSettingsMenu shows the settings menu with the current values, below
+// header if given; each button opens a form. See present for menu and
+// inPlace.
+func (r *Router) showSettingsMenu(ctx context.Context, chatID int64, from *tgbotapi.User, menu int, header string, inPlace bool) {
+	u, err := r.ensureUser(ctx, chatID)
 	if err != nil {
-		r.sendDurationError(chatID, err)
+		r.log.Error("ensureUser failed", zap.Error(err))
+		r.sendText(chatID, r.tr(chatID, "menu.failed"))
 		return
 	}
-	if err := r.updateInterval(ctx, chatID, dur); err != nil {
-		r.log.Error("updateInterval failed", zap.Error(err))
-		r.sendText(chatID, "Could not save interval.")
-		return
+	text := settingsMenuText(r.lang(chatID), u)
+	if header != "" {
+		text = header + "\n\n" + text
 	}
-	r.sendText(chatID, "Interval updated: "+dur.String())
+	kb := settingsInlineKeyboard(r.lang(chatID))
+	r.present(ctx, chatID, from, formState{Menu: menu}, text, &kb, inPlace)
 }
 
+// --- Interval flow ---
+
 func (r *Router) updateInterval(ctx context.Context, chatID int64, d time.Duration) error {
 	u, err := r.ensureUser(ctx, chatID)
 	if err != nil {
 		return err
 	}
 	u.IntervalSec = int(d.Seconds())
+	r.configuredHere(u)
 	// Recompute next_fire_at after interval change
 	next := domain.NextFire(time.Now().UTC(), u)
 	u.NextFireAt = &next
-	return r.repo.UpsertUser(ctx, u)
+	if err := r.repo.UpsertUser(ctx, u); err != nil {
+		return err
+	}
+	r.notifySchedule(chatID)
+	return nil
 }
 
-// --- Free-form dispatcher (for all "Custom" inputs) ---
+// --- Free-form dispatcher (typed answers to prompts) ---
 
-func (r *Router) handleFreeForm(ctx context.Context, chatID int64, text string) {
-	switch r.getPending(chatID) {
-	case pendingInterval:
-		r.clearPending(chatID)
-		dur, err := domain.ParseDurationHuman(text)
-		if err != nil {
-			r.sendDurationError(chatID, err)
-			return
-		}
-		if err := r.updateInterval(ctx, chatID, dur); err != nil {
-			r.log.Error("updateInterval failed", zap.Error(err))
-			r.sendText(chatID, "Could not save interval.")
-			return
+func (r *Router) handleFreeForm(ctx context.Context, chatID int64, from *tgbotapi.User, text string) {
+	uid := userID(from)
+	state, expired := r.getPending(ctx, chatID, uid)
+	if expired {
+		r.clearPending(ctx, chatID, uid)
+		if st, ok := decodeFormState(state); state == pendingBroadcast || ok && st.Typing ||
+			strings.HasPrefix(state, pendingEmailCode) {
+			r.sendText(chatID, r.tr(chatID, "pending.expired"))
 		}
-		r.sendText(chatID, "Interval updated: "+dur.String())
-
-	case pendingHours:
-		r.clearPending(chatID)
-		fromM, toM, err := domain.ParseActiveWindow(text)
-		if err != nil {
-			r.sendText(chatID, "Invalid format. Example: 09:00–21:00")
-			return
-		}
-		if err := r.updateHours(ctx, chatID, fromM, toM); err != nil {
-			r.log.Error("updateHours failed", zap.Error(err))
-			r.sendText(chatID, "Could not save active hours.")
-			return
-		}
-		r.sendText(chatID, "Active hours updated: "+domain.FormatMinutes(fromM)+"–"+domain.FormatMinutes(toM))
-
-	case pendingTZ:
-		r.clearPending(chatID)
-		tz, err := domain.ValidateTZ(text)
-		if err != nil {
-			r.sendText(chatID, "Invalid timezone. Example: Europe/Moscow")
-			return
-		}
-		if err := r.updateTZ(ctx, chatID, tz); err != nil {
-			r.log.Error("updateTZ failed", zap.Error(err))
-			r.sendText(chatID, "Could not save timezone.")
-			return
+		return
+	}
+	if st, ok := decodeFormState(state); ok {
+		if st.Typing {
+			r.handleFormInput(ctx, chatID, from, st, text)
 		}
-		r.sendText(chatID, "Timezone updated: "+tz)
+		return
+	}
+	switch {
+	case state == pendingBroadcast:
+		r.clearPending(ctx, chatID, uid)
+		r.setBroadcast(ctx, chatID, uid, channelRef(text))
 
-	case pendingMessage:
-		r.clearPending(chatID)
-		if len(text) > 512 {
-			r.sendText(chatID, "Too long. Please keep it under 512 characters.")
-			return
-		}
-		u, err := r.ensureUser(ctx, chatID)
-		if err != nil {
-			r.log.Error("ensureUser failed", zap.Error(err))
-			r.sendText(chatID, "Could not save message.")
-			return
-		}
-		u.Message = text
-		if err := r.repo.UpsertUser(ctx, u); err != nil {
-			r.log.Error("save message failed", zap.Error(err))
-			r.sendText(chatID, "Could not save message.")
-			return
-		}
-		r.sendText(chatID, "Message updated.")
+	case strings.HasPrefix(state, pendingEmailCode):
+		r.handleEmailCode(ctx, chatID, from, state, text)
 
 	default:
 		// No pending flow: ignore free-form message
@@ -260,92 +253,50 @@ func (r *Router) handleFreeForm(ctx context.Context, chatID int64, text string)
 
 // --- Active hours flow ---
 
-func (r *Router) askHoursPresets(ctx context.Context, chatID int64, cbID string) {
-	_ = r.answerCallback(cbID, "")
-	msg := tgbotapi.NewMessage(chatID, "Choose active hours (or Custom):")
-	msg.ReplyMarkup = hoursPresetsKeyboard()
-	_, _ = r.bot.Send(msg)
-}
-
-func (r *Router) handleHoursCallback(ctx context.Context, chatID int64, data string, cbID string) {
-	_ = r.answerCallback(cbID, "")
-	if data == "hours:custom" {
-		r.sendText(chatID, "Enter active hours as HH:MM–HH:MM (e.g., 09:00–21:00)")
-		r.setPending(chatID, pendingHours)
-		return
-	}
-	val := strings.TrimPrefix(data, "hours:")
-	fromM, toM, err := domain.ParseActiveWindow(val)
-	if err != nil {
-		r.sendText(chatID, "Invalid format. Example: 09:00–21:00")
-		return
-	}
-	if err := r.updateHours(ctx, chatID, fromM, toM); err != nil {
-		r.log.Error("updateHours failed", zap.Error(err))
-		r.sendText(chatID, "Could not save active hours.")
-		return
-	}
-	r.sendText(chatID, "Active hours updated: "+domain.FormatMinutes(fromM)+"–"+domain.FormatMinutes(toM))
-}
-
 func (r *Router) updateHours(ctx context.Context, chatID int64, fromM, toM int) error {
 	u, err := r.ensureUser(ctx, chatID)
 	if err != nil {
 		return err
 	}
 	u.ActiveFromM, u.ActiveToM = fromM, toM
+	r.configuredHere(u)
 	next := domain.NextFire(time.Now().UTC(), u)
 	u.NextFireAt = &next
-	return r.repo.UpsertUser(ctx, u)
+	if err := r.repo.UpsertUser(ctx, u); err != nil {
+		return err
+	}
+	r.notifySchedule(chatID)
+	return nil
 }
 
 // --- Timezone flow ---
 
-func (r *Router) askTZPresets(ctx context.Context, chatID int64, cbID string) {
-	_ = r.answerCallback(cbID, "")
-	msg := tgbotapi.NewMessage(chatID, "Choose a timezone or enter your own (Region/City):")
-	msg.ReplyMarkup = tzPresetsKeyboard()
-	_, _ = r.bot.Send(msg)
-}
-
-func (r *Router) handleTZCallback(ctx context.Context, chatID int64, data string, cbID string) {
-	_ = r.answerCallback(cbID, "")
-	if data == "tz:custom" {
-		r.sendText(chatID, "Enter timezone (e.g., Europe/Moscow):")
-		r.setPending(chatID, pendingTZ)
-		return
-	}
-	val := strings.TrimPrefix(data, "tz:")
-	tz, err := domain.ValidateTZ(val)
-	if err != nil {
-		r.sendText(chatID, "Invalid timezone. Example: Europe/Moscow")
-		return
-	}
-	if err := r.updateTZ(ctx, chatID, tz); err != nil {
-		r.log.Error("updateTZ failed", zap.Error(err))
-		r.sendText(chatID, "Could not save timezone.")
-		return
-	}
-	r.sendText(chatID, "Timezone updated: "+tz)
-}
-
 func (r *Router) updateTZ(ctx context.Context, chatID int64, tz string) error {
 	u, err := r.ensureUser(ctx, chatID)
 	if err != nil {
 		return err
 	}
 	u.TZ = tz
+	r.configuredHere(u)
 	next := domain.NextFire(time.Now().UTC(), u)
 	u.NextFireAt = &next
-	return r.repo.UpsertUser(ctx, u)
+	if err := r.repo.UpsertUser(ctx, u); err != nil {
+		return err
+	}
+	r.notifySchedule(chatID)
+	return nil
 }
 
 // --- Message flow ---
 
-func (r *Router) askMessage(ctx context.Context, chatID int64, cbID string) {
-	_ = r.answerCallback(cbID, "")
-	r.sendText(chatID, "Send your reminder text in a single message (max 512 chars):")
-	r.setPending(chatID, pendingMessage)
+func (r *Router) updateMessage(ctx context.Context, chatID int64, text string) error {
+	u, err := r.ensureUser(ctx, chatID)
+	if err != nil {
+		return err
+	}
+	u.Message = text
+	r.configuredHere(u)
+	return r.repo.UpsertUser(ctx, u)
 }
 
 // --- Pause / Resume ---
@@ -353,18 +304,19 @@ func (r *Router) askMessage(ctx context.Context, chatID int64, cbID string) {
 func (r *Router) handlePause(ctx context.Context, chatID int64) {
 	if err := r.repo.SetEnabled(ctx, chatID, false); err != nil {
 		r.log.Error("pause failed", zap.Error(err))
-		r.sendText(chatID, "Failed to pause.")
+		r.sendText(chatID, r.tr(chatID, "pause.failed"))
 		return
 	}
-	msg := tgbotapi.NewMessage(chatID, "Paused ⏸")
+	r.notifySchedule(chatID)
+	msg := tgbotapi.NewMessage(chatID, r.tr(chatID, "pause.done"))
 	msg.ReplyMarkup = mainMenuKeyboard(false)
-	_, _ = r.bot.Send(msg)
+	_, _ = r.send(msg)
 }
 
 func (r *Router) handleResume(ctx context.Context, chatID int64) {
 	if err := r.repo.SetEnabled(ctx, chatID, true); err != nil {
 		r.log.Error("resume failed", zap.Error(err))
-		r.sendText(chatID, "Failed to resume.")
+		r.sendText(chatID, r.tr(chatID, "resume.failed"))
 		return
 	}
 	// Ensure next_fire_at is set after resuming.
@@ -372,20 +324,21 @@ func (r *Router) handleResume(ctx context.Context, chatID int64) {
 		next := domain.NextFire(time.Now().UTC(), u)
 		_ = r.repo.SetSchedule(ctx, chatID, next, nil)
 	}
-	msg := tgbotapi.NewMessage(chatID, "Resumed ✅")
+	r.notifySchedule(chatID)
+	msg := tgbotapi.NewMessage(chatID, r.tr(chatID, "resume.done"))
 	msg.ReplyMarkup = mainMenuKeyboard(true)
-	_, _ = r.bot.Send(msg)
+	_, _ = r.send(msg)
 }
 
 // handleExamples sends all bundled MP3s to the user.
 func (r *Router) handleExamples(ctx context.Context, chatID int64) {
 	files := assets.List()
 	if len(files) == 0 {
-		r.sendText(chatID, "No audio examples bundled.")
+		r.sendText(chatID, r.tr(chatID, "examples.none"))
 		return
 	}
 
-	r.sendText(chatID, "Sending audio examples…")
+	r.sendText(chatID, r.tr(chatID, "examples.sending"))
 	for _, p := range files {
 		f, err := assets.AudioFS.Open(p)
 		if err != nil {
@@ -406,9 +359,9 @@ func (r *Router) handleExamples(ctx context.Context, chatID int64) {
 			Bytes: data,
 		})
 		// Optional: title/caption
-		if _, err := r.bot.Send(audio); err != nil {
+		if _, err := r.send(audio); err != nil {
 			r.log.Error("send audio failed", zap.String("path", p), zap.Error(err))
 		}
 	}
-	r.sendText(chatID, "Done. Open the chat’s notification settings in Telegram to set a custom sound.")
+	r.sendText(chatID, r.tr(chatID, "examples.done"))
 }
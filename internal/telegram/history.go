@@ -0,0 +1,125 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"github.com/ykvlv/notification-bot/internal/domain"
+	"github.com/ykvlv/notification-bot/internal/i18n"
+	"github.com/ykvlv/notification-bot/internal/store"
+)
+
+// historyPageSize is how many deliveries one /history page shows.
+const historyPageSize = 10
+
+// handleHistory sends the first page of the chat's delivery history.
+func (r *Router) handleHistory(ctx context.Context, chatID int64) {
+	text, kb, err := r.renderHistory(ctx, chatID, 0)
+	if err != nil {
+		r.log.Error("history failed", zap.Error(err))
+		r.sendText(chatID, r.tr(chatID, "history.failed"))
+		return
+	}
+	msg := tgbotapi.NewMessage(chatID, text)
+	if kb != nil {
+		msg.ReplyMarkup = *kb
+	}
+	_, _ = r.send(msg)
+}
+
+// handleHistoryCallback switches the history message to another page in place.
+func (r *Router) handleHistoryCallback(ctx context.Context, chatID int64, messageID int, data, cbID string) {
+	_ = r.answerCallback(cbID, "")
+	page, err := strconv.Atoi(strings.TrimPrefix(data, "history:"))
+	if err != nil || page < 0 {
+		return
+	}
+	text, kb, err := r.renderHistory(ctx, chatID, page)
+	if err != nil {
+		r.log.Error("history failed", zap.Error(err))
+		return
+	}
+	edit := tgbotapi.NewEditMessageText(chatID, messageID, text)
+	edit.ReplyMarkup = kb
+	_, _ = r.send(edit)
+}
+
+// renderHistory builds the text and prev/next keyboard for a history page.
+// The keyboard is nil when everything fits on one page.
+func (r *Router) renderHistory(ctx context.Context, chatID int64, page int) (string, *tgbotapi.InlineKeyboardMarkup, error) {
+	total, err := r.repo.CountDeliveries(ctx, store.DeliveryFilter{ChatID: chatID})
+	if err != nil {
+		return "", nil, err
+	}
+	if total == 0 {
+		return r.tr(chatID, "history.empty"), nil, nil
+	}
+	pages := (total + historyPageSize - 1) / historyPageSize
+	page = min(page, pages-1)
+
+	items, err := r.repo.ListDeliveries(ctx, store.DeliveryFilter{
+		ChatID: chatID,
+		Offset: page * historyPageSize,
+		Limit:  historyPageSize,
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	tz := defaultTZ
+	if u, err := r.repo.GetUser(ctx, chatID); err == nil {
+		tz = u.TZ
+	}
+
+	var b strings.Builder
+	l := r.lang(chatID)
+	b.WriteString(l.T("history.title", page+1, pages) + "\n\n")
+	for _, d := range items {
+		b.WriteString(formatDelivery(l, d, tz))
+		b.WriteByte('\n')
+	}
+
+	var row []tgbotapi.InlineKeyboardButton
+	if page > 0 {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(l.T("btn.prev"), fmt.Sprintf("history:%d", page-1)))
+	}
+	if page < pages-1 {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(l.T("btn.next"), fmt.Sprintf("history:%d", page+1)))
+	}
+	if len(row) == 0 {
+		return b.String(), nil, nil
+	}
+	kb := tgbotapi.NewInlineKeyboardMarkup(row)
+	return b.String(), &kb, nil
+}
+
+// formatDelivery renders one history line in the user's timezone.
+func formatDelivery(l i18n.Lang, d domain.Delivery, tz string) string {
+	when := d.ScheduledAt.Format("2006-01-02 15:04")
+	if s, err := domain.LocalizeDateTime(d.ScheduledAt, tz); err == nil {
+		when = s
+	}
+	switch d.Status {
+	case domain.DeliverySent:
+		if d.AckedAt != nil {
+			return "✅ " + when + " — " + l.T("history.acked")
+		}
+		return "✅ " + when
+	case domain.DeliveryPending, domain.DeliverySending:
+		if d.Attempts > 0 {
+			return "⏳ " + when + " — " + l.T("history.retrying", d.Attempts, d.Error)
+		}
+		return "⏳ " + when
+	case domain.DeliveryCanceled:
+		return "⏹ " + when + " — " + l.T("history.canceled")
+	case domain.DeliveryUnknown:
+		return "❔ " + when + " — " + l.T("history.unknown")
+	default:
+		return "❌ " + when + " — " + d.Error
+	}
+}
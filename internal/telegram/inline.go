@@ -0,0 +1,120 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"github.com/ykvlv/notification-bot/internal/domain"
+	"github.com/ykvlv/notification-bot/internal/i18n"
+)
+
+// inlineCacheTime is how long Telegram may reuse an inline answer, in
+// seconds. Answers depend on the sender's language, so they are personal.
+const inlineCacheTime = 300
+
+// A reminderTemplate is a ready-made reminder offered in inline mode
+// ("@bot water"). Its title and text are the catalog messages
+// "tpl.<ID>.title" and "tpl.<ID>.message".
+type reminderTemplate struct {
+	ID       string
+	Interval time.Duration
+	FromM    int // active hours, minutes after midnight
+	ToM      int
+}
+
+var reminderTemplates = []reminderTemplate{
+	{ID: "water", Interval: time.Hour, FromM: 9 * 60, ToM: 21 * 60},
+	{ID: "stretch", Interval: 2 * time.Hour, FromM: 10 * 60, ToM: 19 * 60},
+	{ID: "eyes", Interval: 20 * time.Minute, FromM: 9 * 60, ToM: 18 * 60},
+	{ID: "posture", Interval: 90 * time.Minute, FromM: 9 * 60, ToM: 19 * 60},
+	{ID: "walk", Interval: 3 * time.Hour, FromM: 10 * 60, ToM: 20 * 60},
+}
+
+// templateByID returns the template with the given ID, or nil.
+func templateByID(id string) *reminderTemplate {
+	for i := range reminderTemplates {
+		if reminderTemplates[i].ID == id {
+			return &reminderTemplates[i]
+		}
+	}
+	return nil
+}
+
+// templateForMessage returns the ID of the template whose text, in any
+// language, is message; "" if none.
+func templateForMessage(message string) string {
+	for _, t := range reminderTemplates {
+		for _, l := range i18n.Langs {
+			if l.T("tpl."+t.ID+".message") == message {
+				return t.ID
+			}
+		}
+	}
+	return ""
+}
+
+// startPayload is the /start parameter of the template's deep link; see
+// deeplink.go.
+func (t reminderTemplate) startPayload() string {
+	s := linkSettings{Template: t.ID, Interval: t.Interval, FromM: t.FromM, ToM: t.ToM}
+	p, _ := s.templatePayload()
+	return p
+}
+
+// schedule describes when the template reminds, e.g. "every 1 hour,
+// 09:00–21:00".
+func (t reminderTemplate) schedule(l i18n.Lang) string {
+	return l.T("tpl.schedule", l.Duration(t.Interval), domain.FormatMinutes(t.FromM), domain.FormatMinutes(t.ToM))
+}
+
+// matches reports whether an inline query picks the template: by ID, or
+// by a word of its title or text. An empty query picks all.
+func (t reminderTemplate) matches(l i18n.Lang, query string) bool {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" || strings.HasPrefix(t.ID, query) {
+		return true
+	}
+	text := strings.ToLower(l.T("tpl."+t.ID+".title") + " " + l.T("tpl."+t.ID+".message"))
+	return strings.Contains(text, query)
+}
+
+// handleInlineQuery answers "@bot <query>" with matching templates. Each
+// one is posted as a card whose button opens the bot with the template's
+// deep link, so whoever sees it can set the reminder up.
+func (r *Router) handleInlineQuery(ctx context.Context, q *tgbotapi.InlineQuery) {
+	// The sender's private chat shares their ID and holds their /language.
+	r.setLang(ctx, q.From.ID, q.From)
+	defer r.endUpdate(q.From.ID)
+	l := r.lang(q.From.ID)
+
+	results := []interface{}{}
+	for _, t := range reminderTemplates {
+		if !t.matches(l, q.Query) {
+			continue
+		}
+		title := l.T("tpl." + t.ID + ".title")
+		text := l.T("tpl.card", title, l.T("tpl."+t.ID+".message"), t.schedule(l))
+		kb := tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonURL(l.T("btn.setup"), r.startURL(t.startPayload())),
+		))
+
+		article := tgbotapi.NewInlineQueryResultArticle(t.ID, title, text)
+		article.Description = t.schedule(l)
+		article.ReplyMarkup = &kb
+		results = append(results, article)
+	}
+
+	_, err := r.bot.Request(tgbotapi.InlineConfig{
+		InlineQueryID: q.ID,
+		Results:       results,
+		CacheTime:     inlineCacheTime,
+		IsPersonal:    true,
+	})
+	if err != nil {
+		r.log.Warn("answerInlineQuery failed", zap.Error(err), zap.String("query", q.Query))
+	}
+}
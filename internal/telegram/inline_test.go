@@ -0,0 +1,68 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+func TestInlineQuery(t *testing.T) {
+	bot, stub := newStubBot(t)
+	r := NewRouter(bot, zap.NewNop(), &convRepo{}, nil)
+
+	r.HandleUpdate(context.Background(), Update{Update: tgbotapi.Update{InlineQuery: &tgbotapi.InlineQuery{
+		ID:    "q1",
+		From:  &tgbotapi.User{ID: 7, LanguageCode: "en"},
+		Query: "Water",
+	}}})
+
+	c := stub.last()
+	if c.method != "answerInlineQuery" || c.params["inline_query_id"] != "q1" || c.params["is_personal"] != "true" {
+		t.Fatalf("unexpected call %+v", c)
+	}
+	var results []struct {
+		ID                  string `json:"id"`
+		Title               string `json:"title"`
+		InputMessageContent struct {
+			Text string `json:"message_text"`
+		} `json:"input_message_content"`
+		ReplyMarkup tgbotapi.InlineKeyboardMarkup `json:"reply_markup"`
+	}
+	if err := json.Unmarshal([]byte(c.params["results"]), &results); err != nil {
+		t.Fatalf("results: %v", err)
+	}
+	if len(results) != 1 || results[0].ID != "water" || results[0].Title != "Drink water" {
+		t.Fatalf("results: %+v", results)
+	}
+	btn := results[0].ReplyMarkup.InlineKeyboard[0][0]
+	if btn.URL == nil || *btn.URL != "https://t.me/test_bot?start=t1_water" {
+		t.Fatalf("button: %+v", btn)
+	}
+
+	// An empty query lists every template.
+	r.HandleUpdate(context.Background(), Update{Update: tgbotapi.Update{InlineQuery: &tgbotapi.InlineQuery{
+		ID: "q2", From: &tgbotapi.User{ID: 7},
+	}}})
+	if err := json.Unmarshal([]byte(stub.last().params["results"]), &results); err != nil {
+		t.Fatalf("results: %v", err)
+	}
+	if len(results) != len(reminderTemplates) {
+		t.Fatalf("want %d results, got %d", len(reminderTemplates), len(results))
+	}
+}
+
+func TestInlineMessageCallback(t *testing.T) {
+	bot, stub := newStubBot(t)
+	r := NewRouter(bot, zap.NewNop(), &convRepo{}, nil)
+
+	// Callbacks from inline-mode messages carry no Message.
+	r.HandleUpdate(context.Background(), Update{Update: tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+		ID: "cb", From: &tgbotapi.User{ID: 7, LanguageCode: "en"}, InlineMessageID: "im1", Data: "cancel",
+	}}})
+	if c := stub.last(); c.method != "answerCallbackQuery" || c.params["callback_query_id"] != "cb" {
+		t.Fatalf("unexpected call %+v", c)
+	}
+}
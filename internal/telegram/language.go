@@ -0,0 +1,103 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"github.com/ykvlv/notification-bot/internal/i18n"
+	"github.com/ykvlv/notification-bot/internal/store"
+)
+
+// setLang picks the language of the update being handled in a chat: the
+// one chosen with /language, else the sender's Telegram app language.
+// Like setTopic, it holds for the current update only, until endUpdate.
+func (r *Router) setLang(ctx context.Context, chatID int64, from *tgbotapi.User) {
+	l := i18n.Default
+	if from != nil {
+		l = i18n.Match(from.LanguageCode)
+	}
+	if u, err := r.repo.GetUser(ctx, chatID); err == nil && u.Language != "" {
+		l = i18n.Match(u.Language)
+	}
+	r.mu.Lock()
+	r.langs[chatID] = l
+	r.mu.Unlock()
+}
+
+// lang returns the language responses to a chat are written in.
+func (r *Router) lang(chatID int64) i18n.Lang {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if l, ok := r.langs[chatID]; ok {
+		return l
+	}
+	return i18n.Default
+}
+
+// tr translates a catalog message for a chat; see i18n.Lang.T.
+func (r *Router) tr(chatID int64, key string, args ...any) string {
+	return r.lang(chatID).T(key, args...)
+}
+
+// chatLang is the language of messages sent outside an update, such as
+// reminders: the chat's stored language, else i18n.Default.
+func chatLang(ctx context.Context, repo store.Repo, chatID int64) i18n.Lang {
+	u, err := repo.GetUser(ctx, chatID)
+	if err != nil || u.Language == "" {
+		return i18n.Default
+	}
+	return i18n.Match(u.Language)
+}
+
+// handleLanguage shows the language picker, or sets the chat's language
+// directly: "/language en".
+func (r *Router) handleLanguage(ctx context.Context, chatID int64, arg string) {
+	if arg == "" {
+		var row []tgbotapi.InlineKeyboardButton
+		for _, l := range i18n.Langs {
+			row = append(row, tgbotapi.NewInlineKeyboardButtonData(l.Name(), "lang:"+string(l)))
+		}
+		msg := tgbotapi.NewMessage(chatID, r.tr(chatID, "language.prompt"))
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(row)
+		_, _ = r.send(msg)
+		return
+	}
+	l, ok := i18n.Parse(strings.ToLower(arg))
+	if !ok {
+		r.sendText(chatID, r.tr(chatID, "language.unknown"))
+		return
+	}
+	r.setLanguage(ctx, chatID, l)
+}
+
+// handleLanguageCallback is a button of the language picker.
+func (r *Router) handleLanguageCallback(ctx context.Context, cb *tgbotapi.CallbackQuery) {
+	_ = r.answerCallback(cb.ID, "")
+	l, ok := i18n.Parse(strings.TrimPrefix(cb.Data, "lang:"))
+	if !ok {
+		return
+	}
+	r.retireMenu(cb.Message.Chat.ID, cb.Message.MessageID)
+	r.setLanguage(ctx, cb.Message.Chat.ID, l)
+}
+
+// setLanguage stores the chat's language and answers in it.
+func (r *Router) setLanguage(ctx context.Context, chatID int64, l i18n.Lang) {
+	u, err := r.ensureUser(ctx, chatID)
+	if err == nil {
+		u.Language = string(l)
+		err = r.repo.UpsertUser(ctx, u)
+	}
+	if err != nil {
+		r.log.Error("language update failed", zap.Error(err))
+		r.sendText(chatID, r.tr(chatID, "language.failed"))
+		return
+	}
+	r.mu.Lock()
+	r.langs[chatID] = l
+	r.mu.Unlock()
+	r.sendText(chatID, l.T("language.set"))
+}
@@ -0,0 +1,90 @@
+package telegram
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"github.com/ykvlv/notification-bot/internal/domain"
+	"github.com/ykvlv/notification-bot/internal/i18n"
+)
+
+func TestSetLang(t *testing.T) {
+	repo := &convRepo{}
+	r := NewRouter(nil, zap.NewNop(), repo, nil)
+	ctx := context.Background()
+
+	r.setLang(ctx, 7, &tgbotapi.User{ID: 7, LanguageCode: "en-GB"})
+	if r.lang(7) != i18n.En {
+		t.Fatalf("app language: %s", r.lang(7))
+	}
+	r.setLang(ctx, 7, &tgbotapi.User{ID: 7})
+	if r.lang(7) != i18n.Default {
+		t.Fatalf("no app language: %s", r.lang(7))
+	}
+
+	repo.user = &domain.User{ChatID: 7, Language: "ru"}
+	r.setLang(ctx, 7, &tgbotapi.User{ID: 7, LanguageCode: "en"})
+	if r.lang(7) != i18n.Ru {
+		t.Fatalf("chosen language: %s", r.lang(7))
+	}
+	if r.lang(8) != i18n.Default {
+		t.Fatalf("other chat: %s", r.lang(8))
+	}
+}
+
+func TestHandleUpdate_ForgetsChat(t *testing.T) {
+	bot, _ := newStubBot(t)
+	r := NewRouter(bot, zap.NewNop(), &convRepo{convs: map[pendingKey]domain.Conversation{}}, nil)
+	r.HandleUpdate(context.Background(), Update{
+		Update: tgbotapi.Update{Message: &tgbotapi.Message{
+			MessageID: 1, From: &tgbotapi.User{ID: 7, LanguageCode: "en"},
+			Chat: &tgbotapi.Chat{ID: -100, Type: "supergroup"}, Text: "hello",
+		}},
+		ThreadID: 3,
+	})
+	if len(r.topics) != 0 || len(r.langs) != 0 {
+		t.Fatalf("update state kept: topics %v, langs %v", r.topics, r.langs)
+	}
+}
+
+// TestUsedKeysInCatalogs fails when the package looks up a message that a
+// catalog lacks. Keys built at run time (e.g. "tpl."+id) are not checked.
+func TestUsedKeysInCatalogs(t *testing.T) {
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	used := regexp.MustCompile(`(?:\btr\([^,()]+, |\.T\(|\.N\()"([a-z_.]+)"[,)]`)
+	n := 0
+	for _, f := range files {
+		if strings.HasSuffix(f, "_test.go") {
+			continue
+		}
+		src, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, m := range used.FindAllStringSubmatch(string(src), -1) {
+			key := m[1]
+			if strings.HasPrefix(m[0], ".N(") {
+				key += "#one" // every language has it
+			}
+			n++
+			for _, l := range i18n.Langs {
+				if _, ok := l.Lookup(key); !ok {
+					t.Errorf("%s: %s catalog lacks %q", f, l, key)
+				}
+			}
+		}
+	}
+	if n == 0 {
+		t.Fatal("no message lookups found")
+	}
+}
@@ -2,138 +2,296 @@ package telegram
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"strings"
 	"sync"
+	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"go.uber.org/zap"
 
+	"github.com/ykvlv/notification-bot/internal/domain"
+	"github.com/ykvlv/notification-bot/internal/i18n"
 	"github.com/ykvlv/notification-bot/internal/store"
 )
 
-// Pending state keys used in conversational flows.
+// pendingTTL is how long a prompt waits for its answer.
+const pendingTTL = 15 * time.Minute
+
+// Lapsed prompts linger pendingKeep after expiry, so returning members hear
+// it lapsed; SweepConversations deletes them every pendingSweepEvery.
 const (
-	pendingInterval = "await_interval_text"
-	pendingHours    = "await_hours_text"
-	pendingTZ       = "await_tz_text"
-	pendingMessage  = "await_message_text"
+	pendingKeep       = 24 * time.Hour
+	pendingSweepEvery = time.Hour
 )
 
+// pendingBroadcast awaits a forwarded channel post or @channel.
+const pendingBroadcast = "await_broadcast_channel"
+
+// ScheduleNotifier is told when a chat's schedule-affecting settings change
+// (interval, hours, TZ, pause state). scheduler.Scheduler implements it.
+type ScheduleNotifier interface {
+	Notify(chatID int64)
+	// NotifyAll is told when every chat's schedule may have changed.
+	NotifyAll()
+	// NotifyDelivery is told about a delivery queued outside the scheduler.
+	NotifyDelivery(deliveryID int64)
+}
+
 // Router wires Telegram updates to handlers and holds minimal in-memory state.
 type Router struct {
-	bot   *tgbotapi.BotAPI
-	log   *zap.Logger
-	repo  store.Repo
-	state map[int64]string // chatID -> pending state
-	mu    sync.RWMutex
+	bot      *tgbotapi.BotAPI
+	log      *zap.Logger
+	repo     store.Repo
+	notifier ScheduleNotifier
+	mailer   Mailer         // nil when email delivery is not configured
+	admins   map[int64]bool // Telegram user IDs allowed to run admin commands
+	// adminCache remembers getChatMember answers for group settings checks.
+	adminCache map[pendingKey]adminEntry
+	topics     map[int64]int       // chatID -> forum topic of the update being handled; see endUpdate
+	langs      map[int64]i18n.Lang // chatID -> language of the update being handled; see endUpdate
+	mu         sync.RWMutex
 }
 
 // NewRouter creates a new Telegram router.
-func NewRouter(bot *tgbotapi.BotAPI, log *zap.Logger, repo store.Repo) *Router {
+func NewRouter(bot *tgbotapi.BotAPI, log *zap.Logger, repo store.Repo, adminIDs []int64) *Router {
+	admins := make(map[int64]bool, len(adminIDs))
+	for _, id := range adminIDs {
+		admins[id] = true
+	}
 	return &Router{
-		bot:   bot,
-		log:   log,
-		repo:  repo,
-		state: make(map[int64]string),
+		bot:        bot,
+		log:        log,
+		repo:       repo,
+		admins:     admins,
+		adminCache: make(map[pendingKey]adminEntry),
+		topics:     make(map[int64]int),
+		langs:      make(map[int64]i18n.Lang),
+	}
+}
+
+// handleMigration moves a group's settings to its new supergroup chat ID.
+func (r *Router) handleMigration(ctx context.Context, oldChatID, newChatID int64) {
+	if err := r.repo.MigrateChat(ctx, oldChatID, newChatID); err != nil {
+		r.log.Error("MigrateChat failed", zap.Error(err),
+			zap.Int64("chatID", oldChatID), zap.Int64("newChatID", newChatID))
+		return
 	}
+	r.notifySchedule(oldChatID)
+	r.notifySchedule(newChatID)
 }
 
-// setPending sets a pending state for a chat (non-persistent, in-memory).
-func (r *Router) setPending(chatID int64, s string) {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-	r.state[chatID] = s
+// SetNotifier registers the scheduler to wake on settings changes.
+// Must be called before the first update is handled.
+func (r *Router) SetNotifier(n ScheduleNotifier) {
+	r.notifier = n
 }
 
-// getPending returns current pending state for a chat.
-func (r *Router) getPending(chatID int64) string {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-	return r.state[chatID]
+// notifySchedule forwards a schedule change to the notifier, if any.
+func (r *Router) notifySchedule(chatID int64) {
+	if r.notifier != nil {
+		r.notifier.Notify(chatID)
+	}
 }
 
-// clearPending clears a pending state for a chat.
-func (r *Router) clearPending(chatID int64) {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-	delete(r.state, chatID)
+// notifyAllSchedules tells the notifier, if any, that every schedule may
+// have changed.
+func (r *Router) notifyAllSchedules() {
+	if r.notifier != nil {
+		r.notifier.NotifyAll()
+	}
+}
+
+// notifyDelivery tells the notifier, if any, about a queued delivery.
+func (r *Router) notifyDelivery(deliveryID int64) {
+	if r.notifier != nil {
+		r.notifier.NotifyDelivery(deliveryID)
+	}
+}
+
+// setPending records that a member's next message answers a prompt. It is
+// kept in the store, so a restart does not lose it, and lapses after
+// pendingTTL.
+func (r *Router) setPending(ctx context.Context, chatID, userID int64, s string) {
+	now := time.Now().UTC()
+	err := r.repo.SetConversation(ctx, &domain.Conversation{
+		ChatID:    chatID,
+		UserID:    userID,
+		State:     s,
+		ExpiresAt: now.Add(pendingTTL),
+	})
+	if err != nil {
+		r.log.Error("SetConversation failed", zap.Error(err), zap.Int64("chatID", chatID))
+	}
+}
+
+// SweepConversations deletes lapsed prompts until ctx is canceled.
+func (r *Router) SweepConversations(ctx context.Context) {
+	t := time.NewTicker(pendingSweepEvery)
+	defer t.Stop()
+	for {
+		n, err := r.repo.DeleteExpiredConversations(ctx, time.Now().UTC().Add(-pendingKeep))
+		if err != nil && ctx.Err() == nil {
+			r.log.Warn("DeleteExpiredConversations failed", zap.Error(err))
+		} else if n > 0 {
+			r.log.Debug("lapsed prompts deleted", zap.Int64("count", n))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// getPending returns a member's pending state. expired is true when the
+// member was asked but did not answer in time; the lapsed state is still
+// returned so callers can tell what was asked.
+func (r *Router) getPending(ctx context.Context, chatID, userID int64) (state string, expired bool) {
+	c, err := r.repo.GetConversation(ctx, chatID, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false
+	}
+	if err != nil {
+		r.log.Error("GetConversation failed", zap.Error(err), zap.Int64("chatID", chatID))
+		return "", false
+	}
+	if c.Expired(time.Now()) {
+		return c.State, true
+	}
+	return c.State, false
+}
+
+// clearPending clears a member's pending state.
+func (r *Router) clearPending(ctx context.Context, chatID, userID int64) {
+	if err := r.repo.DeleteConversation(ctx, chatID, userID); err != nil {
+		r.log.Error("DeleteConversation failed", zap.Error(err), zap.Int64("chatID", chatID))
+	}
 }
 
 // HandleUpdate routes a single update to appropriate handler.
-func (r *Router) HandleUpdate(ctx context.Context, upd tgbotapi.Update) {
+func (r *Router) HandleUpdate(ctx context.Context, upd Update) {
 	// Text messages
 	if upd.Message != nil {
 		msg := upd.Message
 		chatID := msg.Chat.ID
+		r.setTopic(chatID, upd.ThreadID)
+		r.setLang(ctx, chatID, msg.From)
+		defer r.endUpdate(chatID)
 		text := strings.TrimSpace(msg.Text)
 
-		switch {
-		case strings.HasPrefix(text, "/start"):
-			r.handleStart(ctx, chatID)
-		case strings.HasPrefix(text, "/status"):
-			r.handleStatus(ctx, chatID)
-		case strings.HasPrefix(text, "/settings"):
-			r.handleSettings(ctx, chatID)
-		case strings.HasPrefix(text, "/pause"):
-			r.handlePause(ctx, chatID)
-		case strings.HasPrefix(text, "/resume"):
-			r.handleResume(ctx, chatID)
-		case strings.HasPrefix(text, "/examples"):
-			r.handleExamples(ctx, chatID)
-		default:
-			// Free-form text used in "Custom" flows (interval/hours/tz/message)
-			r.handleFreeForm(ctx, chatID, text)
+		// Service message: group upgraded to a supergroup.
+		if msg.MigrateToChatID != 0 {
+			r.handleMigration(ctx, chatID, msg.MigrateToChatID)
+			return
+		}
+
+		if strings.HasPrefix(text, "/") {
+			cmd, args, ok := parseCommand(text, r.bot.Self.UserName)
+			if !ok {
+				return // addressed to another bot in the group
+			}
+			if isSettingsCommand(cmd) && !r.canConfigure(ctx, msg.Chat, msg.From, msg.SenderChat) {
+				r.sendText(chatID, r.tr(chatID, "group.not_admin"))
+				return
+			}
+			if r.handleCommand(ctx, msg, cmd, args) {
+				return
+			}
+		}
+
+		// A channel post forwarded while choosing a broadcast channel.
+		if msg.ForwardFromChat != nil && msg.ForwardFromChat.IsChannel() &&
+			r.pendingIs(ctx, chatID, userID(msg.From), pendingBroadcast) {
+			r.handleForwardedPost(ctx, msg)
+			return
 		}
+
+		// Free-form text used in "Custom" flows (interval/hours/tz/message)
+		r.handleFreeForm(ctx, chatID, msg.From, text)
 		return
 	}
 
 	// Callback queries (inline buttons)
 	if upd.CallbackQuery != nil {
 		cb := upd.CallbackQuery
+		if cb.Message == nil {
+			// Buttons on inline-mode messages (template cards) have no chat
+			// and only open links; answer anything else so it stops spinning.
+			r.setLang(ctx, cb.From.ID, cb.From)
+			defer r.endUpdate(cb.From.ID)
+			_ = r.answerCallback(cb.ID, r.tr(cb.From.ID, "menu.stale"))
+			return
+		}
 		data := cb.Data
 		chatID := cb.Message.Chat.ID
+		r.setTopic(chatID, upd.ThreadID)
+		r.setLang(ctx, chatID, cb.From)
+		defer r.endUpdate(chatID)
+
+		if isSettingsCallback(data) && !r.canConfigure(ctx, cb.Message.Chat, cb.From, nil) {
+			_, _ = r.bot.Request(tgbotapi.CallbackConfig{CallbackQueryID: cb.ID, Text: r.tr(chatID, "group.not_admin"), ShowAlert: true})
+			return
+		}
 
 		switch {
 		// Settings sections
-		case data == "set_interval":
-			r.askIntervalPresets(ctx, chatID, cb.ID)
-		case strings.HasPrefix(data, "interval:"):
-			r.handleIntervalCallback(ctx, chatID, data, cb.ID)
+		case settingsFormIDs[data] != "":
+			r.handleMenuCallback(ctx, cb)
+		case strings.HasPrefix(data, "f:"):
+			r.handleFormCallback(ctx, cb)
 
-		case data == "set_hours":
-			r.askHoursPresets(ctx, chatID, cb.ID)
-		case strings.HasPrefix(data, "hours:"):
-			r.handleHoursCallback(ctx, chatID, data, cb.ID)
+		case strings.HasPrefix(data, "ack:"):
+			r.handleAckCallback(ctx, cb)
 
-		case data == "set_tz":
-			r.askTZPresets(ctx, chatID, cb.ID)
-		case strings.HasPrefix(data, "tz:"):
-			r.handleTZCallback(ctx, chatID, data, cb.ID)
+		case data == "start_link":
+			r.handleStartLinkCallback(ctx, cb)
 
-		case data == "set_msg":
-			r.askMessage(ctx, chatID, cb.ID)
+		case data == "cancel":
+			r.handleCancelCallback(ctx, cb)
 
 		case data == "send_examples":
 			r.handleExamples(ctx, chatID)
 
-		case data == "back_to_menu":
-			if err := r.answerCallback(cb.ID, ""); err != nil {
-				r.log.Warn("callback ack failed", zap.Error(err))
-			}
-			msg := tgbotapi.NewMessage(chatID, "Settings:")
-			msg.ReplyMarkup = settingsInlineKeyboard()
-			_, _ = r.bot.Send(msg)
+		case strings.HasPrefix(data, "lang:"):
+			r.handleLanguageCallback(ctx, cb)
+
+		case strings.HasPrefix(data, "history:"):
+			r.handleHistoryCallback(ctx, chatID, cb.Message.MessageID, data, cb.ID)
 
 		default:
-			// Unknown callback — ignore silently
+			// Buttons of menus from older versions.
+			_ = r.answerCallback(cb.ID, r.tr(chatID, "menu.stale"))
 		}
 		return
 	}
+
+	// Inline mode: "@bot water" in any chat
+	if upd.InlineQuery != nil {
+		r.handleInlineQuery(ctx, upd.InlineQuery)
+		return
+	}
+}
+
+// handleCommand runs a parsed command from the registry and reports whether
+// it was known. Unknown commands fall through to free-form input.
+func (r *Router) handleCommand(ctx context.Context, msg *tgbotapi.Message, cmd, args string) bool {
+	c := commandByName(cmd)
+	if c == nil || c.Admin && !r.isAdmin(msg.From) {
+		return false
+	}
+	c.Run(r, ctx, msg, args)
+	return true
 }
 
-// SendMessage sends a plain text message to the given chat.
-// This makes Router satisfy scheduler.Sender.
-func (r *Router) SendMessage(chatID int64, text string) error {
-	_, err := r.bot.Send(tgbotapi.NewMessage(chatID, text))
-	return err
+// SendMessage sends a plain text message to the given chat, in the topic
+// its reminders go to. API errors are classified the same way as for
+// scheduled reminders.
+func (r *Router) SendMessage(chatID int64, text string) (int, error) {
+	sent, err := sendInTopic(r.bot, tgbotapi.NewMessage(chatID, text), r.reminderTopic(chatID))
+	if err != nil {
+		return 0, classifySendError(err)
+	}
+	return sent.MessageID, nil
 }
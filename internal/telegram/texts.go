@@ -1,16 +1,20 @@
 package telegram
 
-import tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+import (
+	"fmt"
+	"strconv"
+	"time"
 
-// UI texts in English
-const (
-	startText = "👋 I am a reminder bot.\n\n" +
-		"Set interval, active hours, timezone and your message — I will ping you.\n\n" +
-		"🎵 Need ready-made sounds? Use /examples to get MP3s and set them as custom notification sounds in Telegram."
-	statusTitle = "🧾 Your current settings:"
-	statusFmt   = "• Interval: %s\n• Active hours: %s–%s\n• TZ: %s\n• Enabled: %s\n• Next: %s\n• Message: %s\n"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/ykvlv/notification-bot/internal/domain"
+	"github.com/ykvlv/notification-bot/internal/i18n"
+	"github.com/ykvlv/notification-bot/internal/scheduler"
 )
 
+// UI texts are in the i18n catalog; the helpers below build the composite
+// ones in a chat's language.
+
 // mainMenuKeyboard builds a reply keyboard with a single toggle button:
 // if enabled is true -> "/pause", else -> "/resume".
 func mainMenuKeyboard(enabled bool) tgbotapi.ReplyKeyboardMarkup {
@@ -29,78 +33,67 @@ func mainMenuKeyboard(enabled bool) tgbotapi.ReplyKeyboardMarkup {
 	)
 }
 
+// settingsMenuText lists the values the settings menu changes.
+func settingsMenuText(l i18n.Lang, u *domain.User) string {
+	digest := l.T("value.off")
+	if u.DigestSec > 0 {
+		digest = l.Duration(u.DigestWindow())
+	}
+	body := l.T("menu.body",
+		l.Duration(time.Duration(u.IntervalSec)*time.Second),
+		domain.FormatMinutes(u.ActiveFromM), domain.FormatMinutes(u.ActiveToM),
+		u.TZ, u.Message, digest)
+	return l.T("menu.title") + "\n\n" + body + "\n\n" + l.T("menu.question")
+}
+
 // Inline keyboards
-func settingsInlineKeyboard() tgbotapi.InlineKeyboardMarkup {
+func settingsInlineKeyboard(l i18n.Lang) tgbotapi.InlineKeyboardMarkup {
 	return tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("⏲️ Interval", "set_interval"),
-			tgbotapi.NewInlineKeyboardButtonData("🕘 Active hours", "set_hours"),
+			tgbotapi.NewInlineKeyboardButtonData(l.T("btn.interval"), "set_interval"),
+			tgbotapi.NewInlineKeyboardButtonData(l.T("btn.hours"), "set_hours"),
 		),
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("🌍 Timezone", "set_tz"),
-			tgbotapi.NewInlineKeyboardButtonData("📝 Message", "set_msg"),
+			tgbotapi.NewInlineKeyboardButtonData(l.T("btn.tz"), "set_tz"),
+			tgbotapi.NewInlineKeyboardButtonData(l.T("btn.message"), "set_msg"),
 		),
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("🎵 Audio examples", "send_examples"),
+			tgbotapi.NewInlineKeyboardButtonData(l.T("btn.digest"), "set_digest"),
+			tgbotapi.NewInlineKeyboardButtonData(l.T("btn.examples"), "send_examples"),
 		),
 	)
 }
 
-func intervalPresetsKeyboard() tgbotapi.InlineKeyboardMarkup {
-	return tgbotapi.NewInlineKeyboardMarkup(
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("30m", "interval:30m"),
-			tgbotapi.NewInlineKeyboardButtonData("1h", "interval:1h"),
-			tgbotapi.NewInlineKeyboardButtonData("2h", "interval:2h"),
-			tgbotapi.NewInlineKeyboardButtonData("3h", "interval:3h"),
-		),
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("4h", "interval:4h"),
-			tgbotapi.NewInlineKeyboardButtonData("6h", "interval:6h"),
-			tgbotapi.NewInlineKeyboardButtonData("8h", "interval:8h"),
-		),
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("12h", "interval:12h"),
-			tgbotapi.NewInlineKeyboardButtonData("24h", "interval:24h"),
-			tgbotapi.NewInlineKeyboardButtonData("✍️ Custom…", "interval:custom"),
-		),
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("⬅️ Back", "back_to_menu"),
-		),
-	)
+// cancelKeyboard is attached to every prompt for free-form input.
+func cancelKeyboard(l i18n.Lang) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(l.T("btn.cancel"), "cancel"),
+	))
 }
 
-func hoursPresetsKeyboard() tgbotapi.InlineKeyboardMarkup {
-	return tgbotapi.NewInlineKeyboardMarkup(
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("08:00–22:00", "hours:08:00-22:00"),
-			tgbotapi.NewInlineKeyboardButtonData("09:00–21:00", "hours:09:00-21:00"),
-		),
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("22:00–02:00", "hours:22:00-02:00"),
-			tgbotapi.NewInlineKeyboardButtonData("✍️ Custom…", "hours:custom"),
-		),
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("⬅️ Back", "back_to_menu"),
-		),
-	)
+// ackKeyboard has one Done button per reminder; a single reminder gets a
+// plain "Done", digest items are numbered to match the message.
+func ackKeyboard(l i18n.Lang, items []scheduler.Reminder) tgbotapi.InlineKeyboardMarkup {
+	if len(items) == 1 {
+		return tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(l.T("btn.done"), ackData(items[0].DeliveryID)),
+		))
+	}
+	var rows [][]tgbotapi.InlineKeyboardButton
+	var row []tgbotapi.InlineKeyboardButton
+	for i, it := range items {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("✅ %d", i+1), ackData(it.DeliveryID)))
+		if len(row) == 5 {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
 }
 
-func tzPresetsKeyboard() tgbotapi.InlineKeyboardMarkup {
-	return tgbotapi.NewInlineKeyboardMarkup(
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("Europe/Moscow", "tz:Europe/Moscow"),
-			tgbotapi.NewInlineKeyboardButtonData("Europe/Tallinn", "tz:Europe/Tallinn"),
-		),
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("Asia/Almaty", "tz:Asia/Almaty"),
-			tgbotapi.NewInlineKeyboardButtonData("UTC", "tz:UTC"),
-		),
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("✍️ Custom…", "tz:custom"),
-		),
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("⬅️ Back", "back_to_menu"),
-		),
-	)
+func ackData(deliveryID int64) string {
+	return "ack:" + strconv.FormatInt(deliveryID, 10)
 }
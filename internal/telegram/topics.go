@@ -0,0 +1,218 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"github.com/ykvlv/notification-bot/internal/domain"
+)
+
+// Update is a tgbotapi.Update together with the forum topic it came from;
+// tgbotapi v5.5.1 predates topics and drops message_thread_id.
+type Update struct {
+	tgbotapi.Update
+	ThreadID int `json:"-"` // topic of the message or callback message; 0 = General / none
+}
+
+// topicFields are the message fields tgbotapi does not decode.
+type topicFields struct {
+	MessageThreadID int  `json:"message_thread_id"`
+	IsTopicMessage  bool `json:"is_topic_message"`
+}
+
+// UnmarshalJSON decodes the update and its topic. Replies in non-forum
+// groups also carry message_thread_id, so only topic messages count.
+func (u *Update) UnmarshalJSON(b []byte) error {
+	if err := json.Unmarshal(b, &u.Update); err != nil {
+		return err
+	}
+	var raw struct {
+		Message       *topicFields `json:"message"`
+		EditedMessage *topicFields `json:"edited_message"`
+		CallbackQuery *struct {
+			Message *topicFields `json:"message"`
+		} `json:"callback_query"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	m := raw.Message
+	if m == nil {
+		m = raw.EditedMessage
+	}
+	if m == nil && raw.CallbackQuery != nil {
+		m = raw.CallbackQuery.Message
+	}
+	u.ThreadID = 0
+	if m != nil && m.IsTopicMessage {
+		u.ThreadID = m.MessageThreadID
+	}
+	return nil
+}
+
+// PollUpdates long-polls getUpdates like BotAPI.GetUpdatesChan, but decodes
+// updates with their topic. stop ends polling and closes the channel.
+func PollUpdates(bot *tgbotapi.BotAPI, log *zap.Logger, timeout int) (updates <-chan Update, stop func()) {
+	ch := make(chan Update, bot.Buffer)
+	done := make(chan struct{})
+	go func() {
+		defer close(ch)
+		offset := 0
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			params := tgbotapi.Params{}
+			params.AddNonZero("offset", offset)
+			params.AddNonZero("timeout", timeout)
+			resp, err := bot.MakeRequest("getUpdates", params)
+			var batch []Update
+			if err == nil {
+				err = json.Unmarshal(resp.Result, &batch)
+			}
+			if err != nil {
+				log.Warn("getUpdates failed, retrying in 3s", zap.Error(err))
+				select {
+				case <-done:
+					return
+				case <-time.After(3 * time.Second):
+				}
+				continue
+			}
+			for _, u := range batch {
+				if u.UpdateID >= offset {
+					offset = u.UpdateID + 1
+				}
+				select {
+				case ch <- u:
+				case <-done:
+					return
+				}
+			}
+		}
+	}()
+	var once sync.Once
+	return ch, func() { once.Do(func() { close(done) }) }
+}
+
+// setTopic remembers the topic of the update being handled in a chat, so
+// that responses land next to the command. Updates are handled one at a
+// time, so it is the topic of the current update until endUpdate.
+func (r *Router) setTopic(chatID int64, threadID int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if threadID == 0 {
+		delete(r.topics, chatID)
+		return
+	}
+	r.topics[chatID] = threadID
+}
+
+// endUpdate forgets the topic and language of the update just handled in a
+// chat, so only chats with an update in progress have entries.
+func (r *Router) endUpdate(chatID int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.topics, chatID)
+	delete(r.langs, chatID)
+}
+
+// topic returns the topic responses to a chat go to (0 = General / none).
+func (r *Router) topic(chatID int64) int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.topics[chatID]
+}
+
+// configuredHere makes the topic the settings are changed from the one
+// the user's reminders are posted to.
+func (r *Router) configuredHere(u *domain.User) {
+	u.ThreadID = r.topic(u.ChatID)
+}
+
+// reminderTopic returns the topic a chat's reminders are posted to.
+func (r *Router) reminderTopic(chatID int64) int {
+	u, err := r.repo.GetUser(context.Background(), chatID)
+	if err != nil {
+		return 0
+	}
+	return u.ThreadID
+}
+
+// send is bot.Send for router responses: new messages go to the topic of
+// the update being handled.
+func (r *Router) send(c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	switch m := c.(type) {
+	case tgbotapi.MessageConfig:
+		return sendInTopic(r.bot, m, r.topic(m.ChatID))
+	case tgbotapi.AudioConfig:
+		if thread := r.topic(m.ChatID); thread != 0 {
+			return sendAudioInTopic(r.bot, m, thread)
+		}
+	}
+	return r.bot.Send(c)
+}
+
+// sendInTopic sends m to a forum topic. The library's MessageConfig has no
+// message_thread_id, so the request is built by hand.
+func sendInTopic(bot *tgbotapi.BotAPI, m tgbotapi.MessageConfig, threadID int) (tgbotapi.Message, error) {
+	if threadID == 0 {
+		return bot.Send(m)
+	}
+	params, err := baseChatParams(m.BaseChat, threadID)
+	if err != nil {
+		return tgbotapi.Message{}, err
+	}
+	params["text"] = m.Text
+	params.AddNonEmpty("parse_mode", m.ParseMode)
+	params.AddBool("disable_web_page_preview", m.DisableWebPagePreview)
+	if err := params.AddInterface("entities", m.Entities); err != nil {
+		return tgbotapi.Message{}, err
+	}
+	resp, err := bot.MakeRequest("sendMessage", params)
+	return decodeMessage(resp, err)
+}
+
+// sendAudioInTopic is sendInTopic for audio uploads.
+func sendAudioInTopic(bot *tgbotapi.BotAPI, a tgbotapi.AudioConfig, threadID int) (tgbotapi.Message, error) {
+	params, err := baseChatParams(a.BaseChat, threadID)
+	if err != nil {
+		return tgbotapi.Message{}, err
+	}
+	params.AddNonZero("duration", a.Duration)
+	params.AddNonEmpty("performer", a.Performer)
+	params.AddNonEmpty("title", a.Title)
+	params.AddNonEmpty("caption", a.Caption)
+	params.AddNonEmpty("parse_mode", a.ParseMode)
+	resp, err := bot.UploadFiles("sendAudio", params, []tgbotapi.RequestFile{{Name: "audio", Data: a.File}})
+	return decodeMessage(resp, err)
+}
+
+func baseChatParams(c tgbotapi.BaseChat, threadID int) (tgbotapi.Params, error) {
+	params := tgbotapi.Params{}
+	if err := params.AddFirstValid("chat_id", c.ChatID, c.ChannelUsername); err != nil {
+		return nil, err
+	}
+	params.AddNonZero("message_thread_id", threadID)
+	params.AddNonZero("reply_to_message_id", c.ReplyToMessageID)
+	params.AddBool("disable_notification", c.DisableNotification)
+	params.AddBool("allow_sending_without_reply", c.AllowSendingWithoutReply)
+	err := params.AddInterface("reply_markup", c.ReplyMarkup)
+	return params, err
+}
+
+func decodeMessage(resp *tgbotapi.APIResponse, err error) (tgbotapi.Message, error) {
+	var msg tgbotapi.Message
+	if err != nil {
+		return msg, err
+	}
+	err = json.Unmarshal(resp.Result, &msg)
+	return msg, err
+}
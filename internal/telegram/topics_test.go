@@ -0,0 +1,88 @@
+package telegram
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+func TestUpdate_DecodesTopic(t *testing.T) {
+	cases := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"topic message", `{"update_id":1,"message":{"message_id":5,"message_thread_id":3,"is_topic_message":true,"chat":{"id":-100},"text":"/start"}}`, 3},
+		{"reply in a non-forum group", `{"update_id":1,"message":{"message_id":5,"message_thread_id":4,"chat":{"id":-100},"text":"hi"}}`, 0},
+		{"callback in a topic", `{"update_id":1,"callback_query":{"id":"q","data":"set_tz","message":{"message_id":6,"message_thread_id":7,"is_topic_message":true,"chat":{"id":-100}}}}`, 7},
+		{"private chat", `{"update_id":1,"message":{"message_id":5,"chat":{"id":42},"text":"/start"}}`, 0},
+	}
+	for _, c := range cases {
+		var u Update
+		if err := json.Unmarshal([]byte(c.body), &u); err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if u.ThreadID != c.want {
+			t.Errorf("%s: ThreadID = %d, want %d", c.name, u.ThreadID, c.want)
+		}
+		if u.UpdateID != 1 || (u.Message == nil && u.CallbackQuery == nil) {
+			t.Errorf("%s: embedded update not decoded: %+v", c.name, u.Update)
+		}
+	}
+}
+
+func TestSendInTopic(t *testing.T) {
+	bot, stub := newStubBot(t)
+	stub.results = map[string]string{"sendMessage": `{"message_id":9,"chat":{"id":-100}}`}
+
+	msg := tgbotapi.NewMessage(-100, "hello")
+	msg.ReplyMarkup = tgbotapi.ForceReply{ForceReply: true, Selective: true}
+	sent, err := sendInTopic(bot, msg, 3)
+	if err != nil {
+		t.Fatalf("sendInTopic: %v", err)
+	}
+	if sent.MessageID != 9 {
+		t.Fatalf("MessageID = %d, want 9", sent.MessageID)
+	}
+	c := stub.last()
+	if c.method != "sendMessage" || c.params["chat_id"] != "-100" || c.params["message_thread_id"] != "3" ||
+		c.params["text"] != "hello" || c.params["reply_markup"] != `{"force_reply":true,"selective":true}` {
+		t.Fatalf("unexpected call %+v", c)
+	}
+
+	// Without a topic the library request is used unchanged.
+	if _, err := sendInTopic(bot, tgbotapi.NewMessage(42, "hi"), 0); err != nil {
+		t.Fatalf("sendInTopic: %v", err)
+	}
+	if _, ok := stub.last().params["message_thread_id"]; ok {
+		t.Fatal("message_thread_id sent outside a topic")
+	}
+}
+
+func TestPollUpdates(t *testing.T) {
+	bot, stub := newStubBot(t)
+	stub.results = map[string]string{"getUpdates": `[{"update_id":10,"message":{"message_id":1,"message_thread_id":3,"is_topic_message":true,"chat":{"id":-100},"text":"/status"}}]`}
+
+	updates, stop := PollUpdates(bot, zap.NewNop(), 0)
+	select {
+	case u := <-updates:
+		if u.UpdateID != 10 || u.ThreadID != 3 || u.Message.Text != "/status" {
+			t.Fatalf("unexpected update %+v", u)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no update received")
+	}
+	<-updates // the stub repeats the batch: the next poll must ask for offset 11
+	stop()
+	for range updates {
+	}
+
+	stub.mu.Lock()
+	defer stub.mu.Unlock()
+	if got := stub.calls[len(stub.calls)-1].params["offset"]; got != "11" {
+		t.Fatalf("offset = %q, want 11", got)
+	}
+}
@@ -0,0 +1,106 @@
+package telegram
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// SecretTokenHeader carries the secret_token given to setWebhook on every
+// update Telegram delivers.
+const SecretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// WebhookConfig describes how Telegram reaches the bot in webhook mode.
+type WebhookConfig struct {
+	URL            string // public base URL, e.g. https://bot.example.com:8443
+	Path           string // path the updates are POSTed to, e.g. /telegram/webhook
+	SecretToken    string // 1-256 chars of A-Z, a-z, 0-9, _ and -
+	CertFile       string // optional PEM certificate to upload (self-signed setups)
+	MaxConnections int    // 1-100; 0 leaves Telegram's default (40)
+}
+
+// Endpoint returns the full webhook URL (base URL plus path).
+func (c WebhookConfig) Endpoint() (string, error) {
+	u, err := url.Parse(c.URL)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return "", errors.New("webhook URL must be an absolute https URL")
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + strings.TrimPrefix(c.Path, "/")
+	return u.String(), nil
+}
+
+// SetWebhook registers the webhook with Telegram, uploading the certificate
+// if one is configured. The library's WebhookConfig has no secret_token, so
+// the request is built by hand.
+func SetWebhook(bot *tgbotapi.BotAPI, cfg WebhookConfig) error {
+	endpoint, err := cfg.Endpoint()
+	if err != nil {
+		return err
+	}
+	params := tgbotapi.Params{"url": endpoint}
+	params.AddNonEmpty("secret_token", cfg.SecretToken)
+	params.AddNonZero("max_connections", cfg.MaxConnections)
+
+	if cfg.CertFile == "" {
+		_, err = bot.MakeRequest("setWebhook", params)
+		return err
+	}
+	_, err = bot.UploadFiles("setWebhook", params, []tgbotapi.RequestFile{{
+		Name: "certificate",
+		Data: tgbotapi.FilePath(cfg.CertFile),
+	}})
+	return err
+}
+
+// NewSecretToken returns a random secret token for setWebhook.
+func NewSecretToken() (string, error) {
+	var b [32]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b[:]), nil
+}
+
+// DeleteWebhook unregisters the webhook; pending updates are kept for the
+// next start.
+func DeleteWebhook(bot *tgbotapi.BotAPI) error {
+	_, err := bot.Request(tgbotapi.DeleteWebhookConfig{})
+	return err
+}
+
+// WebhookHandler accepts updates POSTed by Telegram and passes them to out.
+// Requests without the expected secret token are rejected. The response is
+// sent once the update is queued, so a full channel slows Telegram down
+// instead of dropping updates.
+func WebhookHandler(secretToken string, out chan<- Update) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		got := r.Header.Get(SecretTokenHeader)
+		if subtle.ConstantTimeCompare([]byte(got), []byte(secretToken)) != 1 {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		var upd Update
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&upd); err != nil {
+			http.Error(w, "bad update", http.StatusBadRequest)
+			return
+		}
+		select {
+		case out <- upd:
+			w.WriteHeader(http.StatusOK)
+		case <-r.Context().Done():
+			http.Error(w, "timeout", http.StatusServiceUnavailable)
+		}
+	})
+}
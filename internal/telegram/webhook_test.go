@@ -0,0 +1,163 @@
+package telegram
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// stubBotAPI is a local stand-in for the Bot API that records method calls.
+type stubBotAPI struct {
+	mu      sync.Mutex
+	calls   []stubCall
+	results map[string]string // method -> JSON result (default true)
+}
+
+type stubCall struct {
+	method string
+	params map[string]string
+	cert   string // uploaded certificate contents, if any
+}
+
+func (s *stubBotAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	method := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+	call := stubCall{method: method, params: map[string]string{}}
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/") {
+		if err := r.ParseMultipartForm(1 << 20); err == nil {
+			for k, v := range r.MultipartForm.Value {
+				call.params[k] = v[0]
+			}
+			if fh := r.MultipartForm.File["certificate"]; len(fh) == 1 {
+				f, _ := fh[0].Open()
+				b, _ := io.ReadAll(f)
+				call.cert = string(b)
+			}
+		}
+	} else if err := r.ParseForm(); err == nil {
+		for k, v := range r.PostForm {
+			call.params[k] = v[0]
+		}
+	}
+	s.mu.Lock()
+	s.calls = append(s.calls, call)
+	result, ok := s.results[method]
+	s.mu.Unlock()
+
+	if !ok {
+		result = `true`
+	}
+	if method == "getMe" {
+		result = `{"id":1,"is_bot":true,"first_name":"Test","username":"test_bot"}`
+	}
+	_, _ = io.WriteString(w, `{"ok":true,"result":`+result+`}`)
+}
+
+func (s *stubBotAPI) last() stubCall {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls[len(s.calls)-1]
+}
+
+func newStubBot(t *testing.T) (*tgbotapi.BotAPI, *stubBotAPI) {
+	t.Helper()
+	stub := &stubBotAPI{}
+	srv := httptest.NewServer(stub)
+	t.Cleanup(srv.Close)
+	bot, err := tgbotapi.NewBotAPIWithClient("TOKEN", srv.URL+"/bot%s/%s", srv.Client())
+	if err != nil {
+		t.Fatalf("NewBotAPIWithClient: %v", err)
+	}
+	return bot, stub
+}
+
+func TestSetWebhook(t *testing.T) {
+	bot, stub := newStubBot(t)
+	cfg := WebhookConfig{
+		URL:            "https://bot.example.com:8443/",
+		Path:           "/telegram/webhook",
+		SecretToken:    "s3cret",
+		MaxConnections: 10,
+	}
+	if err := SetWebhook(bot, cfg); err != nil {
+		t.Fatalf("SetWebhook: %v", err)
+	}
+	c := stub.last()
+	if c.method != "setWebhook" || c.params["url"] != "https://bot.example.com:8443/telegram/webhook" ||
+		c.params["secret_token"] != "s3cret" || c.params["max_connections"] != "10" {
+		t.Fatalf("unexpected call %+v", c)
+	}
+
+	// With a certificate the request is a multipart upload.
+	cfg.CertFile = filepath.Join(t.TempDir(), "cert.pem")
+	if err := os.WriteFile(cfg.CertFile, []byte("-----BEGIN CERTIFICATE-----"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetWebhook(bot, cfg); err != nil {
+		t.Fatalf("SetWebhook with cert: %v", err)
+	}
+	if c := stub.last(); c.cert != "-----BEGIN CERTIFICATE-----" || c.params["secret_token"] != "s3cret" {
+		t.Fatalf("certificate not uploaded: %+v", c)
+	}
+
+	if err := DeleteWebhook(bot); err != nil {
+		t.Fatalf("DeleteWebhook: %v", err)
+	}
+	if c := stub.last(); c.method != "deleteWebhook" {
+		t.Fatalf("want deleteWebhook, got %s", c.method)
+	}
+
+	cfg.URL = "http://insecure.example.com"
+	if err := SetWebhook(bot, cfg); err == nil {
+		t.Fatal("plain http URL must be rejected")
+	}
+}
+
+func TestWebhookHandler_VerifiesSecret(t *testing.T) {
+	out := make(chan Update, 1)
+	srv := httptest.NewServer(WebhookHandler("s3cret", out))
+	defer srv.Close()
+
+	post := func(secret, body string) int {
+		req, _ := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader(body))
+		if secret != "" {
+			req.Header.Set(SecretTokenHeader, secret)
+		}
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	update, _ := json.Marshal(tgbotapi.Update{UpdateID: 42, Message: &tgbotapi.Message{Text: "/start"}})
+	if code := post("", string(update)); code != http.StatusForbidden {
+		t.Fatalf("missing secret: got %d", code)
+	}
+	if code := post("wrong", string(update)); code != http.StatusForbidden {
+		t.Fatalf("wrong secret: got %d", code)
+	}
+	if code := post("s3cret", "{"); code != http.StatusBadRequest {
+		t.Fatalf("bad body: got %d", code)
+	}
+	if code := post("s3cret", string(update)); code != http.StatusOK {
+		t.Fatalf("valid update: got %d", code)
+	}
+	select {
+	case u := <-out:
+		if u.UpdateID != 42 || u.Message.Text != "/start" {
+			t.Fatalf("unexpected update %+v", u)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("update not delivered")
+	}
+}
@@ -0,0 +1,157 @@
+package telegram
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/ykvlv/notification-bot/internal/domain"
+	"github.com/ykvlv/notification-bot/internal/i18n"
+)
+
+// webhookLogSize is how many recent webhook events /webhooks shows.
+const webhookLogSize = 5
+
+// handleWebhooks shows or manages the chat's outgoing webhook:
+// "/webhooks", "/webhooks set <url>", "/webhooks rotate", "/webhooks off".
+func (r *Router) handleWebhooks(ctx context.Context, chatID int64, arg string) {
+	sub, rest, _ := strings.Cut(arg, " ")
+	switch strings.ToLower(sub) {
+	case "":
+		r.showWebhook(ctx, chatID)
+	case "set", "rotate":
+		if isGroupChat(chatID) {
+			// Both reply with the signing secret, which every member would see.
+			r.sendText(chatID, r.tr(chatID, "webhooks.private_only"))
+			return
+		}
+		if strings.EqualFold(sub, "set") {
+			r.setWebhook(ctx, chatID, strings.TrimSpace(rest))
+		} else {
+			r.rotateWebhookSecret(ctx, chatID)
+		}
+	case "off":
+		if err := r.repo.DeleteWebhook(ctx, chatID); err != nil {
+			r.log.Error("DeleteWebhook failed", zap.Error(err))
+			r.sendText(chatID, r.tr(chatID, "webhooks.remove_failed"))
+			return
+		}
+		r.sendText(chatID, r.tr(chatID, "webhooks.removed"))
+	default:
+		r.sendText(chatID, r.tr(chatID, "webhooks.usage"))
+	}
+}
+
+// showWebhook prints the webhook URL and its recent delivery log.
+func (r *Router) showWebhook(ctx context.Context, chatID int64) {
+	l := r.lang(chatID)
+	w, err := r.repo.GetWebhook(ctx, chatID)
+	if errors.Is(err, sql.ErrNoRows) {
+		r.sendText(chatID, l.T("webhooks.none")+"\n\n"+l.T("webhooks.usage"))
+		return
+	}
+	if err != nil {
+		r.log.Error("GetWebhook failed", zap.Error(err))
+		r.sendText(chatID, l.T("webhooks.load_failed"))
+		return
+	}
+	events, err := r.repo.ListWebhookEvents(ctx, chatID, webhookLogSize)
+	if err != nil {
+		r.log.Error("ListWebhookEvents failed", zap.Error(err))
+		r.sendText(chatID, l.T("webhooks.log_failed"))
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString(l.T("webhooks.info", w.URL, w.Secret[:6]) + "\n\n")
+	if len(events) == 0 {
+		b.WriteString(l.T("webhooks.no_events") + "\n")
+	} else {
+		b.WriteString(l.T("webhooks.recent") + "\n")
+		for _, e := range events {
+			b.WriteString(formatWebhookEvent(l, e) + "\n")
+		}
+	}
+	b.WriteString("\n" + l.T("webhooks.usage"))
+	r.sendText(chatID, b.String())
+}
+
+// formatWebhookEvent renders one log line, times in UTC.
+func formatWebhookEvent(l i18n.Lang, e domain.WebhookEvent) string {
+	when := e.CreatedAt.Format("2006-01-02 15:04")
+	switch e.Status {
+	case domain.WebhookSent:
+		return fmt.Sprintf("✅ #%d %s — HTTP %d", e.ID, when, e.ResponseCode)
+	case domain.WebhookPending, domain.WebhookSending:
+		if e.Attempts > 0 {
+			return fmt.Sprintf("⏳ #%d %s — %s", e.ID, when, l.T("history.retrying", e.Attempts, e.Error))
+		}
+		return fmt.Sprintf("⏳ #%d %s", e.ID, when)
+	default:
+		return fmt.Sprintf("❌ #%d %s — %s", e.ID, when, e.Error)
+	}
+}
+
+// setWebhook sets the URL, keeping the current secret or creating one.
+func (r *Router) setWebhook(ctx context.Context, chatID int64, rawURL string) {
+	u, err := domain.ValidateWebhookURL(rawURL)
+	if err != nil {
+		r.sendText(chatID, r.tr(chatID, "webhooks.bad_url"))
+		return
+	}
+	w, err := r.repo.GetWebhook(ctx, chatID)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		w = &domain.Webhook{ChatID: chatID}
+	case err != nil:
+		r.log.Error("GetWebhook failed", zap.Error(err))
+		r.sendText(chatID, r.tr(chatID, "webhooks.failed"))
+		return
+	}
+	fresh := w.Secret == ""
+	if fresh {
+		if w.Secret, err = domain.NewWebhookSecret(); err != nil {
+			r.log.Error("NewWebhookSecret failed", zap.Error(err))
+			r.sendText(chatID, r.tr(chatID, "webhooks.failed"))
+			return
+		}
+	}
+	w.URL = u
+	w.CreatedAt = time.Now().UTC()
+	if err := r.repo.SetWebhook(ctx, w); err != nil {
+		r.log.Error("SetWebhook failed", zap.Error(err))
+		r.sendText(chatID, r.tr(chatID, "webhooks.failed"))
+		return
+	}
+	if fresh {
+		r.sendText(chatID, r.tr(chatID, "webhooks.set_secret", u, w.Secret))
+		return
+	}
+	r.sendText(chatID, r.tr(chatID, "webhooks.set", u))
+}
+
+// rotateWebhookSecret replaces the signing secret and shows the new one.
+func (r *Router) rotateWebhookSecret(ctx context.Context, chatID int64) {
+	w, err := r.repo.GetWebhook(ctx, chatID)
+	if errors.Is(err, sql.ErrNoRows) {
+		r.sendText(chatID, r.tr(chatID, "webhooks.none")+"\n\n"+r.tr(chatID, "webhooks.usage"))
+		return
+	}
+	if err == nil {
+		w.Secret, err = domain.NewWebhookSecret()
+	}
+	if err == nil {
+		err = r.repo.SetWebhook(ctx, w)
+	}
+	if err != nil {
+		r.log.Error("webhook secret rotation failed", zap.Error(err))
+		r.sendText(chatID, r.tr(chatID, "webhooks.rotate_failed"))
+		return
+	}
+	r.sendText(chatID, r.tr(chatID, "webhooks.rotated", w.Secret))
+}
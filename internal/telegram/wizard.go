@@ -0,0 +1,357 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"github.com/ykvlv/notification-bot/internal/domain"
+	"github.com/ykvlv/notification-bot/internal/i18n"
+)
+
+// A form is a declarative settings flow: one or more steps, each answered
+// by a preset button or typed input, then an optional summary to confirm,
+// then Apply. Progress is kept as the member's pending state (see
+// setPending), so it survives restarts.
+//
+// Screens are edited into one menu message per member, from /settings to
+// the result of Apply. The state records that message, so buttons of any
+// other (older) menu are rejected with a toast.
+//
+// Callback data is "f:<form>:<step>:<action>", where action is "=<value>"
+// for a preset, "custom", "back" or "ok" (confirm). The step index lets the
+// engine reject buttons of a screen the member has already left.
+type form struct {
+	ID      string
+	Steps   []step
+	Confirm bool // show Summary with Save/Back after the last step
+
+	// Summary renders the answers on the confirm screen.
+	Summary func(l i18n.Lang, answers url.Values) string
+	// Apply saves the answers and returns the line shown above the menu,
+	// in the chat's language.
+	Apply func(ctx context.Context, r *Router, chatID int64, answers url.Values) (string, error)
+	// Failed is shown when Apply returns an error.
+	Failed string
+}
+
+// A step asks for one value, stored under Key. Prompt, Custom, the result
+// of Invalid and preset labels are i18n catalog keys; labels that are not
+// in the catalog are shown as is.
+type step struct {
+	Key     string
+	Prompt  string     // shown above the presets
+	Presets [][]preset // keyboard rows; none means typed input only
+	Custom  string     // prompt for typed input; "" disables typing when there are presets
+
+	// Parse validates a preset or typed value and returns its canonical form.
+	Parse func(string) (string, error)
+	// Invalid explains a Parse error to the member.
+	Invalid func(error) string
+	// Current returns the user's value in Parse's canonical form; it is
+	// shown on the step and marks the matching preset.
+	Current func(u *domain.User) string
+	// Format shows a canonical value without a preset; nil shows it as is.
+	Format func(l i18n.Lang, value string) string
+}
+
+type preset struct {
+	Label string
+	Value string
+}
+
+// typed reports whether the step is answered by typing only.
+func (s step) typed() bool { return len(s.Presets) == 0 }
+
+// formStatePrefix marks pending states that belong to a form or menu.
+const formStatePrefix = "form:"
+
+// formState is a member's position in the settings menu. Form is "" on the
+// menu itself. Settings forms store their progress as formState.
+type formState struct {
+	Form    string
+	Step    int // len(Steps) is the confirm screen
+	Typing  bool
+	Menu    int // message ID of the menu; 0 when none is shown
+	Answers url.Values
+}
+
+func (s formState) encode() string {
+	typing := 0
+	if s.Typing {
+		typing = 1
+	}
+	return fmt.Sprintf("%s%s:%d:%d:%d?%s", formStatePrefix, s.Form, s.Step, typing, s.Menu, s.Answers.Encode())
+}
+
+// decodeFormState parses a pending state written by encode.
+func decodeFormState(state string) (formState, bool) {
+	rest, ok := strings.CutPrefix(state, formStatePrefix)
+	if !ok {
+		return formState{}, false
+	}
+	head, query, _ := strings.Cut(rest, "?")
+	parts := strings.Split(head, ":")
+	if len(parts) != 4 {
+		return formState{}, false
+	}
+	stepIdx, err1 := strconv.Atoi(parts[1])
+	menu, err2 := strconv.Atoi(parts[3])
+	answers, err3 := url.ParseQuery(query)
+	if err1 != nil || err2 != nil || err3 != nil {
+		return formState{}, false
+	}
+	return formState{Form: parts[0], Step: stepIdx, Typing: parts[2] == "1", Menu: menu, Answers: answers}, true
+}
+
+func formData(formID string, stepIdx int, action string) string {
+	return fmt.Sprintf("f:%s:%d:%s", formID, stepIdx, action)
+}
+
+// menuState returns the member's state if messageID is their current menu.
+func (r *Router) menuState(ctx context.Context, chatID int64, from *tgbotapi.User, messageID int) (formState, bool) {
+	state, _ := r.getPending(ctx, chatID, userID(from))
+	st, ok := decodeFormState(state)
+	return st, ok && st.Menu != 0 && st.Menu == messageID
+}
+
+// present shows a screen of the member's menu and saves st. inPlace edits
+// the menu message; otherwise, or if the edit fails, the old menu loses its
+// buttons and the screen is sent as a new message, which becomes the menu.
+func (r *Router) present(ctx context.Context, chatID int64, from *tgbotapi.User, st formState, text string, kb *tgbotapi.InlineKeyboardMarkup, inPlace bool) {
+	if inPlace && st.Menu != 0 {
+		if err := r.edit(chatID, st.Menu, text, kb); err == nil {
+			r.setPending(ctx, chatID, userID(from), st.encode())
+			return
+		}
+	}
+	r.retireMenu(chatID, st.Menu)
+	msg := tgbotapi.NewMessage(chatID, text)
+	if kb != nil {
+		msg.ReplyMarkup = *kb
+	}
+	st.Menu = 0
+	if sent, err := r.send(msg); err == nil {
+		st.Menu = sent.MessageID
+	}
+	r.setPending(ctx, chatID, userID(from), st.encode())
+}
+
+// startForm opens a form at its first step, discarding earlier progress.
+func (r *Router) startForm(ctx context.Context, chatID int64, from *tgbotapi.User, formID string, menu int) {
+	f := formByID(formID)
+	if f == nil {
+		return
+	}
+	r.showStep(ctx, chatID, from, f, formState{Form: f.ID, Menu: menu, Answers: url.Values{}}, true)
+}
+
+// showStep saves the state and renders its screen: presets, a typed-input
+// prompt or the confirm summary.
+func (r *Router) showStep(ctx context.Context, chatID int64, from *tgbotapi.User, f *form, st formState, inPlace bool) {
+	l := r.lang(chatID)
+	if st.Step == len(f.Steps) {
+		st.Typing = false
+		kb := tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(l.T("btn.save"), formData(f.ID, st.Step, "ok")),
+				tgbotapi.NewInlineKeyboardButtonData(l.T("btn.back"), formData(f.ID, st.Step, "back")),
+			),
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(l.T("btn.cancel"), "cancel"),
+			),
+		)
+		r.present(ctx, chatID, from, st, f.Summary(l, st.Answers), &kb, inPlace)
+		return
+	}
+
+	s := f.Steps[st.Step]
+	st.Typing = st.Typing || s.typed()
+	current := st.Answers.Get(s.Key)
+	if u, err := r.repo.GetUser(ctx, chatID); err == nil && current == "" && s.Current != nil {
+		current = s.Current(u)
+	}
+
+	if st.Typing {
+		text := l.T(s.Custom)
+		if s.typed() {
+			text = l.T(s.Prompt)
+		}
+		if current != "" {
+			text += l.T("form.current", s.label(l, current))
+		}
+		// Groups answer by replying to a fresh prompt; see prompt.
+		if isGroupChat(chatID) {
+			r.retireMenu(chatID, st.Menu)
+			st.Menu = 0
+			r.setPending(ctx, chatID, userID(from), st.encode())
+			r.prompt(chatID, from, text)
+			return
+		}
+		kb := cancelKeyboard(l)
+		r.present(ctx, chatID, from, st, text, &kb, inPlace)
+		return
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, row := range s.Presets {
+		var buttons []tgbotapi.InlineKeyboardButton
+		for _, p := range row {
+			label := presetLabel(l, p.Label)
+			if current != "" && s.canonical(p.Value) == current {
+				label = "✓ " + label
+			}
+			buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData(label, formData(f.ID, st.Step, "="+p.Value)))
+		}
+		rows = append(rows, buttons)
+	}
+	if s.Custom != "" {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(l.T("btn.custom"), formData(f.ID, st.Step, "custom")),
+		))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(l.T("btn.back"), formData(f.ID, st.Step, "back")),
+	))
+	text := l.T(s.Prompt)
+	if current != "" {
+		text += l.T("form.current", s.label(l, current))
+	}
+	kb := tgbotapi.NewInlineKeyboardMarkup(rows...)
+	r.present(ctx, chatID, from, st, text, &kb, inPlace)
+}
+
+// canonical returns a preset value in Parse's canonical form.
+func (s step) canonical(value string) string {
+	v, err := s.Parse(value)
+	if err != nil {
+		return value
+	}
+	return v
+}
+
+// label names a canonical value after its preset, if it has one.
+func (s step) label(l i18n.Lang, value string) string {
+	for _, row := range s.Presets {
+		for _, p := range row {
+			if s.canonical(p.Value) == value {
+				return presetLabel(l, p.Label)
+			}
+		}
+	}
+	if s.Format != nil {
+		return s.Format(l, value)
+	}
+	return value
+}
+
+// presetLabel translates a preset label that is a catalog key.
+func presetLabel(l i18n.Lang, label string) string {
+	if s, ok := l.Lookup(label); ok {
+		return s
+	}
+	return label
+}
+
+// handleMenuCallback opens the form behind a settings menu button.
+func (r *Router) handleMenuCallback(ctx context.Context, cb *tgbotapi.CallbackQuery) {
+	st, ok := r.menuState(ctx, cb.Message.Chat.ID, cb.From, cb.Message.MessageID)
+	if !ok {
+		_ = r.answerCallback(cb.ID, r.tr(cb.Message.Chat.ID, "menu.stale"))
+		return
+	}
+	_ = r.answerCallback(cb.ID, "")
+	r.startForm(ctx, cb.Message.Chat.ID, cb.From, settingsFormIDs[cb.Data], st.Menu)
+}
+
+// handleFormCallback handles the buttons of a form screen.
+func (r *Router) handleFormCallback(ctx context.Context, cb *tgbotapi.CallbackQuery) {
+	chatID := cb.Message.Chat.ID
+	parts := strings.SplitN(cb.Data, ":", 4)
+	if len(parts) != 4 {
+		_ = r.answerCallback(cb.ID, "")
+		return
+	}
+	f := formByID(parts[1])
+	stepIdx, err := strconv.Atoi(parts[2])
+	st, ok := r.menuState(ctx, chatID, cb.From, cb.Message.MessageID)
+	if f == nil || err != nil || !ok || st.Form != f.ID || st.Step != stepIdx {
+		_ = r.answerCallback(cb.ID, r.tr(chatID, "menu.stale"))
+		return
+	}
+	_ = r.answerCallback(cb.ID, "")
+
+	switch action := parts[3]; {
+	case action == "back":
+		if st.Step == 0 {
+			r.showSettingsMenu(ctx, chatID, cb.From, st.Menu, "", true)
+			return
+		}
+		st.Step--
+		st.Typing = false
+		r.showStep(ctx, chatID, cb.From, f, st, true)
+
+	case action == "custom" && st.Step < len(f.Steps):
+		st.Typing = true
+		r.showStep(ctx, chatID, cb.From, f, st, true)
+
+	case strings.HasPrefix(action, "=") && st.Step < len(f.Steps):
+		r.answerStep(ctx, chatID, cb.From, f, st, strings.TrimPrefix(action, "="), true)
+
+	case action == "ok" && st.Step == len(f.Steps):
+		r.finishForm(ctx, chatID, cb.From, f, st, true)
+	}
+}
+
+// handleFormInput takes typed input for the current step. Invalid input
+// keeps the step open so the member can try again. The next screen is a
+// new message, below the member's answer.
+func (r *Router) handleFormInput(ctx context.Context, chatID int64, from *tgbotapi.User, st formState, text string) {
+	f := formByID(st.Form)
+	if f == nil || st.Step >= len(f.Steps) {
+		r.clearPending(ctx, chatID, userID(from))
+		return
+	}
+	r.answerStep(ctx, chatID, from, f, st, text, false)
+}
+
+// answerStep validates a value for the current step and moves on: to the
+// next step, the confirm screen or straight to Apply.
+func (r *Router) answerStep(ctx context.Context, chatID int64, from *tgbotapi.User, f *form, st formState, value string, inPlace bool) {
+	s := f.Steps[st.Step]
+	v, err := s.Parse(strings.TrimSpace(value))
+	if err != nil {
+		text := r.tr(chatID, s.Invalid(err))
+		if st.Typing {
+			text += r.tr(chatID, "form.try_again")
+		}
+		r.sendText(chatID, text)
+		return
+	}
+	st.Answers.Set(s.Key, v)
+	st.Step++
+	st.Typing = false
+	if st.Step == len(f.Steps) && !f.Confirm {
+		r.finishForm(ctx, chatID, from, f, st, inPlace)
+		return
+	}
+	r.showStep(ctx, chatID, from, f, st, inPlace)
+}
+
+// finishForm applies the answers and returns to the settings menu, headed
+// by the outcome.
+func (r *Router) finishForm(ctx context.Context, chatID int64, from *tgbotapi.User, f *form, st formState, inPlace bool) {
+	reply, err := f.Apply(ctx, r, chatID, st.Answers)
+	if err != nil {
+		r.log.Error("form apply failed", zap.String("form", f.ID), zap.Error(err))
+		reply = "⚠️ " + r.tr(chatID, f.Failed)
+	} else {
+		reply = "✅ " + reply
+	}
+	r.showSettingsMenu(ctx, chatID, from, st.Menu, reply, inPlace)
+}
@@ -0,0 +1,186 @@
+package telegram
+
+import (
+	"context"
+	"database/sql"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"github.com/ykvlv/notification-bot/internal/domain"
+	"github.com/ykvlv/notification-bot/internal/i18n"
+	"github.com/ykvlv/notification-bot/internal/store"
+)
+
+// convRepo keeps conversations and one user in memory; other Repo methods
+// are not used.
+type convRepo struct {
+	store.Repo
+	convs map[pendingKey]domain.Conversation
+	user  *domain.User
+}
+
+func (c *convRepo) GetUser(context.Context, int64) (*domain.User, error) {
+	if c.user == nil {
+		return nil, sql.ErrNoRows
+	}
+	u := *c.user
+	return &u, nil
+}
+
+func (c *convRepo) GetConversation(_ context.Context, chatID, userID int64) (*domain.Conversation, error) {
+	v, ok := c.convs[pendingKey{chatID, userID}]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &v, nil
+}
+
+func (c *convRepo) SetConversation(_ context.Context, v *domain.Conversation) error {
+	c.convs[pendingKey{v.ChatID, v.UserID}] = *v
+	return nil
+}
+
+func (c *convRepo) DeleteConversation(_ context.Context, chatID, userID int64) error {
+	delete(c.convs, pendingKey{chatID, userID})
+	return nil
+}
+
+func (c *convRepo) DeleteExpiredConversations(context.Context, time.Time) (int64, error) {
+	return 0, nil
+}
+
+func TestWizard_StepsBackAndConfirm(t *testing.T) {
+	var applied url.Values
+	settingsForms = append(settingsForms, &form{
+		ID: "test",
+		Steps: []step{
+			{
+				Key:     "n",
+				Prompt:  "Pick a number",
+				Presets: [][]preset{{{"1", "1"}, {"2", "2"}}},
+				Custom:  "Type a number",
+				Parse: func(s string) (string, error) {
+					_, err := strconv.Atoi(s)
+					return s, err
+				},
+				Invalid: func(error) string { return "not a number" },
+				Current: func(*domain.User) string { return "2" },
+			},
+			{
+				Key:     "name",
+				Prompt:  "Type a name",
+				Parse:   func(s string) (string, error) { return s, nil },
+				Invalid: func(error) string { return "" },
+			},
+		},
+		Confirm: true,
+		Summary: func(_ i18n.Lang, a url.Values) string { return "n=" + a.Get("n") + " name=" + a.Get("name") },
+		Apply: func(_ context.Context, _ *Router, _ int64, a url.Values) (string, error) {
+			applied = a
+			return "saved", nil
+		},
+	})
+	defer func() { settingsForms = settingsForms[:len(settingsForms)-1] }()
+
+	bot, stub := newStubBot(t)
+	stub.results = map[string]string{"sendMessage": `{"message_id":1,"chat":{"id":7}}`}
+	repo := &convRepo{convs: map[pendingKey]domain.Conversation{}, user: &domain.User{ChatID: 7, TZ: "UTC", Language: "en"}}
+	r := NewRouter(bot, zap.NewNop(), repo, nil)
+	ctx := context.Background()
+	user := &tgbotapi.User{ID: 7}
+	chat := &tgbotapi.Chat{ID: 7, Type: "private"}
+
+	tapOn := func(messageID int, data string) {
+		r.HandleUpdate(ctx, Update{Update: tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+			ID: "q", From: user, Data: data, Message: &tgbotapi.Message{MessageID: messageID, Chat: chat},
+		}}})
+	}
+	tap := func(data string) { tapOn(1, data) }
+	say := func(text string) {
+		r.HandleUpdate(ctx, Update{Update: tgbotapi.Update{Message: &tgbotapi.Message{
+			MessageID: 2, From: user, Chat: chat, Text: text,
+		}}})
+	}
+	// Messages, edits and callback answers all carry "text".
+	lastText := func() string { return stub.last().params["text"] }
+
+	r.setLang(ctx, 7, user)
+	r.handleSettings(ctx, 7, user)
+	if !strings.Contains(lastText(), "What do you want to configure?") {
+		t.Fatalf("menu: %q", lastText())
+	}
+	r.startForm(ctx, 7, user, "test", 1)
+	last := stub.last()
+	if last.method != "editMessageText" || last.params["message_id"] != "1" {
+		t.Fatalf("first step not edited in place: %+v", last)
+	}
+	if lastText() != "Pick a number\n\nCurrent: 2" || !strings.Contains(last.params["reply_markup"], "✓ 2") {
+		t.Fatalf("first step: %q %s", lastText(), last.params["reply_markup"])
+	}
+	tapOn(99, "f:test:0:custom") // an older menu
+	if lastText() != i18n.En.T("menu.stale") {
+		t.Fatalf("old menu: %q", lastText())
+	}
+	tap("f:test:0:custom")
+	say("x")
+	if !strings.HasPrefix(lastText(), "not a number") {
+		t.Fatalf("invalid input: %q", lastText())
+	}
+	say("5")
+	if lastText() != "Type a name" || stub.last().method != "sendMessage" {
+		t.Fatalf("second step: %q", lastText())
+	}
+	say("Ann")
+	if lastText() != "n=5 name=Ann" {
+		t.Fatalf("summary: %q", lastText())
+	}
+
+	tap("f:test:0:=2") // a button of a screen already left
+	if lastText() != i18n.En.T("menu.stale") {
+		t.Fatalf("stale button: %q", lastText())
+	}
+
+	tap("f:test:2:back")
+	if lastText() != "Type a name\n\nCurrent: Ann" {
+		t.Fatalf("back from summary: %q", lastText())
+	}
+	say("Bob")
+	tap("f:test:2:ok")
+	if applied.Get("n") != "5" || applied.Get("name") != "Bob" {
+		t.Fatalf("applied %v", applied)
+	}
+	if !strings.HasPrefix(lastText(), "✅ saved\n\n⚙️ Settings") || stub.last().method != "editMessageText" {
+		t.Fatalf("after save: %q", lastText())
+	}
+	state, _ := r.getPending(ctx, 7, 7)
+	if st, ok := decodeFormState(state); !ok || st.Form != "" || st.Menu != 1 {
+		t.Fatalf("state after save: %q", state)
+	}
+}
+
+func TestIsNotModified(t *testing.T) {
+	err := &tgbotapi.Error{Code: 400, Message: "Bad Request: message is not modified: specified new message content and reply markup are exactly the same"}
+	if !isNotModified(err) {
+		t.Fatal("not modified error not recognised")
+	}
+	if isNotModified(&tgbotapi.Error{Code: 400, Message: "Bad Request: message to edit not found"}) || isNotModified(nil) {
+		t.Fatal("other error taken for not modified")
+	}
+}
+
+func TestFormState_RoundTrip(t *testing.T) {
+	st := formState{Form: "message", Step: 1, Typing: true, Menu: 42, Answers: url.Values{"message": {"a:b?c&d"}}}
+	got, ok := decodeFormState(st.encode())
+	if !ok || got.Form != st.Form || got.Step != 1 || !got.Typing || got.Menu != 42 || got.Answers.Get("message") != "a:b?c&d" {
+		t.Fatalf("round trip: %+v", got)
+	}
+	if _, ok := decodeFormState(pendingBroadcast); ok {
+		t.Fatal("non-form state decoded")
+	}
+}